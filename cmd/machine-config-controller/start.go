@@ -4,6 +4,7 @@ import (
 	"github.com/pkg/errors"
 	"context"
 	"flag"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/cmd/common"
@@ -17,6 +18,13 @@ import (
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	batchinformersv1 "k8s.io/client-go/informers/batch/v1"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/leaderelection"
 )
 
@@ -33,6 +41,36 @@ var (
 		templates  string
 
 		resourceLockNamespace string
+
+		nodeControllerHealthAddr       string
+		nodeControllerHealthStaleAfter time.Duration
+		nodeControllerDrainTimeout     time.Duration
+		nodeControllerGlobalMaxUnavail int
+		nodeControllerHonorManualEdits bool
+		nodeControllerPatchLatency     time.Duration
+		nodeControllerPrePullLookahead int
+		nodeControllerStartupGrace     time.Duration
+		nodeControllerQuarantine       int
+		nodeControllerRetrySteps       int
+		nodeControllerRetryInterval    time.Duration
+		nodeControllerRetryJitter      float64
+		nodeControllerScaleDownAnnos   []string
+		nodeControllerPausedWarnAfter  time.Duration
+		nodeControllerConflictEscalate int
+		nodeControllerUpdateCooldown   time.Duration
+		nodeControllerStatusDebounce   time.Duration
+		nodeControllerDefaultsCM       string
+		nodeControllerSummary          bool
+		nodeControllerNodeEvents       bool
+		nodeControllerMaintenanceRes   string
+		nodeControllerDrainNotRequired string
+		nodeControllerWatchPods        bool
+		nodeControllerWatchJobs        bool
+		nodeControllerResyncEndpoint   bool
+		nodeControllerServerSideApply  bool
+		nodeControllerFairnessWindow   time.Duration
+		nodeControllerFairnessBudget   time.Duration
+		nodeControllerCandidateJitter  time.Duration
 	}
 )
 
@@ -40,6 +78,35 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Path to the template files used for creating MachineConfig objects")
+	startCmd.PersistentFlags().StringVar(&startOpts.nodeControllerHealthAddr, "node-controller-health-addr", "", "Address to serve the node controller health endpoints on (disabled if empty)")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerHealthStaleAfter, "node-controller-health-stale-after", time.Hour, "Report the node controller unhealthy when no pool synced successfully for this long")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerDrainTimeout, "node-controller-drain-timeout", 30*time.Second, "How long the node controller waits for in-flight pool syncs to finish when stopping")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeControllerGlobalMaxUnavail, "node-controller-global-max-unavailable", 0, "Maximum number of nodes updating at once across all pools (unlimited if 0)")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerHonorManualEdits, "node-controller-honor-manual-edits", false, "Leave nodes whose desired config was edited by hand at that config instead of setting them back to the target of their pool")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerPatchLatency, "node-controller-patch-latency-threshold", 0, "Sync one pool at a time while node patches take longer than this on average (never if 0)")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeControllerPrePullLookahead, "node-controller-pre-pull-lookahead", 0, "Number of nodes due to update next in every pool that are asked to pre-pull the target config (disabled if 0)")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerStartupGrace, "node-controller-startup-grace-period", 0, "Delay the first sync of pools observed this long after the node controller starts until the period ends (disabled if 0)")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeControllerQuarantine, "node-controller-quarantine-threshold", 0, "Quarantine nodes that failed to apply the same config this many times in a row (never if 0)")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeControllerRetrySteps, "node-controller-update-retry-steps", 5, "Number of attempts of a node update conflicting with other writers")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerRetryInterval, "node-controller-update-retry-interval", 100*time.Millisecond, "Delay between attempts of a node update conflicting with other writers")
+	startCmd.PersistentFlags().Float64Var(&startOpts.nodeControllerRetryJitter, "node-controller-update-retry-jitter", 1.0, "Random extra delay between node update attempts, as a fraction of the interval")
+	startCmd.PersistentFlags().StringSliceVar(&startOpts.nodeControllerScaleDownAnnos, "node-controller-scale-down-annotations", nil, "Annotation keys marking nodes about to be removed by an autoscaler, which are not updated")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerPausedWarnAfter, "node-controller-paused-warn-after", 0, "Warn about pools paused for longer than this (never if 0)")
+	startCmd.PersistentFlags().IntVar(&startOpts.nodeControllerConflictEscalate, "node-controller-conflict-threshold", 0, "Warn about nodes whose desired config couldn't be set because of conflicts in this many syncs in a row (never if 0)")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerUpdateCooldown, "node-controller-update-cooldown", 0, "Leave nodes alone for this long after they completed an update (disabled if 0)")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerStatusDebounce, "node-controller-status-debounce", 0, "Recompute the status of a pool at most once in this window (disabled if 0)")
+	startCmd.PersistentFlags().StringVar(&startOpts.nodeControllerDefaultsCM, "node-controller-defaults-configmap", "", "Namespace/name of a ConfigMap with the maxUnavailable of pools that don't set one (disabled if empty)")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerSummary, "node-controller-candidate-summary", false, "Summarize how every sync picked the nodes to update in a JSON annotation of the pool")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerNodeEvents, "node-controller-node-events", false, "Also record the progress of node updates as events on the nodes")
+	startCmd.PersistentFlags().StringVar(&startOpts.nodeControllerMaintenanceRes, "node-controller-maintenance-resource", "", "resource.version.group of NodeMaintenance-style objects whose spec.nodeName is left alone, e.g. nodemaintenances.v1beta1.nodemaintenance.kubevirt.io (disabled if empty)")
+	startCmd.PersistentFlags().StringVar(&startOpts.nodeControllerDrainNotRequired, "node-controller-drain-not-required-annotation", "", "Annotation key opting nodes set to \"true\" out of draining, which is passed on to the daemon with their desired config (disabled if empty)")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerWatchPods, "node-controller-watch-pods", false, "Watch all pods so that pools preferring their least loaded nodes can count the pods of their nodes")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerWatchJobs, "node-controller-watch-jobs", false, "Watch all Jobs so that pools can hold back their rollout until their blocking Job completed")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerResyncEndpoint, "node-controller-resync-endpoint", false, "Also serve an unauthenticated /resync endpoint on the health address, resyncing every pool on POST")
+	startCmd.PersistentFlags().BoolVar(&startOpts.nodeControllerServerSideApply, "node-controller-server-side-apply", false, "Set the fields the node controller owns on nodes with server-side apply instead of strategic merge patches")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerFairnessWindow, "node-controller-fairness-window", time.Minute, "Window the worker time budget of every pool applies to, see --node-controller-fairness-budget")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerFairnessBudget, "node-controller-fairness-budget", 0, "Worker time a pool may use per window while other pools are queued before it is deferred (unlimited if 0)")
+	startCmd.PersistentFlags().DurationVar(&startOpts.nodeControllerCandidateJitter, "node-controller-candidate-jitter", 0, "Wait a random duration between this and twice this between the nodes set to a new config in the same sync (disabled if 0)")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -91,6 +158,80 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 func createControllers(ctx *controllercommon.ControllerContext) []controllercommon.Controller {
 	var controllers []controllercommon.Controller
 
+	defaultsNamespace, defaultsName, err := cache.SplitMetaNamespaceKey(startOpts.nodeControllerDefaultsCM)
+	if err == nil && defaultsName != "" && defaultsNamespace == "" {
+		err = errors.New("expected namespace/name")
+	}
+	if err != nil {
+		controllercommon.WriteTerminationError(errors.Wrapf(err, "Parsing --node-controller-defaults-configmap"))
+	}
+	// Only the defaults ConfigMap is watched, not every ConfigMap of the cluster.
+	defaultsInformers := informers.NewSharedInformerFactoryWithOptions(ctx.ClientBuilder.KubeClientOrDie("node-update-controller"), 0,
+		informers.WithNamespace(defaultsNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", defaultsName).String()
+		}),
+	)
+
+	if startOpts.nodeControllerRetrySteps < 1 {
+		controllercommon.WriteTerminationError(errors.Wrapf(errors.Errorf("expected at least 1 step, got %d", startOpts.nodeControllerRetrySteps), "Parsing --node-controller-update-retry-steps"))
+	}
+
+	var maintenance node.NodeMaintenanceLister
+	if startOpts.nodeControllerMaintenanceRes != "" {
+		gvr, _ := schema.ParseResourceArg(startOpts.nodeControllerMaintenanceRes)
+		if gvr == nil {
+			controllercommon.WriteTerminationError(errors.Wrapf(errors.New("expected resource.version.group"), "Parsing --node-controller-maintenance-resource"))
+		}
+		maintenance = node.NewNodeMaintenanceInformer(ctx.ClientBuilder.KubeClientOrDie("node-update-controller").Discovery().RESTClient(), *gvr)
+	}
+
+	var pods coreinformersv1.PodInformer
+	if startOpts.nodeControllerWatchPods {
+		pods = ctx.KubeInformerFactory.Core().V1().Pods()
+	}
+
+	var jobs batchinformersv1.JobInformer
+	if startOpts.nodeControllerWatchJobs {
+		jobs = ctx.KubeInformerFactory.Batch().V1().Jobs()
+	}
+
+	nodeOptions := []node.Option{
+		node.WithHealthServer(startOpts.nodeControllerHealthAddr, startOpts.nodeControllerHealthStaleAfter),
+		node.WithResyncEndpoint(startOpts.nodeControllerResyncEndpoint),
+		node.WithDrainTimeout(startOpts.nodeControllerDrainTimeout),
+		node.WithGlobalMaxUnavailable(startOpts.nodeControllerGlobalMaxUnavail),
+		node.WithHonorManualDesiredConfigEdits(startOpts.nodeControllerHonorManualEdits),
+		node.WithPatchLatencyThreshold(startOpts.nodeControllerPatchLatency),
+		node.WithPrePullLookahead(startOpts.nodeControllerPrePullLookahead),
+		node.WithStartupGracePeriod(startOpts.nodeControllerStartupGrace),
+		node.WithQuarantineThreshold(startOpts.nodeControllerQuarantine),
+		node.WithNodeUpdateBackoff(wait.Backoff{
+			Steps:    startOpts.nodeControllerRetrySteps,
+			Duration: startOpts.nodeControllerRetryInterval,
+			Jitter:   startOpts.nodeControllerRetryJitter,
+		}),
+		node.WithScaleDownAnnotations(startOpts.nodeControllerScaleDownAnnos),
+		node.WithPausedWarnAfter(startOpts.nodeControllerPausedWarnAfter),
+		node.WithConflictEscalation(startOpts.nodeControllerConflictEscalate),
+		node.WithUpdateCooldown(startOpts.nodeControllerUpdateCooldown),
+		node.WithStatusDebounce(startOpts.nodeControllerStatusDebounce),
+		node.WithDefaultsConfigMap(defaultsInformers.Core().V1().ConfigMaps(), defaultsNamespace, defaultsName),
+		node.WithCandidateSummary(startOpts.nodeControllerSummary),
+		node.WithJobInformer(jobs),
+		node.WithNodeEvents(startOpts.nodeControllerNodeEvents),
+		node.WithPodInformer(pods),
+		node.WithNodeMaintenanceLister(maintenance),
+		node.WithDrainNotRequiredAnnotation(startOpts.nodeControllerDrainNotRequired),
+		node.WithCandidateJitter(startOpts.nodeControllerCandidateJitter),
+	}
+	if startOpts.nodeControllerServerSideApply {
+		nodeOptions = append(nodeOptions, node.WithServerSideApply())
+	}
+	if startOpts.nodeControllerFairnessBudget > 0 {
+		nodeOptions = append(nodeOptions, node.WithPoolFairness(startOpts.nodeControllerFairnessWindow, startOpts.nodeControllerFairnessBudget))
+	}
+
 	controllers = append(controllers,
 		// Our primary MCs come from here
 		template.New(
@@ -134,11 +275,15 @@ func createControllers(ctx *controllercommon.ControllerContext) []controllercomm
 		// The node controller consumes data written by the above
 		node.New(
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.KubeInformerFactory.Core().V1().Nodes(),
 			ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
+			nodeOptions...,
 		),
 	)
+	// Started here as it isn't part of the controller context.
+	defaultsInformers.Start(ctx.Stop)
 
 	return controllers
 }
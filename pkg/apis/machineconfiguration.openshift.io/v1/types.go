@@ -304,8 +304,312 @@ type MachineConfigPoolSpec struct {
 
 	// The targeted MachineConfig object for the machine config pool.
 	Configuration MachineConfigPoolStatusConfiguration `json:"configuration"`
+
+	// SkipCordonedNodes keeps nodes marked unschedulable, e.g. by an admin for maintenance,
+	// from being selected for an update. They still count as unavailable.
+	// +optional
+	SkipCordonedNodes bool `json:"skipCordonedNodes,omitempty"`
+
+	// RollbackTo names a MachineConfig, usually the last known good one, that the nodes of the
+	// pool are moved back to instead of Configuration, honoring maxUnavailable as usual.
+	// Clearing it resumes updating to Configuration.
+	// +optional
+	RollbackTo string `json:"rollbackTo,omitempty"`
+
+	// UnavailabilityPolicy decides which nodes count against maxUnavailable.
+	// Defaults to Strict.
+	// +optional
+	UnavailabilityPolicy UnavailabilityPolicy `json:"unavailabilityPolicy,omitempty"`
+
+	// StaggerByLabel names a node label splitting the pool into groups by its value.
+	// When set, the nodes of one group are all updated before the next group starts,
+	// in the order of the label values. Nodes without the label form their own group.
+	// +optional
+	StaggerByLabel string `json:"staggerByLabel,omitempty"`
+
+	// RetainPreviousConfig keeps the last this many nodes of the pool on their previous config
+	// during a rollout, as a live rollback reservoir. Clearing it approves finishing the rollout.
+	// +optional
+	RetainPreviousConfig int32 `json:"retainPreviousConfig,omitempty"`
+
+	// MaxUnavailableScaling derives maxUnavailable from the number of nodes in the pool
+	// instead of taking it from MaxUnavailable. Defaults to Fixed.
+	// +optional
+	MaxUnavailableScaling MaxUnavailableScaling `json:"maxUnavailableScaling,omitempty"`
+
+	// IgnoreUnavailableTaints lists taint keys, e.g. of special-purpose nodes that are
+	// often not ready. Nodes carrying any of them neither count against maxUnavailable
+	// nor are selected for an update.
+	// +optional
+	IgnoreUnavailableTaints []string `json:"ignoreUnavailableTaints,omitempty"`
+
+	// PerNodeSoak updates the nodes of the pool one at a time, regardless of maxUnavailable,
+	// and waits this long after a node finished updating and became ready before starting
+	// the next one.
+	// +optional
+	PerNodeSoak *metav1.Duration `json:"perNodeSoak,omitempty"`
+
+	// ConfigSequence lists MachineConfigs, e.g. preparing a feature the target config relies on,
+	// that every node of the pool is moved through in order before Configuration. A node only
+	// moves on to the next config once it finished updating to the previous one.
+	// +optional
+	ConfigSequence []string `json:"configSequence,omitempty"`
+
+	// RequireReadyForUpdated only reports the pool Updated once every node, on top of running
+	// the target config, passes the default node readiness check: NodeReady true, disk and
+	// network available and schedulable. This holds even if the controller is configured with
+	// a more lenient readiness check for picking nodes to update.
+	// +optional
+	RequireReadyForUpdated bool `json:"requireReadyForUpdated,omitempty"`
+
+	// MaxDegraded is the number or percentage of nodes failing to apply the target config at
+	// which the rollout of the pool halts: no more nodes are updated and the pool is reported
+	// Degraded until enough of them recover. Percentages are rounded up. Unset never halts.
+	// +optional
+	MaxDegraded *intstr.IntOrString `json:"maxDegraded,omitempty"`
+
+	// PropagateAnnotations lists annotation keys of the pool, e.g. for inventory tooling, that
+	// are copied to every node of the pool. Removing a key, or the annotation from the pool,
+	// removes it from the nodes again. Keys of the machineconfiguration.openshift.io domain
+	// can't be propagated.
+	// +optional
+	PropagateAnnotations []string `json:"propagateAnnotations,omitempty"`
+
+	// CompletionSoak is how long all nodes of the pool must stay updated and ready before the
+	// pool is reported Completed, e.g. to gate changes depending on the rollout. A node
+	// regressing during the soak restarts it.
+	// +optional
+	CompletionSoak *metav1.Duration `json:"completionSoak,omitempty"`
+
+	// CountCordonedUnavailable counts nodes that are cordoned while their MachineConfigDaemon
+	// is working or reports done as unavailable, even if they pass the readiness check, e.g.
+	// between the MachineConfigDaemon reporting done and uncordoning the node.
+	// +optional
+	CountCordonedUnavailable bool `json:"countCordonedUnavailable,omitempty"`
+
+	// UpdateHooks coordinates node updates with pre- and post-update hooks, e.g. a pre-drain
+	// script and a post-reboot validation run by an agent on the node. Before updating a
+	// node, the controller sets its pre-update-hook annotation to the config and waits for
+	// the agent to set pre-update-done to the same config. An updated node only counts as
+	// updated once the agent set post-update-done to its config.
+	// +optional
+	UpdateHooks bool `json:"updateHooks,omitempty"`
+
+	// CordonOnSelect cordons nodes in the same patch that sets their desired config, so
+	// that no new pods are scheduled to them before the MachineConfigDaemon picks up the
+	// update and cordons them itself. The daemon uncordons them once the update completed.
+	// +optional
+	CordonOnSelect bool `json:"cordonOnSelect,omitempty"`
+
+	// DrainPolicy has the controller drain nodes with this policy before setting their
+	// desired config, like kubectl drain with the corresponding flags. Unset, nodes are
+	// only drained by the MachineConfigDaemon once it picks up the update.
+	// +optional
+	DrainPolicy *MachineConfigPoolDrainPolicy `json:"drainPolicy,omitempty"`
+
+	// MaxUnavailableRamp raises the number of nodes updated at once as a rollout proves
+	// stable, instead of MaxUnavailable. Every rollout starts at the initial value and
+	// resets to it while nodes fail to apply the config.
+	// +optional
+	MaxUnavailableRamp *MachineConfigPoolMaxUnavailableRamp `json:"maxUnavailableRamp,omitempty"`
+
+	// BlockingJob names a Job that must have completed before any node of the pool
+	// starts an update, e.g. a backup taken ahead of reboots. While the Job is running,
+	// has failed or doesn't exist, new node updates are held back.
+	// +optional
+	BlockingJob *MachineConfigPoolJobReference `json:"blockingJob,omitempty"`
+
+	// VerifyUpdates holds nodes that completed their update to the target config in a
+	// verifying state until an external verifier, e.g. running smoke tests, sets their
+	// verified annotation to "true". Verifying nodes don't count as updated and keep
+	// counting as unavailable.
+	// +optional
+	VerifyUpdates bool `json:"verifyUpdates,omitempty"`
+
+	// VerificationTimeout is how long a node may stay verifying before it is marked
+	// degraded. Unset, nodes wait for their verification indefinitely.
+	// +optional
+	VerificationTimeout *metav1.Duration `json:"verificationTimeout,omitempty"`
+
+	// MinHealthy is the number of ready nodes that aren't updating the pool keeps at all
+	// times: no node is selected for an update if that leaves fewer of them, whatever
+	// maxUnavailable allows. Nodes that aren't healthy may still be updated. Zero, the
+	// default, doesn't limit updates beyond maxUnavailable.
+	// +optional
+	MinHealthy int32 `json:"minHealthy,omitempty"`
+
+	// PreferLeastLoaded updates the nodes running the fewest pods first, so that the
+	// first drains of a rollout disrupt as few workloads as possible. DaemonSet and
+	// mirror pods, which aren't evicted by drains, don't count.
+	// +optional
+	PreferLeastLoaded bool `json:"preferLeastLoaded,omitempty"`
+
+	// RequireApproval holds back the rollout of every new target config until an operator
+	// approves it by setting the approved-config annotation of the pool to its name. While
+	// approval is pending, the pool reports the ApprovalPending condition.
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
+	// NotReadyGrace lets nodes reporting NotReady for some reasons, e.g. a short network
+	// outage, keep counting as ready for a while instead of halting the rollout right
+	// away. Nodes NotReady for any other reason count as unready immediately.
+	// +optional
+	NotReadyGrace *MachineConfigPoolNotReadyGrace `json:"notReadyGrace,omitempty"`
+
+	// TargetConfigDebounce is how long a new target config must stay in place before
+	// nodes are moved to it, so that a change reverted within that time, e.g. by a quick
+	// GitOps revert, doesn't start any node update. Unset, new target configs are rolled
+	// out right away.
+	// +optional
+	TargetConfigDebounce *metav1.Duration `json:"targetConfigDebounce,omitempty"`
+
+	// RequiredNodeConditions are node condition types, e.g. a GPU health condition, the
+	// nodes of the pool must report True on top of the default readiness checks to count
+	// as ready. A node not reporting one of them isn't ready.
+	// +optional
+	RequiredNodeConditions []corev1.NodeConditionType `json:"requiredNodeConditions,omitempty"`
+
+	// NodeSelectors are additional node selectors of the pool: a node is in the pool if it
+	// matches NodeSelector or any of these, e.g. to select the nodes of either of two
+	// racks. Empty selectors are invalid, like an empty NodeSelector.
+	// +optional
+	NodeSelectors []*metav1.LabelSelector `json:"nodeSelectors,omitempty"`
+
+	// ShadowValidation validates every new target config on a single shadow node of the
+	// pool before it is rolled out to the other nodes. The shadow node must finish its
+	// update and stay ready for the soak for the config to be validated; if it fails to
+	// apply the config, the config is rejected and isn't rolled out.
+	// +optional
+	ShadowValidation *MachineConfigPoolShadowValidation `json:"shadowValidation,omitempty"`
+
+	// ConfigurationSelector selects the target config of the pool by label instead of by
+	// name, e.g. by a generation label set by a GitOps pipeline that can't predict the
+	// names of rendered configs. The newest MachineConfig matching it is the target
+	// config, and Configuration is ignored. Unset, Configuration names the target config.
+	// +optional
+	ConfigurationSelector *metav1.LabelSelector `json:"configurationSelector,omitempty"`
+
+	// SkipNodesUnderPressure keeps nodes reporting DiskPressure, MemoryPressure or
+	// PIDPressure from being selected for an update until the pressure clears, since
+	// rebooting them could make matters worse. They don't count as unavailable for it.
+	// +optional
+	SkipNodesUnderPressure bool `json:"skipNodesUnderPressure,omitempty"`
+
+	// RolloutPriority orders pools competing for the node controller's global
+	// maxUnavailable: nodes of pools with a higher priority are given the free slots
+	// first, and pools with a lower one wait for what is left. Pools default to 0, all
+	// sharing the budget first come, first served.
+	// +optional
+	RolloutPriority int32 `json:"rolloutPriority,omitempty"`
+}
+
+// MachineConfigPoolShadowValidation describes how the target configs of a pool are
+// validated on a shadow node.
+type MachineConfigPoolShadowValidation struct {
+	// NodeSelector selects the nodes of the pool that may serve as shadow node, e.g.
+	// nodes running no critical workloads. Unset, any node of the pool may.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// Soak is how long the shadow node must stay ready after updating to the target
+	// config for the config to be validated.
+	Soak metav1.Duration `json:"soak"`
+}
+
+// MachineConfigPoolJobReference names a Job.
+type MachineConfigPoolJobReference struct {
+	// Namespace is the namespace of the Job.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the Job.
+	Name string `json:"name"`
+}
+
+// MachineConfigPoolMaxUnavailableRamp describes how the number of nodes of a pool updated
+// at once grows during a rollout.
+type MachineConfigPoolMaxUnavailableRamp struct {
+	// Initial is the number of nodes updated at once when a rollout starts.
+	Initial int32 `json:"initial"`
+
+	// Ceiling is the highest number of nodes updated at once.
+	Ceiling int32 `json:"ceiling"`
+
+	// Step is added to the number of nodes updated at once every SuccessesPerStep nodes
+	// that completed their update and are ready.
+	Step int32 `json:"step"`
+
+	// SuccessesPerStep is the number of nodes that must complete their update and stay
+	// ready for the next step.
+	SuccessesPerStep int32 `json:"successesPerStep"`
+}
+
+// MachineConfigPoolNotReadyGrace describes which NotReady nodes of a pool keep counting
+// as ready, and for how long.
+type MachineConfigPoolNotReadyGrace struct {
+	// Reasons are the reasons of the NodeReady condition of a node the grace period
+	// applies to, e.g. KubeletNotReady.
+	Reasons []string `json:"reasons"`
+
+	// Period is how long after its NodeReady condition last changed a node NotReady for
+	// one of Reasons still counts as ready.
+	Period metav1.Duration `json:"period"`
+}
+
+// MachineConfigPoolDrainPolicy decides how the pods of a node are evicted before it is
+// updated.
+type MachineConfigPoolDrainPolicy struct {
+	// GracePeriodSeconds is given to every pod to terminate gracefully. Unset, the
+	// grace period of the pod is used.
+	// +optional
+	GracePeriodSeconds *int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// Timeout bounds how long evicting the pods of a node may take. Unset, it defaults
+	// to one hour.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// ForceOnTimeout also drains pods not managed by a controller, and deletes the pods
+	// left once Timeout elapsed without eviction, and thus regardless of
+	// PodDisruptionBudgets, with GracePeriodSeconds. Otherwise the drain fails, the node
+	// isn't updated, and the drain is retried later.
+	// +optional
+	ForceOnTimeout bool `json:"forceOnTimeout,omitempty"`
+
+	// IgnoreDaemonSets drains nodes running DaemonSet pods, leaving those pods alone.
+	// Otherwise such nodes can't be drained.
+	// +optional
+	IgnoreDaemonSets bool `json:"ignoreDaemonSets,omitempty"`
+
+	// DeleteEmptyDirData drains nodes running pods with emptyDir volumes, whose data is
+	// lost. Otherwise such nodes can't be drained.
+	// +optional
+	DeleteEmptyDirData bool `json:"deleteEmptyDirData,omitempty"`
 }
 
+// MaxUnavailableScaling decides how the maxUnavailable of a pool follows its size.
+type MaxUnavailableScaling string
+
+const (
+	// MaxUnavailableScalingFixed uses MaxUnavailable as is. This is the default.
+	MaxUnavailableScalingFixed MaxUnavailableScaling = "Fixed"
+	// MaxUnavailableScalingSqrt uses the square root of the number of nodes, rounded down.
+	MaxUnavailableScalingSqrt MaxUnavailableScaling = "Sqrt"
+	// MaxUnavailableScalingLog2 uses the binary logarithm of the number of nodes, rounded down.
+	MaxUnavailableScalingLog2 MaxUnavailableScaling = "Log2"
+)
+
+// UnavailabilityPolicy decides which nodes of a pool count as unavailable.
+type UnavailabilityPolicy string
+
+const (
+	// UnavailabilityPolicyStrict counts nodes that are updating as well as nodes that are
+	// not ready, e.g. because they haven't come back after their update, as unavailable.
+	// This is the default.
+	UnavailabilityPolicyStrict UnavailabilityPolicy = "Strict"
+	// UnavailabilityPolicyUpdateOnly only counts nodes that are updating as unavailable.
+	UnavailabilityPolicyUpdateOnly UnavailabilityPolicy = "UpdateOnly"
+)
+
 // MachineConfigPoolStatus is the status for MachineConfigPool resource.
 type MachineConfigPoolStatus struct {
 	// The generation observed by the controller.
@@ -325,12 +629,91 @@ type MachineConfigPoolStatus struct {
 
 	// Total number of unavailable (non-ready) machines targeted by the pool.
 	// A node is marked unavailable if it is in updating state or NodeReady condition is false.
+	// With the UpdateOnly unavailability policy only nodes in updating state are counted.
 	UnavailableMachineCount int32 `json:"unavailableMachineCount"`
 
 	// Total number of machines marked degraded (or unreconcilable).
 	// A node is marked degraded if applying a configuration failed..
 	DegradedMachineCount int32 `json:"degradedMachineCount"`
 
+	// UnresolvedMachineCount is the number of machines selected by the pool whose pool can't
+	// be determined, e.g. because they are selected by both the master and a custom pool.
+	// They count as unavailable and aren't updated until the ambiguity is resolved.
+	// +optional
+	UnresolvedMachineCount int32 `json:"unresolvedMachineCount,omitempty"`
+
+	// EstimatedTimeRemaining is a rough estimate of how long the in-progress update of the pool
+	// will take to complete, based on how long recent node updates took. It is only an estimate
+	// and is unset when no update is in progress or no node update has been observed yet.
+	// +optional
+	EstimatedTimeRemaining *metav1.Duration `json:"estimatedTimeRemaining,omitempty"`
+
+	// LastNodeUpdateTime is when a node of the pool was last seen to finish updating and
+	// become ready. It is only tracked when spec.perNodeSoak is set.
+	// +optional
+	LastNodeUpdateTime *metav1.Time `json:"lastNodeUpdateTime,omitempty"`
+
+	// LastConfigChangeTime is when the target config of the pool was last seen changing, or
+	// when the pool was created if no change was observed yet.
+	// +optional
+	LastConfigChangeTime *metav1.Time `json:"lastConfigChangeTime,omitempty"`
+
+	// PausedSince is when the pool was paused. It is unset while the pool isn't paused.
+	// +optional
+	PausedSince *metav1.Time `json:"pausedSince,omitempty"`
+
+	// UpdatedSince is since when all nodes of the pool are updated and ready without
+	// interruption. It is only tracked when spec.completionSoak is set.
+	// +optional
+	UpdatedSince *metav1.Time `json:"updatedSince,omitempty"`
+
+	// ConfigChange describes what changes between the current and the target config of
+	// the pool. It is unset while no update is in progress.
+	// +optional
+	ConfigChange *MachineConfigPoolConfigChange `json:"configChange,omitempty"`
+
+	// PausedNodes lists the nodes of the pool paused individually with the
+	// machineconfiguration.openshift.io/paused annotation. They aren't updated and don't
+	// count against maxUnavailable.
+	// +optional
+	PausedNodes []string `json:"pausedNodes,omitempty"`
+
+	// RolloutHistory records the recent rollouts of the pool, most recent last. Only the
+	// last 10 are kept.
+	// +optional
+	RolloutHistory []MachineConfigPoolRollout `json:"rolloutHistory,omitempty"`
+
+	// MaxUnavailableRamp reports how far the current rollout ramped up, if the pool has a
+	// maxUnavailable ramp.
+	// +optional
+	MaxUnavailableRamp *MachineConfigPoolMaxUnavailableRampStatus `json:"maxUnavailableRamp,omitempty"`
+
+	// LastActedConfig is the last target config nodes were moved to, if the pool has a
+	// targetConfigDebounce.
+	// +optional
+	LastActedConfig string `json:"lastActedConfig,omitempty"`
+
+	// ResolvedConfig is the config spec.configurationSelector last resolved to, which
+	// changes to the target config of the pool are detected against. It is unset for
+	// pools without a configuration selector.
+	// +optional
+	ResolvedConfig string `json:"resolvedConfig,omitempty"`
+
+	// Zones breaks down the progress of the pool by the topology.kubernetes.io/zone label
+	// of its nodes, ordered by zone. Nodes without the label aren't counted.
+	// +optional
+	Zones []MachineConfigPoolZoneStatus `json:"zones,omitempty"`
+
+	// ShadowValidation reports the validation of the latest target config on the shadow
+	// node, if the pool has spec.shadowValidation.
+	// +optional
+	ShadowValidation *MachineConfigPoolShadowValidationStatus `json:"shadowValidation,omitempty"`
+
+	// Phase sums up the state of the pool for automation to switch on. The conditions
+	// tell the details.
+	// +optional
+	Phase MachineConfigPoolPhase `json:"phase,omitempty"`
+
 	// Represents the latest available observations of current state.
 	Conditions []MachineConfigPoolCondition `json:"conditions"`
 }
@@ -345,6 +728,118 @@ type MachineConfigPoolStatusConfiguration struct {
 	Source []corev1.ObjectReference `json:"source,omitempty"`
 }
 
+// MachineConfigPoolConfigChange describes what changes between two configs of a pool.
+type MachineConfigPoolConfigChange struct {
+	// From is the name of the config the pool is updating from.
+	From string `json:"from"`
+
+	// To is the name of the config the pool is updating to.
+	To string `json:"to"`
+
+	// RebootRequired is set if updating requires rebooting the nodes, which is the case
+	// for any change to the OS image, the kernel arguments or the Ignition config.
+	RebootRequired bool `json:"rebootRequired"`
+
+	// ChangedFields lists the fields of the config spec that differ, e.g. osImageURL
+	// or config.storage.
+	// +optional
+	ChangedFields []string `json:"changedFields,omitempty"`
+}
+
+// MachineConfigPoolRollout records a rollout of a pool to a config.
+type MachineConfigPoolRollout struct {
+	// Config is the name of the config rolled out.
+	Config string `json:"config"`
+
+	// StartTime is when the target config of the pool changed to Config.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is when all nodes of the pool were updated to Config. It is unset while
+	// the rollout is in progress, or if it was superseded by another one.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// FailedMachineCount is the highest number of nodes seen degraded during the rollout.
+	FailedMachineCount int32 `json:"failedMachineCount"`
+}
+
+// MachineConfigPoolMaxUnavailableRampStatus is the progress of the maxUnavailable ramp of a
+// rollout.
+type MachineConfigPoolMaxUnavailableRampStatus struct {
+	// Config is the config being rolled out.
+	Config string `json:"config"`
+
+	// Baseline is the number of updated and ready nodes when the ramp last started over,
+	// at the start of the rollout or after a node failed. Only nodes on top of it count
+	// towards the next step.
+	Baseline int32 `json:"baseline"`
+
+	// Current is the number of nodes currently updated at once.
+	Current int32 `json:"current"`
+}
+
+// MachineConfigPoolZoneStatus is the progress of the nodes of a pool in a zone.
+type MachineConfigPoolZoneStatus struct {
+	// Zone is the value of the topology.kubernetes.io/zone label of the nodes.
+	Zone string `json:"zone"`
+
+	// MachineCount is the number of nodes of the pool in the zone.
+	MachineCount int32 `json:"machineCount"`
+
+	// UpdatedMachineCount is the number of those nodes updated to the target config.
+	UpdatedMachineCount int32 `json:"updatedMachineCount"`
+}
+
+// MachineConfigPoolShadowValidationStatus is the validation of a config on a shadow node.
+type MachineConfigPoolShadowValidationStatus struct {
+	// Config is the name of the config being validated.
+	Config string `json:"config"`
+
+	// Node is the name of the shadow node the config is validated on.
+	// +optional
+	Node string `json:"node,omitempty"`
+
+	// State is how far the validation got.
+	State ShadowValidationState `json:"state"`
+
+	// ReadySince is since when the shadow node is updated to Config and ready without
+	// interruption.
+	// +optional
+	ReadySince *metav1.Time `json:"readySince,omitempty"`
+}
+
+// ShadowValidationState is the state of the validation of a config on a shadow node.
+type ShadowValidationState string
+
+const (
+	// ShadowValidationValidating means the shadow node is updating to the config or soaking
+	// it. The rest of the pool isn't updated meanwhile.
+	ShadowValidationValidating ShadowValidationState = "Validating"
+	// ShadowValidationValidated means the shadow node stayed ready on the config for the
+	// soak, and the config is rolled out to the rest of the pool.
+	ShadowValidationValidated ShadowValidationState = "Validated"
+	// ShadowValidationRejected means the shadow node failed to apply the config, which
+	// isn't rolled out any further.
+	ShadowValidationRejected ShadowValidationState = "Rejected"
+)
+
+// MachineConfigPoolPhase is the overall phase of a pool.
+type MachineConfigPoolPhase string
+
+const (
+	// MachineConfigPoolPhaseUpdating means some nodes of the pool aren't updated and ready
+	// on its target config yet.
+	MachineConfigPoolPhaseUpdating MachineConfigPoolPhase = "Updating"
+	// MachineConfigPoolPhaseUpdated means all nodes of the pool are updated and ready on its
+	// target config.
+	MachineConfigPoolPhaseUpdated MachineConfigPoolPhase = "Updated"
+	// MachineConfigPoolPhaseDegraded means the pool is degraded. With spec.maxDegraded,
+	// nodes failing to apply the target config only degrade it once they reach it.
+	MachineConfigPoolPhaseDegraded MachineConfigPoolPhase = "Degraded"
+	// MachineConfigPoolPhasePaused means the pool is paused, whatever the state of its nodes.
+	MachineConfigPoolPhasePaused MachineConfigPoolPhase = "Paused"
+)
+
 // MachineConfigPoolCondition contains condition information for an MachineConfigPool.
 type MachineConfigPoolCondition struct {
 	// Type of the condition, currently ('Done', 'Updating', 'Failed').
@@ -381,8 +876,44 @@ const (
 	MachineConfigPoolNodeDegraded MachineConfigPoolConditionType = "NodeDegraded"
 	// MachineConfigPoolRenderDegraded means the rendered configuration for the pool cannot be generated because of an error
 	MachineConfigPoolRenderDegraded MachineConfigPoolConditionType = "RenderDegraded"
+	// MachineConfigPoolSyncDegraded means the node controller failed to sync the pool; the reason
+	// names the class of the failure.
+	MachineConfigPoolSyncDegraded MachineConfigPoolConditionType = "SyncDegraded"
 	// MachineConfigPoolDegraded is the overall status of the pool based, today, on whether we fail with NodeDegraded or RenderDegraded
 	MachineConfigPoolDegraded MachineConfigPoolConditionType = "Degraded"
+	// MachineConfigPoolMaxUnavailableClamped means the requested maxUnavailable of the master pool
+	// was lowered to avoid losing etcd quorum.
+	MachineConfigPoolMaxUnavailableClamped MachineConfigPoolConditionType = "MaxUnavailableClamped"
+	// MachineConfigPoolRolloutGated means the controller's rollout gate is holding back
+	// new node updates of the pool; the message carries the gate's reason.
+	MachineConfigPoolRolloutGated MachineConfigPoolConditionType = "RolloutGated"
+	// MachineConfigPoolRollingBack means the pool is being rolled back to spec.rollbackTo;
+	// the message lists the nodes still to revert.
+	MachineConfigPoolRollingBack MachineConfigPoolConditionType = "RollingBack"
+	// MachineConfigPoolRetainingPreviousConfig means the pool holds nodes on their previous config
+	// because of spec.retainPreviousConfig, awaiting approval to finish the rollout.
+	MachineConfigPoolRetainingPreviousConfig MachineConfigPoolConditionType = "RetainingPreviousConfig"
+	// MachineConfigPoolSelectorConflict means the node selector of the pool can select the same
+	// nodes as another pool in a combination that leaves those nodes without a pool.
+	MachineConfigPoolSelectorConflict MachineConfigPoolConditionType = "SelectorConflict"
+	// MachineConfigPoolPausedTooLong means the pool has been paused for longer than the node
+	// controller's threshold, and is likely drifting from its target config unintentionally.
+	MachineConfigPoolPausedTooLong MachineConfigPoolConditionType = "PausedTooLong"
+	// MachineConfigPoolCompleted means all nodes of the pool have been updated and ready for
+	// spec.completionSoak. It is only set when spec.completionSoak is.
+	MachineConfigPoolCompleted MachineConfigPoolConditionType = "Completed"
+	// MachineConfigPoolJobGated means spec.blockingJob of the pool holds back new node updates;
+	// the reason tells whether the Job is running, failed or doesn't exist.
+	MachineConfigPoolJobGated MachineConfigPoolConditionType = "JobGated"
+	// MachineConfigPoolApprovalPending means the pool requires approval of its target config
+	// and holds back new node updates until it is approved.
+	MachineConfigPoolApprovalPending MachineConfigPoolConditionType = "ApprovalPending"
+	// MachineConfigPoolMaxUnavailableExceedsPoolSize means the maxUnavailable of the pool is
+	// larger than the pool, which is likely a mistake. It is purely advisory.
+	MachineConfigPoolMaxUnavailableExceedsPoolSize MachineConfigPoolConditionType = "MaxUnavailableExceedsPoolSize"
+	// MachineConfigPoolNoMatchingNodes means the node selectors of the pool match no node,
+	// which is likely a mistake such as a typo in a label. It is purely advisory.
+	MachineConfigPoolNoMatchingNodes MachineConfigPoolConditionType = "NoMatchingNodes"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -582,6 +582,69 @@ func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolConfigChange) DeepCopyInto(out *MachineConfigPoolConfigChange) {
+	*out = *in
+	if in.ChangedFields != nil {
+		in, out := &in.ChangedFields, &out.ChangedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolConfigChange.
+func (in *MachineConfigPoolConfigChange) DeepCopy() *MachineConfigPoolConfigChange {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolConfigChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolDrainPolicy) DeepCopyInto(out *MachineConfigPoolDrainPolicy) {
+	*out = *in
+	if in.GracePeriodSeconds != nil {
+		in, out := &in.GracePeriodSeconds, &out.GracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolDrainPolicy.
+func (in *MachineConfigPoolDrainPolicy) DeepCopy() *MachineConfigPoolDrainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolDrainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolJobReference) DeepCopyInto(out *MachineConfigPoolJobReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolJobReference.
+func (in *MachineConfigPoolJobReference) DeepCopy() *MachineConfigPoolJobReference {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolJobReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
 	*out = *in
@@ -615,6 +678,123 @@ func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolMaxUnavailableRamp) DeepCopyInto(out *MachineConfigPoolMaxUnavailableRamp) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolMaxUnavailableRamp.
+func (in *MachineConfigPoolMaxUnavailableRamp) DeepCopy() *MachineConfigPoolMaxUnavailableRamp {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolMaxUnavailableRamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolMaxUnavailableRampStatus) DeepCopyInto(out *MachineConfigPoolMaxUnavailableRampStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolMaxUnavailableRampStatus.
+func (in *MachineConfigPoolMaxUnavailableRampStatus) DeepCopy() *MachineConfigPoolMaxUnavailableRampStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolMaxUnavailableRampStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolNotReadyGrace) DeepCopyInto(out *MachineConfigPoolNotReadyGrace) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Period = in.Period
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolNotReadyGrace.
+func (in *MachineConfigPoolNotReadyGrace) DeepCopy() *MachineConfigPoolNotReadyGrace {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolNotReadyGrace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolRollout) DeepCopyInto(out *MachineConfigPoolRollout) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolRollout.
+func (in *MachineConfigPoolRollout) DeepCopy() *MachineConfigPoolRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolShadowValidation) DeepCopyInto(out *MachineConfigPoolShadowValidation) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Soak = in.Soak
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolShadowValidation.
+func (in *MachineConfigPoolShadowValidation) DeepCopy() *MachineConfigPoolShadowValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolShadowValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolShadowValidationStatus) DeepCopyInto(out *MachineConfigPoolShadowValidationStatus) {
+	*out = *in
+	if in.ReadySince != nil {
+		in, out := &in.ReadySince, &out.ReadySince
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolShadowValidationStatus.
+func (in *MachineConfigPoolShadowValidationStatus) DeepCopy() *MachineConfigPoolShadowValidationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolShadowValidationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
 	*out = *in
@@ -634,6 +814,92 @@ func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
 		**out = **in
 	}
 	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.IgnoreUnavailableTaints != nil {
+		in, out := &in.IgnoreUnavailableTaints, &out.IgnoreUnavailableTaints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PerNodeSoak != nil {
+		in, out := &in.PerNodeSoak, &out.PerNodeSoak
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ConfigSequence != nil {
+		in, out := &in.ConfigSequence, &out.ConfigSequence
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxDegraded != nil {
+		in, out := &in.MaxDegraded, &out.MaxDegraded
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.PropagateAnnotations != nil {
+		in, out := &in.PropagateAnnotations, &out.PropagateAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletionSoak != nil {
+		in, out := &in.CompletionSoak, &out.CompletionSoak
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.DrainPolicy != nil {
+		in, out := &in.DrainPolicy, &out.DrainPolicy
+		*out = new(MachineConfigPoolDrainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailableRamp != nil {
+		in, out := &in.MaxUnavailableRamp, &out.MaxUnavailableRamp
+		*out = new(MachineConfigPoolMaxUnavailableRamp)
+		**out = **in
+	}
+	if in.BlockingJob != nil {
+		in, out := &in.BlockingJob, &out.BlockingJob
+		*out = new(MachineConfigPoolJobReference)
+		**out = **in
+	}
+	if in.VerificationTimeout != nil {
+		in, out := &in.VerificationTimeout, &out.VerificationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NotReadyGrace != nil {
+		in, out := &in.NotReadyGrace, &out.NotReadyGrace
+		*out = new(MachineConfigPoolNotReadyGrace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TargetConfigDebounce != nil {
+		in, out := &in.TargetConfigDebounce, &out.TargetConfigDebounce
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RequiredNodeConditions != nil {
+		in, out := &in.RequiredNodeConditions, &out.RequiredNodeConditions
+		*out = make([]corev1.NodeConditionType, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelectors != nil {
+		in, out := &in.NodeSelectors, &out.NodeSelectors
+		*out = make([]*metav1.LabelSelector, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.LabelSelector)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.ShadowValidation != nil {
+		in, out := &in.ShadowValidation, &out.ShadowValidation
+		*out = new(MachineConfigPoolShadowValidation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigurationSelector != nil {
+		in, out := &in.ConfigurationSelector, &out.ConfigurationSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -651,6 +917,59 @@ func (in *MachineConfigPoolSpec) DeepCopy() *MachineConfigPoolSpec {
 func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
 	*out = *in
 	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.EstimatedTimeRemaining != nil {
+		in, out := &in.EstimatedTimeRemaining, &out.EstimatedTimeRemaining
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastNodeUpdateTime != nil {
+		in, out := &in.LastNodeUpdateTime, &out.LastNodeUpdateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastConfigChangeTime != nil {
+		in, out := &in.LastConfigChangeTime, &out.LastConfigChangeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PausedSince != nil {
+		in, out := &in.PausedSince, &out.PausedSince
+		*out = (*in).DeepCopy()
+	}
+	if in.UpdatedSince != nil {
+		in, out := &in.UpdatedSince, &out.UpdatedSince
+		*out = (*in).DeepCopy()
+	}
+	if in.ConfigChange != nil {
+		in, out := &in.ConfigChange, &out.ConfigChange
+		*out = new(MachineConfigPoolConfigChange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PausedNodes != nil {
+		in, out := &in.PausedNodes, &out.PausedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutHistory != nil {
+		in, out := &in.RolloutHistory, &out.RolloutHistory
+		*out = make([]MachineConfigPoolRollout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaxUnavailableRamp != nil {
+		in, out := &in.MaxUnavailableRamp, &out.MaxUnavailableRamp
+		*out = new(MachineConfigPoolMaxUnavailableRampStatus)
+		**out = **in
+	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]MachineConfigPoolZoneStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ShadowValidation != nil {
+		in, out := &in.ShadowValidation, &out.ShadowValidation
+		*out = new(MachineConfigPoolShadowValidationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]MachineConfigPoolCondition, len(*in))
@@ -692,3 +1011,19 @@ func (in *MachineConfigPoolStatusConfiguration) DeepCopy() *MachineConfigPoolSta
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolZoneStatus) DeepCopyInto(out *MachineConfigPoolZoneStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolZoneStatus.
+func (in *MachineConfigPoolZoneStatus) DeepCopy() *MachineConfigPoolZoneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolZoneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fieldManager is the field manager the node controller server-side applies node fields as.
+const fieldManager = "machine-config-controller"
+
+// nodeApplier server-side applies the partial node object in data to the named node,
+// taking over fields owned by other managers with force.
+type nodeApplier func(name string, data []byte, force bool) (*corev1.Node, error)
+
+// WithServerSideApply has the node controller set the fields it owns on nodes
+// with server-side apply as the machine-config-controller field manager, instead of
+// strategic merge patches. The first apply to a node takes over these fields from
+// whoever wrote them before, e.g. the strategic merge patches of the controller itself;
+// afterwards fields owned by another manager are reported as conflicts rather than
+// overwritten.
+func WithServerSideApply() Option {
+	return func(ctrl *Controller) {
+		ctrl.applyNode = ctrl.serverSideApplyNode
+	}
+}
+
+func (ctrl *Controller) serverSideApplyNode(name string, data []byte, force bool) (*corev1.Node, error) {
+	result := &corev1.Node{}
+	request := ctrl.kubeClient.CoreV1().RESTClient().Patch(types.ApplyPatchType).
+		Resource("nodes").
+		Name(name).
+		Param("fieldManager", fieldManager)
+	if force {
+		request = request.Param("force", "true")
+	}
+	err := request.Body(data).Do().Into(result)
+	return result, err
+}
+
+// isAppliedByController returns whether the controller server-side applied fields of
+// node before.
+func isAppliedByController(node *corev1.Node) bool {
+	for _, entry := range node.ManagedFields {
+		if entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDesiredMachineConfigAnnotation server-side applies the desired config annotation of node.
+func (ctrl *Controller) applyDesiredMachineConfigAnnotation(node *corev1.Node, desiredConfig string, cordon bool) error {
+	annotations := map[string]string{
+		daemonconsts.DesiredMachineConfigAnnotationKey: desiredConfig,
+	}
+	ctrl.signalSkipDrain(node, annotations, desiredConfig)
+	// Only the owned fields may be part of the applied object, so don't marshal a corev1.Node.
+	object := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata": map[string]interface{}{
+			"name":        node.Name,
+			"annotations": annotations,
+		},
+	}
+	// Keep applying a cordon made on select while it's in place, applying without it
+	// would drop it.
+	if cordoned, ok := node.Annotations[cordonedOnSelectAnnotationKey]; ok && node.Spec.Unschedulable {
+		annotations[cordonedOnSelectAnnotationKey] = cordoned
+		object["spec"] = map[string]interface{}{"unschedulable": true}
+	} else if cordon && !node.Spec.Unschedulable {
+		annotations[cordonedOnSelectAnnotationKey] = desiredConfig
+		object["spec"] = map[string]interface{}{"unschedulable": true}
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	// Nodes the controller never applied to have their fields owned by their previous
+	// writers, take them over once.
+	_, err = ctrl.applyNode(node.Name, data, !isAppliedByController(node))
+	if status, ok := err.(errors.APIStatus); ok && errors.IsConflict(err) {
+		ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "FieldManagerConflict", "Not setting desired config %s, %s is managed elsewhere: %v", desiredConfig, daemonconsts.DesiredMachineConfigAnnotationKey, err)
+		return &fieldManagerConflictError{node: node.Name, status: status}
+	}
+	return err
+}
+
+// fieldManagerConflictError is a conflict with another field manager applying the
+// desired config of a node. It is still a conflict to errors.IsConflict, but unlike
+// conflicting writes it isn't retried, see retryNodeUpdate.
+type fieldManagerConflictError struct {
+	node   string
+	status errors.APIStatus
+}
+
+func (e *fieldManagerConflictError) Error() string {
+	return fmt.Sprintf("field manager conflict applying desired config to node %s: %v", e.node, e.status)
+}
+
+// Status implements errors.APIStatus.
+func (e *fieldManagerConflictError) Status() metav1.Status {
+	return e.status.Status()
+}
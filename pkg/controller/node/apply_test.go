@@ -0,0 +1,122 @@
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSetDesiredMachineConfigAnnotationServerSideApply(t *testing.T) {
+	f := newFixture(t)
+	node := newNode("node-0", "v0", "v0")
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+
+	var applied map[string]interface{}
+	c.applyNode = func(name string, data []byte, force bool) (*corev1.Node, error) {
+		if name != "node-0" {
+			t.Fatalf("unexpected node applied: %s", name)
+		}
+		if !force {
+			t.Fatalf("expected the first apply to a node to force")
+		}
+		if err := json.Unmarshal(data, &applied); err != nil {
+			t.Fatal(err)
+		}
+		return node, nil
+	}
+
+	if err := c.setDesiredMachineConfigAnnotation("node-0", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata": map[string]interface{}{
+			"name": "node-0",
+			"annotations": map[string]interface{}{
+				daemonconsts.DesiredMachineConfigAnnotationKey: "v1",
+			},
+		},
+	}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Fatalf("mismatch applied object: got %v want: %v", applied, expected)
+	}
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("expected no strategic merge patch, got %v", action)
+		}
+	}
+}
+
+func TestSetDesiredMachineConfigAnnotationServerSideApplyConflict(t *testing.T) {
+	f := newFixture(t)
+	node := newNode("node-0", "v0", "v0")
+	// The controller applied to the node before, and another manager took over since.
+	node.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: fieldManager, Operation: metav1.ManagedFieldsOperationApply}}
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	applies := 0
+	c.applyNode = func(name string, data []byte, force bool) (*corev1.Node, error) {
+		applies++
+		if force {
+			t.Fatalf("expected no force once the controller applied to the node")
+		}
+		return nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, name, nil)
+	}
+
+	if err := c.setDesiredMachineConfigAnnotation("node-0", "v1"); !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict to be reported, got %v", err)
+	}
+	if applies != 1 {
+		t.Fatalf("expected an ownership conflict not to be retried, applied %d times", applies)
+	}
+	select {
+	case event := <-recorder.Events:
+		if want := "Warning FieldManagerConflict"; !strings.HasPrefix(event, want) {
+			t.Fatalf("mismatch event: got %q want prefix: %q", event, want)
+		}
+	default:
+		t.Fatalf("expected a FieldManagerConflict event")
+	}
+}
+
+func TestSetDesiredMachineConfigAnnotationServerSideApplyForeignManager(t *testing.T) {
+	f := newFixture(t)
+	node := newNode("node-0", "v0", "v0")
+	// The desired config was last written with a strategic merge patch.
+	node.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "machine-config-controller", Operation: metav1.ManagedFieldsOperationUpdate}}
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	// Like the API server, report a conflict with the previous writer unless forced.
+	c.applyNode = func(name string, data []byte, force bool) (*corev1.Node, error) {
+		if !force {
+			return nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, name, nil)
+		}
+		return node, nil
+	}
+
+	if err := c.setDesiredMachineConfigAnnotation("node-0", "v1"); err != nil {
+		t.Fatalf("expected the first apply to take over the desired config, got %v", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no conflict to be reported, got %q", <-recorder.Events)
+	}
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isApprovalPending returns whether pool requires approval of its target config, which
+// some of its nodes aren't set to yet, and the approved-config annotation of the pool
+// doesn't name it. Rollbacks don't wait for approval.
+func isApprovalPending(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) bool {
+	config := pool.Spec.Configuration.Name
+	if !pool.Spec.RequireApproval || pool.Spec.RollbackTo != "" || pool.Annotations[approvedConfigAnnotationKey] == config {
+		return false
+	}
+	for _, node := range nodes {
+		if desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != "" && desired != config {
+			return true
+		}
+	}
+	return false
+}
+
+// checkApproval records in the ApprovalPending condition of pool whether the rollout of
+// its target config waits for approval, and returns whether it does.
+func (ctrl *Controller) checkApproval(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) bool {
+	config := pool.Spec.Configuration.Name
+	if !isApprovalPending(pool, nodes) {
+		if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolApprovalPending) {
+			glog.Infof("Pool %s: rollout of %s approved", pool.Name, config)
+			sapproval := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolApprovalPending, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sapproval)
+		}
+		return false
+	}
+	pending := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolApprovalPending)
+	message := fmt.Sprintf("Set the %s annotation to %s to approve its rollout", approvedConfigAnnotationKey, config)
+	if pending == nil || pending.Status != corev1.ConditionTrue || pending.Message != message {
+		glog.Infof("Pool %s: rollout of %s waits for approval", pool.Name, config)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "ApprovalPending", "Rollout of %s waits for approval: %s", config, message)
+	}
+	sapproval := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolApprovalPending, corev1.ConditionTrue, "AwaitingApproval", message)
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sapproval)
+	return true
+}
@@ -0,0 +1,103 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+// syncApprovalPool syncs mcp, whose nodes are all on v0, and returns how many nodes were
+// patched along with the written status of the pool, if any.
+func syncApprovalPool(t *testing.T, mcp *mcfgv1.MachineConfigPool) (int, *mcfgv1.MachineConfigPoolStatus) {
+	f := newFixture(t)
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	patches := 0
+	for _, action := range f.kubeclient.Actions() {
+		if action.Matches("patch", "nodes") {
+			patches++
+		}
+	}
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	return patches, status
+}
+
+func TestRequireApproval(t *testing.T) {
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.RequireApproval = true
+
+	// A new target config waits for approval.
+	patches, status := syncApprovalPool(t, mcp.DeepCopy())
+	if patches != 0 {
+		t.Fatalf("expected no node updates while approval is pending, got %d", patches)
+	}
+	if status == nil {
+		t.Fatalf("expected the status of the pool to be updated")
+	}
+	pending := mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolApprovalPending)
+	if pending == nil || pending.Status != corev1.ConditionTrue {
+		t.Fatalf("expected approval to be pending, got %v", pending)
+	}
+
+	// Approving another config doesn't help.
+	mcp.Annotations = map[string]string{approvedConfigAnnotationKey: "v0"}
+	mcp.Status = *status
+	if patches, _ := syncApprovalPool(t, mcp.DeepCopy()); patches != 0 {
+		t.Fatalf("expected no node updates with another config approved, got %d", patches)
+	}
+
+	// Approving the target config starts the rollout.
+	mcp.Annotations[approvedConfigAnnotationKey] = "v1"
+	if patches, _ := syncApprovalPool(t, mcp.DeepCopy()); patches != 1 {
+		t.Fatalf("expected a node update once approved, got %d", patches)
+	}
+	f := newFixture(t)
+	c := f.newController()
+	approved := mcp.DeepCopy()
+	if c.checkApproval(approved, []*corev1.Node{newNode("node-0", "v0", "v0")}) {
+		t.Fatalf("expected the approved rollout to proceed")
+	}
+	if mcfgv1.IsMachineConfigPoolConditionTrue(approved.Status.Conditions, mcfgv1.MachineConfigPoolApprovalPending) {
+		t.Fatalf("expected approval to no longer be pending, got %v", approved.Status.Conditions)
+	}
+}
+
+func TestRequireApprovalSettledPool(t *testing.T) {
+	mcp := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v0")
+	mcp.Spec.RequireApproval = true
+	nodes := []*corev1.Node{newNode("node-0", "v0", "v0")}
+	if isApprovalPending(mcp, nodes) {
+		t.Fatalf("expected a pool already on its target config not to wait for approval")
+	}
+	mcp.Spec.Configuration.Name = "v1"
+	if !isApprovalPending(mcp, nodes) {
+		t.Fatalf("expected a new target config to wait for approval")
+	}
+	mcp.Spec.RollbackTo = "v0"
+	if isApprovalPending(mcp, nodes) {
+		t.Fatalf("expected a rollback not to wait for approval")
+	}
+}
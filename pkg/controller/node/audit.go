@@ -0,0 +1,136 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// AuditAction is the kind of decision an AuditRecord describes.
+type AuditAction string
+
+const (
+	// AuditSetDesiredConfig records that the desired config of a node was changed.
+	AuditSetDesiredConfig AuditAction = "SetDesiredConfig"
+	// AuditPoolPaused records that a pool was paused.
+	AuditPoolPaused AuditAction = "PoolPaused"
+	// AuditPoolUnpaused records that a pool was unpaused.
+	AuditPoolUnpaused AuditAction = "PoolUnpaused"
+	// AuditQuorumClamped records that the maxUnavailable of the master pool started being
+	// limited to preserve etcd quorum.
+	AuditQuorumClamped AuditAction = "QuorumClamped"
+)
+
+// AuditRecord describes a decision of the node controller.
+type AuditRecord struct {
+	Time   time.Time
+	Action AuditAction
+	// Pool is the name of the pool the decision was made for, if known.
+	Pool string
+	// Node, OldConfig and NewConfig are only set for desired config changes.
+	Node      string
+	OldConfig string
+	NewConfig string
+	// Message describes the decision in human readable form.
+	Message string
+}
+
+// AuditSink receives a record of every decision of the node controller that changes
+// which config nodes run, e.g. to ship it to a write-once store in regulated
+// environments.
+type AuditSink interface {
+	// Record is called once the decision took effect. It must not block syncs for
+	// long; failures to store the record are the sink's to handle.
+	Record(record AuditRecord)
+}
+
+// noopAuditSink is the default AuditSink, which drops every record.
+type noopAuditSink struct{}
+
+func (noopAuditSink) Record(AuditRecord) {}
+
+// WithAuditSink records the desired config changes of nodes, pool pauses and unpauses,
+// and the activation of the etcd quorum protection of the master pool in sink.
+// Without it nothing is recorded.
+func WithAuditSink(sink AuditSink) Option {
+	return func(ctrl *Controller) {
+		if sink != nil {
+			ctrl.auditSink = sink
+		}
+	}
+}
+
+// auditDesiredConfig records that the desired config of oldNode was changed to config.
+func (ctrl *Controller) auditDesiredConfig(oldNode *corev1.Node, config string) {
+	record := AuditRecord{
+		Time:      time.Now(),
+		Action:    AuditSetDesiredConfig,
+		Node:      oldNode.Name,
+		OldConfig: oldNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey],
+		NewConfig: config,
+	}
+	if pool, err := ctrl.getPoolForNode(oldNode); err != nil {
+		glog.V(4).Infof("Auditing the desired config of node %s without its pool: %v", oldNode.Name, err)
+	} else if pool != nil {
+		record.Pool = pool.Name
+	}
+	record.Message = fmt.Sprintf("Set the desired config of node %s from %q to %s", record.Node, record.OldConfig, config)
+	ctrl.auditSink.Record(record)
+}
+
+// auditPause records that pool was paused or unpaused.
+func (ctrl *Controller) auditPause(pool *mcfgv1.MachineConfigPool) {
+	record := AuditRecord{Time: time.Now(), Action: AuditPoolUnpaused, Pool: pool.Name, Message: "Pool unpaused"}
+	if pool.Spec.Paused {
+		record.Action = AuditPoolPaused
+		record.Message = "Pool paused"
+	}
+	ctrl.auditSink.Record(record)
+}
+
+// auditQuorumClamp records that m of pool started being limited to preserve etcd
+// quorum. Syncs in which it stays limited aren't recorded again.
+func (ctrl *Controller) auditQuorumClamp(pool *mcfgv1.MachineConfigPool, m maxUnavailableResult) {
+	if !ctrl.quorumClamps.set(pool.Name, m.quorumClamped) {
+		return
+	}
+	ctrl.auditSink.Record(AuditRecord{
+		Time:    time.Now(),
+		Action:  AuditQuorumClamped,
+		Pool:    pool.Name,
+		Message: fmt.Sprintf("Limited maxUnavailable %d to %d to preserve etcd quorum", m.requested, m.effective),
+	})
+}
+
+// quorumClampTracker remembers which pools have their maxUnavailable limited to preserve
+// etcd quorum.
+type quorumClampTracker struct {
+	lock    sync.Mutex
+	clamped sets.String
+}
+
+func newQuorumClampTracker() *quorumClampTracker {
+	return &quorumClampTracker{clamped: sets.NewString()}
+}
+
+// set records whether pool is clamped, and returns whether it just started being.
+func (t *quorumClampTracker) set(pool string, clamped bool) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !clamped {
+		t.clamped.Delete(pool)
+		return false
+	}
+	if t.clamped.Has(pool) {
+		return false
+	}
+	t.clamped.Insert(pool)
+	return true
+}
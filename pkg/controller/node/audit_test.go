@@ -0,0 +1,110 @@
+package node
+
+import (
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// recordingSink keeps every audit record.
+type recordingSink struct {
+	lock    sync.Mutex
+	records []AuditRecord
+}
+
+func (s *recordingSink) Record(record AuditRecord) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.records = append(s.records, record)
+}
+
+func TestAuditSetDesiredConfig(t *testing.T) {
+	f := newFixture(t)
+	sink := &recordingSink{}
+	f.opts = append(f.opts, WithAuditSink(sink))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected one audit record, got %v", sink.records)
+	}
+	record := sink.records[0]
+	if record.Action != AuditSetDesiredConfig || record.Node != "node-0" || record.Pool != "worker" || record.OldConfig != "v0" || record.NewConfig != "v1" {
+		t.Fatalf("unexpected audit record %+v", record)
+	}
+	if record.Time.IsZero() {
+		t.Fatalf("expected the audit record to carry a timestamp")
+	}
+
+	// Setting a node to the desired config it has already isn't a decision.
+	if err := c.setDesiredMachineConfigAnnotation("node-1", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.records) != 1 {
+		t.Fatalf("expected no further audit record, got %v", sink.records)
+	}
+}
+
+func TestAuditPause(t *testing.T) {
+	f := newFixture(t)
+	sink := &recordingSink{}
+	f.opts = append(f.opts, WithAuditSink(sink))
+	c := f.newController()
+
+	mcp := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	paused := mcp.DeepCopy()
+	paused.Spec.Paused = true
+	c.updateMachineConfigPool(mcp, paused)
+	c.updateMachineConfigPool(paused, paused)
+	c.updateMachineConfigPool(paused, mcp)
+
+	var actions []AuditAction
+	for _, record := range sink.records {
+		if record.Pool != "worker" {
+			t.Fatalf("expected the records to name the pool, got %+v", record)
+		}
+		actions = append(actions, record.Action)
+	}
+	if len(actions) != 2 || actions[0] != AuditPoolPaused || actions[1] != AuditPoolUnpaused {
+		t.Fatalf("expected the pool to be audited paused then unpaused, got %v", actions)
+	}
+}
+
+func TestAuditQuorumClamp(t *testing.T) {
+	f := newFixture(t)
+	sink := &recordingSink{}
+	f.opts = append(f.opts, WithAuditSink(sink))
+	c := f.newController()
+	mcp := newMachineConfigPool("master", nil, intStrPtr(intstr.FromInt(3)), "v1")
+
+	clamped := maxUnavailableResult{requested: 3, quorumClamped: true, effective: 1}
+	for _, m := range []maxUnavailableResult{clamped, clamped, {requested: 1, effective: 1}, clamped} {
+		c.auditQuorumClamp(mcp, m)
+	}
+	if len(sink.records) != 2 {
+		t.Fatalf("expected an audit record every time the clamp activates, got %v", sink.records)
+	}
+	for _, record := range sink.records {
+		if record.Action != AuditQuorumClamped || record.Pool != "master" {
+			t.Fatalf("unexpected audit record %+v", record)
+		}
+	}
+}
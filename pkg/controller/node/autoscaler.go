@@ -0,0 +1,31 @@
+package node
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// WithScaleDownAnnotations leaves nodes carrying any of the annotation keys, set
+// e.g. by a cluster autoscaler on nodes it is about to remove, at their current
+// config instead of rebooting them for an update. The keys differ across
+// autoscaler versions, so none are checked by default.
+func WithScaleDownAnnotations(keys []string) Option {
+	return func(ctrl *Controller) {
+		ctrl.scaleDownAnnotations = keys
+	}
+}
+
+// getScaleDownNodes returns the names of the nodes marked for scale-down. Like
+// nodes held for other reasons, they count as unavailable and aren't updated.
+func (ctrl *Controller) getScaleDownNodes(nodes []*corev1.Node) sets.String {
+	scaleDown := sets.NewString()
+	for _, node := range nodes {
+		for _, key := range ctrl.scaleDownAnnotations {
+			if _, ok := node.Annotations[key]; ok {
+				scaleDown.Insert(node.Name)
+				break
+			}
+		}
+	}
+	return scaleDown
+}
@@ -0,0 +1,54 @@
+package node
+
+import (
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestScaleDownAnnotations(t *testing.T) {
+	const toBeDeleted = "autoscaler.example.com/to-be-deleted"
+	f := newFixture(t)
+	f.opts = append(f.opts, WithScaleDownAnnotations([]string{"autoscaler.example.com/scale-down", toBeDeleted}))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		withAnnotation(newNodeWithLabel("node-0", "v0", "v0", labels), toBeDeleted, "1589900000"),
+		newNodeWithLabel("node-1", "v0", "v0", labels),
+		newNodeWithLabel("node-2", "v0", "v0", labels),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// node-0 is left alone and counts against maxUnavailable, leaving room for one update.
+	updated := 0
+	for _, name := range []string{"node-0", "node-1", "node-2"} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "v1" {
+			continue
+		}
+		if name == "node-0" {
+			t.Fatalf("expected the node marked for scale-down not to be updated")
+		}
+		updated++
+	}
+	if updated != 1 {
+		t.Fatalf("expected 1 node to be updated, got %d", updated)
+	}
+}
@@ -0,0 +1,111 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// maxRecentPatchLatencies is how many node patch latencies are averaged to decide
+// whether the apiserver is slow.
+const maxRecentPatchLatencies = 10
+
+// patchLatencyWindow is how long a node patch latency counts. Syncs serialized by slow
+// patches may issue no patch for a while, and must not stay serialized on stale ones.
+const patchLatencyWindow = 5 * time.Minute
+
+// patchSample is the latency of a node patch finished at a given time.
+type patchSample struct {
+	latency  time.Duration
+	finished time.Time
+}
+
+// patchLatency tracks how long recent node patches took, and serializes pool syncs
+// while their mean exceeds threshold so a loaded apiserver isn't piled on.
+type patchLatency struct {
+	lock      sync.Mutex
+	threshold time.Duration
+	// recent holds the latest node patch latencies, oldest first.
+	recent []patchSample
+	// slowed is whether syncs were last found to need serializing.
+	slowed bool
+
+	// serial is held by every sync started while the apiserver is slow.
+	serial sync.Mutex
+}
+
+func newPatchLatency(threshold time.Duration) *patchLatency {
+	return &patchLatency{threshold: threshold}
+}
+
+// WithPatchLatencyThreshold makes the node controller sync one pool at a time while
+// the mean latency of recent node patches exceeds threshold, relieving an apiserver
+// under load. Zero, the default, never limits the workers. Patch latencies are
+// exported as metrics either way.
+func WithPatchLatencyThreshold(threshold time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.patchLatency = newPatchLatency(threshold)
+	}
+}
+
+// observe records that a node patch finished at now took latency.
+func (p *patchLatency) observe(latency time.Duration, now time.Time) {
+	nodePatchLatency.Add("count", 1)
+	nodePatchLatency.AddFloat("seconds_total", latency.Seconds())
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.recent = append(p.recent, patchSample{latency: latency, finished: now})
+	if len(p.recent) > maxRecentPatchLatencies {
+		p.recent = p.recent[len(p.recent)-maxRecentPatchLatencies:]
+	}
+}
+
+// slow returns whether the mean latency of the patches finished within
+// patchLatencyWindow before now exceeds the threshold.
+func (p *patchLatency) slow(now time.Time) bool {
+	if p == nil || p.threshold <= 0 {
+		return false
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for len(p.recent) > 0 && now.Sub(p.recent[0].finished) > patchLatencyWindow {
+		p.recent = p.recent[1:]
+	}
+	var slow bool
+	if len(p.recent) > 0 {
+		var total time.Duration
+		for _, r := range p.recent {
+			total += r.latency
+		}
+		slow = total/time.Duration(len(p.recent)) > p.threshold
+	}
+	if slow != p.slowed {
+		if slow {
+			glog.Warningf("Node patches take longer than %v on average, syncing one pool at a time", p.threshold)
+			nodePatchBackpressure.Set(1)
+		} else {
+			glog.Infof("Node patch latency is back under %v, no longer limiting pool syncs", p.threshold)
+			nodePatchBackpressure.Set(0)
+		}
+		p.slowed = slow
+	}
+	return slow
+}
+
+// throttle blocks until the calling sync may proceed and returns the func to call
+// once it finished.
+func (p *patchLatency) throttle() func() {
+	if !p.slow(time.Now()) {
+		return func() {}
+	}
+	p.serial.Lock()
+	return p.serial.Unlock
+}
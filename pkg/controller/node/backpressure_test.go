@@ -0,0 +1,89 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatchLatencySlow(t *testing.T) {
+	p := newPatchLatency(time.Second)
+	now := time.Now()
+	if p.slow(now) {
+		t.Fatalf("expected no backpressure without patches")
+	}
+
+	p.observe(100*time.Millisecond, now)
+	if p.slow(now) {
+		t.Fatalf("expected no backpressure for fast patches")
+	}
+
+	p.observe(3*time.Second, now)
+	if !p.slow(now) {
+		t.Fatalf("expected backpressure once the mean latency exceeds the threshold")
+	}
+	if got := nodePatchBackpressure.Value(); got != 1 {
+		t.Fatalf("mismatch backpressure metric: got %d want: 1", got)
+	}
+
+	// Only recent patches count.
+	for i := 0; i < maxRecentPatchLatencies; i++ {
+		p.observe(10*time.Millisecond, now)
+	}
+	if p.slow(now) {
+		t.Fatalf("expected backpressure to stop once patches are fast again")
+	}
+	if got := nodePatchBackpressure.Value(); got != 0 {
+		t.Fatalf("mismatch backpressure metric: got %d want: 0", got)
+	}
+}
+
+func TestPatchLatencyExpires(t *testing.T) {
+	p := newPatchLatency(time.Second)
+	now := time.Now()
+	p.observe(time.Minute, now)
+	if !p.slow(now.Add(patchLatencyWindow)) {
+		t.Fatalf("expected backpressure while the slow patch is recent")
+	}
+	// Without patches since, the slow one ages out.
+	if p.slow(now.Add(patchLatencyWindow + time.Second)) {
+		t.Fatalf("expected backpressure to stop once the slow patch aged out")
+	}
+	if got := nodePatchBackpressure.Value(); got != 0 {
+		t.Fatalf("mismatch backpressure metric: got %d want: 0", got)
+	}
+}
+
+func TestPatchLatencyDisabled(t *testing.T) {
+	var unset *patchLatency
+	unset.observe(time.Hour, time.Now())
+	unset.throttle()()
+
+	p := newPatchLatency(0)
+	p.observe(time.Hour, time.Now())
+	if p.slow(time.Now()) {
+		t.Fatalf("expected no backpressure without a threshold")
+	}
+}
+
+func TestPatchLatencyThrottle(t *testing.T) {
+	p := newPatchLatency(time.Second)
+	p.observe(time.Minute, time.Now())
+
+	done := p.throttle()
+	acquired := make(chan struct{})
+	go func() {
+		p.throttle()()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("expected syncs to be serialized while patches are slow")
+	case <-time.After(100 * time.Millisecond):
+	}
+	done()
+	select {
+	case <-acquired:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected the next sync to proceed once the previous one finished")
+	}
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// configChangeTracker remembers when the target config of pools was seen changing,
+// until their status records it.
+type configChangeTracker struct {
+	lock    sync.Mutex
+	changed map[string]time.Time
+}
+
+func newConfigChangeTracker() *configChangeTracker {
+	return &configChangeTracker{changed: map[string]time.Time{}}
+}
+
+// change records that the target config of pool changed at t. Status timestamps only
+// keep seconds, so t is truncated to compare with them.
+func (c *configChangeTracker) change(pool string, t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.changed[pool] = t.Truncate(time.Second)
+}
+
+// get returns when the target config of pool was seen changing, if it was.
+func (c *configChangeTracker) get(pool string) (time.Time, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t, ok := c.changed[pool]
+	return t, ok
+}
+
+// forget drops the config change of pool, e.g. because it was deleted.
+func (c *configChangeTracker) forget(pool string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.changed, pool)
+}
+
+// setLastConfigChangeTime records on newStatus when the target config of pool last
+// changed, and exports the age of the config.
+func (ctrl *Controller) setLastConfigChangeTime(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus) {
+	newStatus.LastConfigChangeTime = pool.Status.LastConfigChangeTime
+	if changed, ok := ctrl.configChanges.get(pool.Name); ok && (newStatus.LastConfigChangeTime == nil || newStatus.LastConfigChangeTime.Time.Before(changed)) {
+		t := metav1.NewTime(changed)
+		newStatus.LastConfigChangeTime = &t
+	} else if newStatus.LastConfigChangeTime == nil && !pool.CreationTimestamp.IsZero() {
+		t := pool.CreationTimestamp
+		newStatus.LastConfigChangeTime = &t
+	}
+	if newStatus.LastConfigChangeTime == nil {
+		return
+	}
+	poolConfigAge.set(pool.Name, newStatus.LastConfigChangeTime.Time)
+}
+
+// ageMap is an expvar exporting the seconds since a time per key. Ages are only
+// computed when the variable is read.
+type ageMap struct {
+	lock  sync.Mutex
+	since map[string]time.Time
+}
+
+func newAgeMap(name string) *ageMap {
+	a := &ageMap{since: map[string]time.Time{}}
+	expvar.Publish(name, a)
+	return a
+}
+
+func (a *ageMap) set(key string, t time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.since[key] = t
+}
+
+func (a *ageMap) delete(key string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.since, key)
+}
+
+// String implements expvar.Var.
+func (a *ageMap) String() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := time.Now()
+	ages := make(map[string]float64, len(a.since))
+	for key, t := range a.since {
+		ages[key] = now.Sub(t).Seconds()
+	}
+	b, err := json.Marshal(ages)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
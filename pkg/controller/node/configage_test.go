@@ -0,0 +1,51 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestLastConfigChangeTime(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	created := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v0")
+	pool.CreationTimestamp = created
+
+	// Without an observed change, the config is as old as the pool.
+	c.setLastConfigChangeTime(pool, &pool.Status)
+	if got := pool.Status.LastConfigChangeTime; got == nil || !got.Equal(&created) {
+		t.Fatalf("expected the creation time of the pool, got %v", got)
+	}
+
+	// An unrelated spec edit leaves the time alone.
+	edited := pool.DeepCopy()
+	edited.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(2))
+	c.updateMachineConfigPool(pool, edited)
+	c.setLastConfigChangeTime(edited, &edited.Status)
+	if got := edited.Status.LastConfigChangeTime; !got.Equal(&created) {
+		t.Fatalf("expected an unrelated spec edit not to change the time, got %v", got)
+	}
+
+	// Changing the target config updates it.
+	before := time.Now().Truncate(time.Second)
+	changed := edited.DeepCopy()
+	changed.Spec.Configuration.Name = "v1"
+	c.updateMachineConfigPool(edited, changed)
+	c.setLastConfigChangeTime(changed, &changed.Status)
+	if got := changed.Status.LastConfigChangeTime; got == nil || got.Time.Before(before) {
+		t.Fatalf("expected the config change to update the time, got %v", got)
+	}
+
+	var ages map[string]float64
+	if err := json.Unmarshal([]byte(poolConfigAge.String()), &ages); err != nil {
+		t.Fatal(err)
+	}
+	if age, ok := ages["worker"]; !ok || age < 0 || age > 60 {
+		t.Fatalf("expected the config age of the pool to be exported, got %v", ages)
+	}
+}
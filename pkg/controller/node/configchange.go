@@ -0,0 +1,80 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// diffConfigs returns the fields of the spec that differ between the configs.
+func diffConfigs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
+	var fields []string
+	if oldConfig.Spec.OSImageURL != newConfig.Spec.OSImageURL {
+		fields = append(fields, "osImageURL")
+	}
+	if len(oldConfig.Spec.KernelArguments) != 0 || len(newConfig.Spec.KernelArguments) != 0 {
+		if !reflect.DeepEqual(oldConfig.Spec.KernelArguments, newConfig.Spec.KernelArguments) {
+			fields = append(fields, "kernelArguments")
+		}
+	}
+	oldIgn, newIgn := oldConfig.Spec.Config, newConfig.Spec.Config
+	if !reflect.DeepEqual(oldIgn.Ignition, newIgn.Ignition) {
+		fields = append(fields, "config.ignition")
+	}
+	if !reflect.DeepEqual(oldIgn.Networkd, newIgn.Networkd) {
+		fields = append(fields, "config.networkd")
+	}
+	if !reflect.DeepEqual(oldIgn.Passwd, newIgn.Passwd) {
+		fields = append(fields, "config.passwd")
+	}
+	if !reflect.DeepEqual(oldIgn.Storage, newIgn.Storage) {
+		fields = append(fields, "config.storage")
+	}
+	if !reflect.DeepEqual(oldIgn.Systemd, newIgn.Systemd) {
+		fields = append(fields, "config.systemd")
+	}
+	return fields
+}
+
+// setConfigChange records on newStatus what changes between the current and the target
+// config of pool, and announces it with an event when a rollout starts. MachineConfigs
+// carry nothing but the OS image, the kernel arguments and the Ignition config, and
+// MachineConfigDaemon reboots nodes to apply any of them, so only a change limited to
+// the metadata of the configs is classified as not requiring a reboot.
+func (ctrl *Controller) setConfigChange(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus) {
+	from, to := newStatus.Configuration.Name, pool.Spec.Configuration.Name
+	if from == "" || from == to {
+		newStatus.ConfigChange = nil
+		return
+	}
+	if change := pool.Status.ConfigChange; change != nil && change.From == from && change.To == to {
+		newStatus.ConfigChange = change
+		return
+	}
+
+	oldConfig, err := ctrl.mcLister.Get(from)
+	if err != nil {
+		glog.V(2).Infof("Pool %s: can't classify the change from %s: %v", pool.Name, from, err)
+		return
+	}
+	newConfig, err := ctrl.mcLister.Get(to)
+	if err != nil {
+		glog.V(2).Infof("Pool %s: can't classify the change to %s: %v", pool.Name, to, err)
+		return
+	}
+	fields := diffConfigs(oldConfig, newConfig)
+	newStatus.ConfigChange = &mcfgv1.MachineConfigPoolConfigChange{
+		From:           from,
+		To:             to,
+		RebootRequired: len(fields) > 0,
+		ChangedFields:  fields,
+	}
+	if len(fields) > 0 {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RolloutStarted", "Updating from %s to %s requires a reboot, changed: %s", from, to, strings.Join(fields, ", "))
+	} else {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RolloutStarted", "Updating from %s to %s doesn't change the config spec", from, to)
+	}
+}
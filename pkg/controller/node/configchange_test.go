@@ -0,0 +1,103 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	withSpec := func(name string, edit func(*mcfgv1.MachineConfig)) *mcfgv1.MachineConfig {
+		config := newMachineConfig(name)
+		edit(config)
+		return config
+	}
+	base := withSpec("v0", func(config *mcfgv1.MachineConfig) {
+		config.Spec.OSImageURL = "quay.io/os:1"
+		config.Spec.KernelArguments = []string{"nosmt"}
+	})
+	tests := []struct {
+		name   string
+		config *mcfgv1.MachineConfig
+		fields []string
+	}{{
+		name: "metadata only",
+		config: withSpec("v1", func(config *mcfgv1.MachineConfig) {
+			config.Spec = *base.Spec.DeepCopy()
+			config.Labels = map[string]string{"a": "b"}
+		}),
+	}, {
+		name: "os and kernel arguments",
+		config: withSpec("v1", func(config *mcfgv1.MachineConfig) {
+			config.Spec.OSImageURL = "quay.io/os:2"
+			config.Spec.KernelArguments = []string{"nosmt", "quiet"}
+		}),
+		fields: []string{"osImageURL", "kernelArguments"},
+	}, {
+		name: "ignition",
+		config: withSpec("v1", func(config *mcfgv1.MachineConfig) {
+			config.Spec = *base.Spec.DeepCopy()
+			config.Spec.Config.Storage.Files = []igntypes.File{{Node: igntypes.Node{Path: "/etc/motd"}}}
+			config.Spec.Config.Systemd.Units = []igntypes.Unit{{Name: "motd.service"}}
+		}),
+		fields: []string{"config.storage", "config.systemd"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := diffConfigs(base, test.config); !reflect.DeepEqual(got, test.fields) {
+				t.Fatalf("mismatch changed fields: got %v want: %v", got, test.fields)
+			}
+		})
+	}
+}
+
+func TestSetConfigChange(t *testing.T) {
+	f := newFixture(t)
+	v1 := newMachineConfig("v1")
+	v1.Spec.OSImageURL = "quay.io/os:2"
+	v2 := newMachineConfig("v2")
+	v2.Spec.OSImageURL = v1.Spec.OSImageURL
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), v1, v2)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Status.Configuration.Name = "v0"
+	status := pool.Status
+	c.setConfigChange(pool, &status)
+	if change := status.ConfigChange; change == nil || !change.RebootRequired || !reflect.DeepEqual(change.ChangedFields, []string{"osImageURL"}) {
+		t.Fatalf("expected a change of the OS image to require a reboot, got %+v", change)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal RolloutStarted") || !strings.Contains(event, "requires a reboot") {
+		t.Fatalf("expected the rollout to be announced, got %q", event)
+	}
+
+	// The classification is only announced once per rollout.
+	pool.Status = status
+	c.setConfigChange(pool, &status)
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no further event, got %q", <-recorder.Events)
+	}
+
+	// A config differing only in its metadata doesn't require a reboot.
+	pool.Spec.Configuration.Name = "v2"
+	pool.Status.Configuration.Name = "v1"
+	status = pool.Status
+	c.setConfigChange(pool, &status)
+	if change := status.ConfigChange; change == nil || change.RebootRequired || change.From != "v1" || change.To != "v2" {
+		t.Fatalf("expected a change of the metadata alone not to require a reboot, got %+v", change)
+	}
+
+	// The change is cleared once the pool is updated.
+	pool.Status.Configuration.Name = "v2"
+	status = pool.Status
+	c.setConfigChange(pool, &status)
+	if status.ConfigChange != nil {
+		t.Fatalf("expected no change once updated, got %+v", status.ConfigChange)
+	}
+}
@@ -0,0 +1,42 @@
+package node
+
+import (
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// targetConfigDebounceRemaining returns how much longer the target config of pool must
+// stay in place at now before its nodes are moved to it. A target config nodes were
+// last moved to, e.g. because a change to another one was reverted, isn't held back.
+func (ctrl *Controller) targetConfigDebounceRemaining(pool *mcfgv1.MachineConfigPool, now time.Time) time.Duration {
+	debounce := pool.Spec.TargetConfigDebounce
+	if debounce == nil || debounce.Duration <= 0 || pool.Spec.Configuration.Name == pool.Status.LastActedConfig {
+		return 0
+	}
+	var changed time.Time
+	if pool.Status.LastConfigChangeTime != nil {
+		changed = pool.Status.LastConfigChangeTime.Time
+	}
+	if t, ok := ctrl.configChanges.get(pool.Name); ok && t.After(changed) {
+		changed = t
+	}
+	if changed.IsZero() {
+		return 0
+	}
+	if remaining := changed.Add(debounce.Duration).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// setLastActedConfig records on newStatus the target config nodes of pool were last
+// moved to, as set on the status of pool by its sync, if pool has a target config
+// debounce.
+func setLastActedConfig(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus) {
+	if pool.Spec.TargetConfigDebounce == nil {
+		newStatus.LastActedConfig = ""
+		return
+	}
+	newStatus.LastActedConfig = pool.Status.LastActedConfig
+}
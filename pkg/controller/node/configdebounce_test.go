@@ -0,0 +1,80 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+// syncDebouncedPool syncs a worker pool targeting config, last acted on acted, whose
+// target config changed since ago, with a node on current. It returns whether the node
+// was patched, along with the written status of the pool.
+func syncDebouncedPool(t *testing.T, config, acted string, since time.Duration, current string) (bool, *mcfgv1.MachineConfigPoolStatus) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), config)
+	mcp.Spec.TargetConfigDebounce = &metav1.Duration{Duration: 10 * time.Minute}
+	mcp.Status.LastActedConfig = acted
+	changed := metav1.NewTime(time.Now().Add(-since))
+	mcp.Status.LastConfigChangeTime = &changed
+	node := newNodeWithLabel("node-0", current, current, map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	patched := false
+	for _, action := range f.kubeclient.Actions() {
+		if action.Matches("patch", "nodes") {
+			patched = true
+		}
+	}
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	return patched, status
+}
+
+func TestTargetConfigDebounceWithinWindow(t *testing.T) {
+	// v0 -> v1: v1 isn't rolled out while it is recent.
+	if patched, _ := syncDebouncedPool(t, "v1", "v0", time.Minute, "v0"); patched {
+		t.Fatalf("expected a recent target config not to be rolled out yet")
+	}
+	// v1 -> v0: the revert leaves the nodes alone, and v0 stays the config acted on.
+	patched, status := syncDebouncedPool(t, "v0", "v0", 0, "v0")
+	if patched {
+		t.Fatalf("expected a reverted target config not to update any node")
+	}
+	if status != nil && status.LastActedConfig != "v0" {
+		t.Fatalf("expected v0 to stay the config acted on, got %q", status.LastActedConfig)
+	}
+}
+
+func TestTargetConfigDebounceOutsideWindow(t *testing.T) {
+	// v0 -> v1: v1 is rolled out once it stayed in place for the window.
+	patched, status := syncDebouncedPool(t, "v1", "v0", 20*time.Minute, "v0")
+	if !patched {
+		t.Fatalf("expected a settled target config to be rolled out")
+	}
+	if status == nil || status.LastActedConfig != "v1" {
+		t.Fatalf("expected v1 to be recorded as the config acted on, got %v", status)
+	}
+	// v1 -> v0: the revert is a new target config and waits for the window again.
+	if patched, _ := syncDebouncedPool(t, "v0", "v1", time.Minute, "v1"); patched {
+		t.Fatalf("expected a recent revert not to be rolled out yet")
+	}
+	if patched, _ := syncDebouncedPool(t, "v0", "v1", 20*time.Minute, "v1"); !patched {
+		t.Fatalf("expected a settled revert to be rolled out")
+	}
+}
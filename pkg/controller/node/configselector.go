@@ -0,0 +1,102 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newestConfig returns the most recently created of configs, breaking ties by name,
+// or nil if there are none.
+func newestConfig(configs []*mcfgv1.MachineConfig) *mcfgv1.MachineConfig {
+	var newest *mcfgv1.MachineConfig
+	for _, config := range configs {
+		if newest == nil || newest.CreationTimestamp.Before(&config.CreationTimestamp) ||
+			newest.CreationTimestamp.Equal(&config.CreationTimestamp) && config.Name > newest.Name {
+			newest = config
+		}
+	}
+	return newest
+}
+
+// useResolvedConfig sets the target config of a pool with spec.configurationSelector to
+// the config it last resolved to, e.g. while the pool is paused or being deleted. Pools
+// without one keep the config named in their spec.
+func useResolvedConfig(pool *mcfgv1.MachineConfigPool) {
+	if pool.Spec.ConfigurationSelector == nil {
+		pool.Status.ResolvedConfig = ""
+		return
+	}
+	if pool.Status.ResolvedConfig != "" {
+		pool.Spec.Configuration.Name = pool.Status.ResolvedConfig
+	}
+}
+
+// resolveTargetConfig sets the target config of a pool with spec.configurationSelector
+// to the newest MachineConfig matching the selector, and records it in the status of the
+// pool. A change from the config it last resolved to, or from the config named in its
+// spec before it had a selector, counts as a change of its target config. Pools without
+// a selector keep the config named in their spec.
+func (ctrl *Controller) resolveTargetConfig(pool *mcfgv1.MachineConfigPool) error {
+	if pool.Spec.ConfigurationSelector == nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.ConfigurationSelector)
+	if err != nil {
+		return newSyncError(syncErrorMachineConfigLookup, fmt.Errorf("invalid configuration selector: %v", err))
+	}
+	configs, err := ctrl.mcLister.List(selector)
+	if err != nil {
+		return newSyncError(syncErrorMachineConfigLookup, err)
+	}
+	newest := newestConfig(configs)
+	if newest == nil {
+		if ctrl.poolEvents.changed(pool.Name, "MachineConfigNotFound", selector.String()) {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "MachineConfigNotFound", "No config matches the configuration selector %s, not updating any node", selector)
+		}
+		return newSyncError(syncErrorMachineConfigNotFound, fmt.Errorf("no config matches the configuration selector %s", selector))
+	}
+	if previous := pool.Spec.Configuration.Name; previous != newest.Name {
+		glog.V(4).Infof("Pool %s: configuration selector %s resolved to %s", pool.Name, selector, newest.Name)
+		if previous != "" {
+			ctrl.configChanges.change(pool.Name, time.Now())
+		}
+	}
+	pool.Spec.Configuration.Name = newest.Name
+	pool.Status.ResolvedConfig = newest.Name
+	return nil
+}
+
+// enqueueSelectingPools enqueues the pools whose configuration selector matches the
+// MachineConfig obj, whose target config it may change.
+func (ctrl *Controller) enqueueSelectingPools(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	config, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		return
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Warningf("Failed to list pools selecting config %s: %v", config.Name, err)
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.ConfigurationSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.ConfigurationSelector)
+		if err != nil || !selector.Matches(labels.Set(config.Labels)) {
+			continue
+		}
+		glog.V(4).Infof("Config %s changed, syncing pool %s", config.Name, pool.Name)
+		ctrl.enqueueMachineConfigPool(pool)
+	}
+}
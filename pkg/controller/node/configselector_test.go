@@ -0,0 +1,156 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newGenerationConfig returns a config of the given generation created ago.
+func newGenerationConfig(name, generation string, ago time.Duration) *mcfgv1.MachineConfig {
+	config := newMachineConfig(name)
+	config.Labels = map[string]string{"generation": generation}
+	config.CreationTimestamp = metav1.NewTime(time.Now().Add(-ago))
+	return config
+}
+
+// syncSelectingPool syncs a worker pool selecting the configs of generation 42 among
+// configs, with a node on v0. It returns the desired configs the node was patched to,
+// along with the error of the sync.
+func syncSelectingPool(t *testing.T, configs ...*mcfgv1.MachineConfig) ([]string, error) {
+	desired, _, err := syncSelectingPoolWith(t, func(*mcfgv1.MachineConfigPool) {}, configs...)
+	return desired, err
+}
+
+// syncSelectingPoolWith is syncSelectingPool passing the pool to setup first. It also
+// returns the pool as written by the sync.
+func syncSelectingPoolWith(t *testing.T, setup func(*mcfgv1.MachineConfigPool), configs ...*mcfgv1.MachineConfig) ([]string, *mcfgv1.MachineConfigPool, error) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "")
+	mcp.Spec.ConfigurationSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "generation", "42")
+	setup(mcp)
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"))
+	f.mcLister = append(f.mcLister, configs...)
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	err := c.syncHandler(getKey(mcp, t))
+
+	var desired []string
+	for _, action := range f.kubeclient.Actions() {
+		if action.Matches("patch", "nodes") {
+			patched, getErr := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+			desired = append(desired, patched.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+		}
+	}
+	pool, getErr := f.client.MachineconfigurationV1().MachineConfigPools().Get("worker", metav1.GetOptions{})
+	if getErr != nil {
+		t.Fatal(getErr)
+	}
+	return desired, pool, err
+}
+
+func TestConfigurationSelectorPicksNewest(t *testing.T) {
+	desired, err := syncSelectingPool(t,
+		newGenerationConfig("rendered-worker-old", "42", 3*time.Hour),
+		newGenerationConfig("rendered-worker-new", "42", time.Hour),
+		newGenerationConfig("rendered-worker-prev", "41", time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desired) != 1 || desired[0] != "rendered-worker-new" {
+		t.Fatalf("expected the node to be updated to the newest selected config, got %v", desired)
+	}
+}
+
+func TestConfigurationSelectorWithoutMatch(t *testing.T) {
+	desired, err := syncSelectingPool(t, newGenerationConfig("rendered-worker-prev", "41", time.Minute))
+	if syncErrorReason(err) != syncErrorMachineConfigNotFound {
+		t.Fatalf("expected the sync to fail while no config is selected, got %v", err)
+	}
+	if len(desired) != 0 {
+		t.Fatalf("expected no node to be updated, got %v", desired)
+	}
+}
+
+func TestConfigurationSelectorChange(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	desired, pool, err := syncSelectingPoolWith(t, func(pool *mcfgv1.MachineConfigPool) {
+		pool.Status.ResolvedConfig = "rendered-worker-old"
+	}, newGenerationConfig("rendered-worker-old", "42", 3*time.Hour), newGenerationConfig("rendered-worker-new", "42", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desired) != 1 || desired[0] != "rendered-worker-new" {
+		t.Fatalf("expected the node to be updated to the newest selected config, got %v", desired)
+	}
+	// The new resolution counts as a change of the target config.
+	if pool.Status.ResolvedConfig != "rendered-worker-new" {
+		t.Fatalf("expected the resolved config to be recorded, got %q", pool.Status.ResolvedConfig)
+	}
+	if changed := pool.Status.LastConfigChangeTime; changed == nil || changed.Time.Before(start) {
+		t.Fatalf("expected the config change to be recorded, got %v", changed)
+	}
+	history := pool.Status.RolloutHistory
+	if len(history) != 1 || history[0].Config != "rendered-worker-new" {
+		t.Fatalf("expected a rollout of the new config to be recorded, got %v", history)
+	}
+}
+
+func TestConfigurationSelectorPaused(t *testing.T) {
+	// A paused pool keeps the config it last resolved to, even when none matches anymore.
+	desired, pool, err := syncSelectingPoolWith(t, func(pool *mcfgv1.MachineConfigPool) {
+		pool.Spec.Paused = true
+		pool.Status.ResolvedConfig = "rendered-worker-old"
+	}, newGenerationConfig("rendered-worker-old", "41", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(desired) != 0 {
+		t.Fatalf("expected no node of the paused pool to be updated, got %v", desired)
+	}
+	if pool.Status.ResolvedConfig != "rendered-worker-old" {
+		t.Fatalf("expected the paused pool to keep its resolved config, got %q", pool.Status.ResolvedConfig)
+	}
+}
+
+func TestNewestConfigTies(t *testing.T) {
+	created := metav1.NewTime(time.Now())
+	a, b := newMachineConfig("rendered-worker-a"), newMachineConfig("rendered-worker-b")
+	a.CreationTimestamp, b.CreationTimestamp = created, created
+	if newest := newestConfig([]*mcfgv1.MachineConfig{b, a}); newest != b {
+		t.Fatalf("expected ties to be broken by name, got %v", newest.Name)
+	}
+	if newest := newestConfig(nil); newest != nil {
+		t.Fatalf("expected no newest config among none, got %v", newest.Name)
+	}
+}
+
+func TestEnqueueSelectingPools(t *testing.T) {
+	f := newFixture(t)
+	selecting := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "")
+	selecting.Spec.ConfigurationSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "generation", "42")
+	named := newMachineConfigPool("infra", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	f.mcpLister = append(f.mcpLister, selecting, named)
+	c, enqueued := f.newControllerRecordingEnqueues()
+
+	c.enqueueSelectingPools(newGenerationConfig("rendered-worker-prev", "41", 0))
+	if len(*enqueued) != 0 {
+		t.Fatalf("expected a config no pool selects not to sync any pool, got %v", *enqueued)
+	}
+	c.enqueueSelectingPools(newGenerationConfig("rendered-worker-new", "42", 0))
+	if len(*enqueued) != 1 || (*enqueued)[0] != "worker" {
+		t.Fatalf("expected only the selecting pool to be enqueued, got %v", *enqueued)
+	}
+}
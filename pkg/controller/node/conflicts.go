@@ -0,0 +1,130 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conflictTracker counts the syncs in a row in which setting the desired config of a
+// node failed because of conflicts with other writers.
+type conflictTracker struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func newConflictTracker() *conflictTracker {
+	return &conflictTracker{counts: map[string]int{}}
+}
+
+// conflict records another failed update of node and returns the number in a row.
+func (c *conflictTracker) conflict(node string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.counts[node]++
+	return c.counts[node]
+}
+
+// count returns the number of failed updates of node in a row.
+func (c *conflictTracker) count(node string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.counts[node]
+}
+
+// forget drops the failed updates of node, e.g. because an update succeeded.
+func (c *conflictTracker) forget(node string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.counts, node)
+}
+
+// WithConflictEscalation escalates nodes whose desired config couldn't be set because of
+// conflicts in threshold syncs in a row, usually because another controller fights over
+// the node: a warning event names the field manager that last wrote the node. Zero, the
+// default, never escalates.
+func WithConflictEscalation(threshold int) Option {
+	return func(ctrl *Controller) {
+		ctrl.conflictThreshold = threshold
+	}
+}
+
+// recordDesiredConfigResult tracks the conflicts setting the desired config of node
+// across syncs of pool, and warns once they reach the threshold.
+func (ctrl *Controller) recordDesiredConfigResult(pool *mcfgv1.MachineConfigPool, node *corev1.Node, err error) {
+	if ctrl.conflictThreshold <= 0 {
+		return
+	}
+	if !errors.IsConflict(err) {
+		if err == nil {
+			ctrl.conflicts.forget(node.Name)
+		}
+		return
+	}
+	if ctrl.conflicts.conflict(node.Name) != ctrl.conflictThreshold {
+		return
+	}
+	manager := lastDesiredConfigManager(node)
+	if manager == "" {
+		manager = "unknown"
+	}
+	glog.Warningf("Pool %s: setting the desired config of node %s conflicted in %d syncs in a row, last written by %s", pool.Name, node.Name, ctrl.conflictThreshold, manager)
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "NodeUpdateConflicts", "Setting the desired config of node %s conflicted in %d syncs in a row, another writer is likely fighting over it; last written by field manager %s", node.Name, ctrl.conflictThreshold, manager)
+}
+
+// lastDesiredConfigManager returns the field manager that most recently wrote the desired
+// config annotation of node, or if none is known the one that most recently wrote the
+// node at all. It returns an empty string if the node carries no managed fields.
+func lastDesiredConfigManager(node *corev1.Node) string {
+	var latest, latestDesired *metav1.ManagedFieldsEntry
+	for i := range node.ManagedFields {
+		entry := &node.ManagedFields[i]
+		if latest == nil || isWrittenAfter(entry, latest) {
+			latest = entry
+		}
+		if managesDesiredConfig(entry.Fields) && (latestDesired == nil || isWrittenAfter(entry, latestDesired)) {
+			latestDesired = entry
+		}
+	}
+	switch {
+	case latestDesired != nil:
+		return latestDesired.Manager
+	case latest != nil:
+		return latest.Manager
+	}
+	return ""
+}
+
+// isWrittenAfter returns whether entry was written after other. Entries without a time
+// are assumed to be older.
+func isWrittenAfter(entry, other *metav1.ManagedFieldsEntry) bool {
+	if entry.Time == nil {
+		return false
+	}
+	return other.Time == nil || other.Time.Before(entry.Time)
+}
+
+// managesDesiredConfig returns whether fields include the desired config annotation.
+func managesDesiredConfig(fields *metav1.Fields) bool {
+	if fields == nil {
+		return false
+	}
+	metadata, ok := fields.Map["f:metadata"]
+	if !ok {
+		return false
+	}
+	annotations, ok := metadata.Map["f:annotations"]
+	if !ok {
+		return false
+	}
+	_, ok = annotations.Map["f:"+daemonconsts.DesiredMachineConfigAnnotationKey]
+	return ok
+}
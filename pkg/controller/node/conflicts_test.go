@@ -0,0 +1,152 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestLastDesiredConfigManager(t *testing.T) {
+	desiredConfigFields := &metav1.Fields{Map: map[string]metav1.Fields{
+		"f:metadata": {Map: map[string]metav1.Fields{
+			"f:annotations": {Map: map[string]metav1.Fields{
+				"f:" + daemonconsts.DesiredMachineConfigAnnotationKey: {},
+			}},
+		}},
+	}}
+	earlier := metav1.NewTime(time.Now().Add(-time.Minute))
+	later := metav1.NewTime(time.Now())
+	node := newNode("node-0", "v0", "v0")
+	if got := lastDesiredConfigManager(node); got != "" {
+		t.Fatalf("expected no manager without managed fields, got %q", got)
+	}
+	node.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "kubelet", Time: &later},
+		{Manager: "node-labeler", Time: &earlier, Fields: desiredConfigFields},
+	}
+	if got := lastDesiredConfigManager(node); got != "node-labeler" {
+		t.Fatalf("expected the manager of the desired config, got %q", got)
+	}
+	node.ManagedFields = node.ManagedFields[:1]
+	if got := lastDesiredConfigManager(node); got != "kubelet" {
+		t.Fatalf("expected the latest manager of the node, got %q", got)
+	}
+}
+
+func TestConflictEscalation(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts,
+		WithConflictEscalation(2),
+		WithNodeUpdateBackoff(wait.Backoff{Steps: 1, Duration: time.Millisecond}))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	now := metav1.Now()
+	node.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "node-labeler", Time: &now}}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	f.kubeclient.PrependReactor("patch", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-0", fmt.Errorf("conflict"))
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := c.syncHandler(getKey(mcp, t)); err == nil || syncErrorReason(err) != syncErrorSetDesiredConfig {
+			t.Fatalf("sync %d: expected setting the desired config to fail, got %v", i, err)
+		}
+	}
+	var warned bool
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.HasPrefix(event, "Warning NodeUpdateConflicts") {
+			warned = true
+			if !strings.Contains(event, "node-labeler") {
+				t.Fatalf("expected the conflicting field manager to be named, got %q", event)
+			}
+		}
+	}
+	if !warned {
+		t.Fatalf("expected a warning once the threshold was reached")
+	}
+}
+
+func TestConflictEscalationServerSideApply(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithConflictEscalation(2), WithServerSideApply())
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	// Only the urgent config write sets a desired config, which escalates conflicts too.
+	mcp.Annotations = map[string]string{
+		urgentConfigAnnotationKey:    "v2",
+		urgentNodesAnnotationKey:     "node-0",
+		urgentConfigAckAnnotationKey: "v2",
+	}
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	now := metav1.Now()
+	node.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: fieldManager, Operation: metav1.ManagedFieldsOperationApply},
+		{Manager: "node-labeler", Time: &now},
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"), newMachineConfig("v2"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	applies := 0
+	c.applyNode = func(name string, data []byte, force bool) (*corev1.Node, error) {
+		applies++
+		return nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, name, fmt.Errorf("conflict with node-labeler"))
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.syncHandler(getKey(mcp, t)); err == nil || syncErrorReason(err) != syncErrorSetDesiredConfig {
+			t.Fatalf("sync %d: expected setting the urgent config to fail, got %v", i, err)
+		}
+	}
+	if applies != 2 {
+		t.Fatalf("expected ownership conflicts not to be retried, applied %d times", applies)
+	}
+	var warned bool
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.HasPrefix(event, "Warning NodeUpdateConflicts") {
+			warned = true
+			if !strings.Contains(event, "node-labeler") {
+				t.Fatalf("expected the conflicting field manager to be named, got %q", event)
+			}
+		}
+	}
+	if !warned {
+		t.Fatalf("expected a warning once the threshold was reached")
+	}
+}
+
+func TestDeleteNodeForgetsConflicts(t *testing.T) {
+	f := newFixture(t)
+	f.mcpLister = append(f.mcpLister, newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1"))
+	c := f.newController()
+	node := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": ""})
+	c.conflicts.conflict(node.Name)
+
+	// A node re-created with the same name starts over.
+	c.deleteNode(node)
+	if got := c.conflicts.count(node.Name); got != 0 {
+		t.Fatalf("expected the conflicts of a deleted node to be forgotten, got %d", got)
+	}
+}
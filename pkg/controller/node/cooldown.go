@@ -0,0 +1,87 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// completionTracker remembers when nodes last completed an update.
+type completionTracker struct {
+	lock      sync.Mutex
+	completed map[string]time.Time
+	now       func() time.Time
+}
+
+func newCompletionTracker() *completionTracker {
+	return &completionTracker{
+		completed: map[string]time.Time{},
+		now:       time.Now,
+	}
+}
+
+// complete records that node completed an update now.
+func (c *completionTracker) complete(node string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.completed[node] = c.now()
+}
+
+// forget drops the completion time of node, e.g. because it was deleted.
+func (c *completionTracker) forget(node string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.completed, node)
+}
+
+// remaining returns how much of cooldown is left since node last completed an update.
+func (c *completionTracker) remaining(node string, cooldown time.Duration) time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	completed, ok := c.completed[node]
+	if !ok {
+		return 0
+	}
+	remaining := completed.Add(cooldown).Sub(c.now())
+	if remaining <= 0 {
+		delete(c.completed, node)
+		return 0
+	}
+	return remaining
+}
+
+// WithUpdateCooldown leaves nodes alone for cooldown after they completed an update, so a
+// node that just rebooted isn't rebooted again right away for an unrelated change. Zero,
+// the default, selects nodes regardless of when they were last updated.
+func WithUpdateCooldown(cooldown time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.updateCooldown = cooldown
+	}
+}
+
+// getCoolingDownNodes returns the names of the nodes that completed an update within the
+// cooldown, along with how long until the first of them cools down. Like nodes held for
+// other reasons, they count as unavailable and aren't updated.
+func (ctrl *Controller) getCoolingDownNodes(nodes []*corev1.Node) (sets.String, time.Duration) {
+	coolingDown := sets.NewString()
+	if ctrl.updateCooldown <= 0 {
+		return coolingDown, 0
+	}
+	var next time.Duration
+	for _, node := range nodes {
+		remaining := ctrl.completions.remaining(node.Name, ctrl.updateCooldown)
+		if remaining <= 0 {
+			continue
+		}
+		coolingDown.Insert(node.Name)
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return coolingDown, next
+}
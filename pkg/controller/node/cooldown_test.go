@@ -0,0 +1,76 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCompletionTracker(t *testing.T) {
+	now := time.Now()
+	c := newCompletionTracker()
+	c.now = func() time.Time { return now }
+	c.complete("node-0")
+
+	now = now.Add(10 * time.Minute)
+	if got := c.remaining("node-0", time.Hour); got != 50*time.Minute {
+		t.Fatalf("expected 50m of the cooldown left, got %v", got)
+	}
+	if got := c.remaining("node-1", time.Hour); got != 0 {
+		t.Fatalf("expected no cooldown for a node that didn't complete an update, got %v", got)
+	}
+	now = now.Add(time.Hour)
+	if got := c.remaining("node-0", time.Hour); got != 0 {
+		t.Fatalf("expected the cooldown to be over, got %v", got)
+	}
+}
+
+func TestUpdateCooldown(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithUpdateCooldown(time.Hour))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v2")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", labels),
+		newNodeWithLabel("node-1", "v1", "v1", labels),
+		newNodeWithLabel("node-2", "v1", "v1", labels),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v1"), newMachineConfig("v2"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	// node-0 just completed its update to v1.
+	c.updateNode(newNodeWithLabel("node-0", "v0", "v1", labels), nodes[0])
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// node-0 is left alone and counts against maxUnavailable, leaving room for one update.
+	updated := 0
+	for _, name := range []string{"node-0", "node-1", "node-2"} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "v2" {
+			continue
+		}
+		if name == "node-0" {
+			t.Fatalf("expected the node cooling down not to be updated")
+		}
+		updated++
+	}
+	if updated != 1 {
+		t.Fatalf("expected 1 node to be updated, got %d", updated)
+	}
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// cordonOnSelect cordons node, which is being set to desiredConfig, and marks that the
+// controller cordoned it. Nodes cordoned already, e.g. by an admin, are left alone.
+func cordonOnSelect(node *corev1.Node, desiredConfig string) {
+	if node.Spec.Unschedulable {
+		return
+	}
+	node.Spec.Unschedulable = true
+	node.Annotations[cordonedOnSelectAnnotationKey] = desiredConfig
+}
+
+// releaseSelectCordons drops the marker of the nodes the controller cordoned when setting
+// their desired config once they are done with their desired config. Usually the daemon
+// uncordoned them when completing the update; if it never started it, e.g. because the
+// desired config was reverted before, they are uncordoned here.
+func (ctrl *Controller) releaseSelectCordons(nodes []*corev1.Node) error {
+	var errs []error
+	for _, node := range nodes {
+		if _, ok := node.Annotations[cordonedOnSelectAnnotationKey]; !ok || !isNodeDone(node) {
+			continue
+		}
+		if node.Spec.Unschedulable {
+			glog.Infof("Node %s is done without the update it was cordoned for, uncordoning it", node.Name)
+		}
+		if err := ctrl.releaseSelectCordon(node.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (ctrl *Controller) releaseSelectCordon(nodeName string) error {
+	return ctrl.retryNodeUpdate(func() error {
+		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := oldNode.Annotations[cordonedOnSelectAnnotationKey]; !ok || !isNodeDone(oldNode) {
+			return nil
+		}
+		oldData, err := json.Marshal(oldNode)
+		if err != nil {
+			return err
+		}
+		newNode := oldNode.DeepCopy()
+		newNode.Spec.Unschedulable = false
+		delete(newNode.Annotations, cordonedOnSelectAnnotationKey)
+		newData, err := json.Marshal(newNode)
+		if err != nil {
+			return err
+		}
+		patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+		if err != nil {
+			return fmt.Errorf("failed to create patch for node %q: %v", nodeName, err)
+		}
+		_, err = ctrl.kubeClient.CoreV1().Nodes().Patch(nodeName, types.StrategicMergePatchType, patchBytes)
+		return err
+	})
+}
@@ -0,0 +1,102 @@
+package node
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestCordonOnSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		cordon   bool
+		expected string
+	}{{
+		name:     "schedulable node",
+		node:     newNode("node-0", "v0", "v0"),
+		cordon:   true,
+		expected: `{"metadata":{"annotations":{"machineconfiguration.openshift.io/cordoned-on-select":"v1","machineconfiguration.openshift.io/desiredConfig":"v1"}},"spec":{"unschedulable":true}}`,
+	}, {
+		name:     "node cordoned already",
+		node:     newCordonedNode("node-0", "v0", "v0"),
+		cordon:   true,
+		expected: `{"metadata":{"annotations":{"machineconfiguration.openshift.io/desiredConfig":"v1"}}}`,
+	}, {
+		name:     "disabled",
+		node:     newNode("node-0", "v0", "v0"),
+		expected: `{"metadata":{"annotations":{"machineconfiguration.openshift.io/desiredConfig":"v1"}}}`,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.kubeobjects = append(f.kubeobjects, test.node)
+			c := f.newController()
+
+			if err := c.setDesiredMachineConfig("node-0", "v1", test.cordon); err != nil {
+				t.Fatal(err)
+			}
+			var patches []string
+			for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+				if patch, ok := action.(core.PatchAction); ok {
+					patches = append(patches, string(patch.GetPatch()))
+				}
+			}
+			if len(patches) != 1 || patches[0] != test.expected {
+				t.Fatalf("expected the single patch %s, got %v", test.expected, patches)
+			}
+		})
+	}
+}
+
+func TestCordonOnSelectSync(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.CordonOnSelect = true
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Spec.Unschedulable || updated.Annotations[cordonedOnSelectAnnotationKey] != "v1" {
+		t.Fatalf("expected node-0 to be cordoned when selected, got unschedulable %t and annotations %v", updated.Spec.Unschedulable, updated.Annotations)
+	}
+}
+
+func TestReleaseSelectCordons(t *testing.T) {
+	done := withAnnotation(newCordonedNode("node-0", "v1", "v1"), cordonedOnSelectAnnotationKey, "v1")
+	updating := withAnnotation(newCordonedNode("node-1", "v0", "v1"), cordonedOnSelectAnnotationKey, "v1")
+	f := newFixture(t)
+	f.kubeobjects = append(f.kubeobjects, done, updating)
+	c := f.newController()
+
+	if err := c.releaseSelectCordons([]*corev1.Node{done, updating}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"metadata":{"annotations":{"machineconfiguration.openshift.io/cordoned-on-select":null}},"spec":{"unschedulable":null}}`
+	var patched []string
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if patch, ok := action.(core.PatchAction); ok {
+			if got := string(patch.GetPatch()); got != expected {
+				t.Fatalf("mismatch patch of %s: got %s want: %s", patch.GetName(), got, expected)
+			}
+			patched = append(patched, patch.GetName())
+		}
+	}
+	if len(patched) != 1 || patched[0] != "node-0" {
+		t.Fatalf("expected only the node done with its update to be uncordoned, got %v", patched)
+	}
+}
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultsMaxUnavailableKey is the key of the defaults ConfigMap holding the
+// maxUnavailable of pools that don't set their own, as a number or a percentage.
+const defaultsMaxUnavailableKey = "maxUnavailable"
+
+// WithDefaultsConfigMap reads the maxUnavailable of pools that don't set one in their spec
+// from the ConfigMap namespace/name watched by configMapInformer, so that a fleet-wide policy
+// can be set in one place instead of on every pool. Changes to the ConfigMap resync the
+// pools it applies to. configMapInformer only needs to watch that ConfigMap, e.g. through a
+// field selector on its name. An empty name, the default, leaves those pools at one node at
+// a time.
+func WithDefaultsConfigMap(configMapInformer coreinformersv1.ConfigMapInformer, namespace, name string) Option {
+	return func(ctrl *Controller) {
+		if name == "" {
+			return
+		}
+		ctrl.defaultsConfigMapNamespace = namespace
+		ctrl.defaultsConfigMap = name
+		informer := configMapInformer.Informer()
+		informer.AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				cm, ok := obj.(*corev1.ConfigMap)
+				return ok && cm.Namespace == namespace && cm.Name == name
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    func(interface{}) { ctrl.enqueueDefaultedPools() },
+				UpdateFunc: func(interface{}, interface{}) { ctrl.enqueueDefaultedPools() },
+				DeleteFunc: func(interface{}) { ctrl.enqueueDefaultedPools() },
+			},
+		})
+		ctrl.configMapLister = configMapInformer.Lister()
+		ctrl.configMapListerSynced = informer.HasSynced
+	}
+}
+
+// enqueueDefaultedPools enqueues the pools taking their maxUnavailable from the defaults
+// ConfigMap, e.g. because it changed.
+func (ctrl *Controller) enqueueDefaultedPools() {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Warningf("Failed to list pools to apply changed defaults: %v", err)
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.MaxUnavailable == nil {
+			glog.V(4).Infof("Defaults ConfigMap %s changed, syncing pool %s", ctrl.defaultsConfigMap, pool.Name)
+			ctrl.enqueueMachineConfigPool(pool)
+		}
+	}
+}
+
+// defaultMaxUnavailable returns the maxUnavailable of the defaults ConfigMap, or nil if
+// there is none or it's invalid.
+func (ctrl *Controller) defaultMaxUnavailable() *intstrutil.IntOrString {
+	if ctrl.configMapLister == nil {
+		return nil
+	}
+	cm, err := ctrl.configMapLister.ConfigMaps(ctrl.defaultsConfigMapNamespace).Get(ctrl.defaultsConfigMap)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		glog.Warningf("Failed to get the defaults ConfigMap %s: %v", ctrl.defaultsConfigMap, err)
+		return nil
+	}
+	value, ok := cm.Data[defaultsMaxUnavailableKey]
+	if !ok {
+		return nil
+	}
+	maxUnavailable := intstrutil.Parse(value)
+	if _, err := intstrutil.GetValueFromIntOrPercent(&maxUnavailable, 100, false); err != nil {
+		glog.Warningf("Ignoring invalid %s %q in the defaults ConfigMap %s: %v", defaultsMaxUnavailableKey, value, ctrl.defaultsConfigMap, err)
+		return nil
+	}
+	return &maxUnavailable
+}
+
+// applyDefaults sets the maxUnavailable of pool from the defaults ConfigMap if its spec
+// doesn't set one. pool must be a copy; only its status is ever written back.
+func (ctrl *Controller) applyDefaults(pool *mcfgv1.MachineConfigPool) {
+	if pool.Spec.MaxUnavailable != nil {
+		return
+	}
+	pool.Spec.MaxUnavailable = ctrl.defaultMaxUnavailable()
+}
@@ -0,0 +1,90 @@
+package node
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDefaultsConfigMapMaxUnavailable(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v1", "v1"),
+		newNode("node-2", "v1", "v1"),
+		newNode("node-3", "v1", "v1"),
+		newNode("node-4", "v1", "v1"),
+	}
+	tests := []struct {
+		name     string
+		spec     *intstr.IntOrString
+		defaults map[string]string
+		expected int
+	}{{
+		name:     "spec wins over the ConfigMap",
+		spec:     intStrPtr(intstr.FromInt(2)),
+		defaults: map[string]string{"maxUnavailable": "3"},
+		expected: 2,
+	}, {
+		name:     "ConfigMap applies without a spec",
+		defaults: map[string]string{"maxUnavailable": "3"},
+		expected: 3,
+	}, {
+		name:     "ConfigMap percentage",
+		defaults: map[string]string{"maxUnavailable": "40%"},
+		expected: 2,
+	}, {
+		name:     "ConfigMap without the key",
+		defaults: map[string]string{},
+		expected: 1,
+	}, {
+		name:     "invalid ConfigMap value",
+		defaults: map[string]string{"maxUnavailable": "lots"},
+		expected: 1,
+	}, {
+		name:     "no ConfigMap",
+		expected: 1,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			configMaps := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), noResyncPeriodFunc()).Core().V1().ConfigMaps()
+			if test.defaults != nil {
+				configMaps.Informer().GetIndexer().Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "pool-defaults", Namespace: "openshift-machine-config-operator"},
+					Data:       test.defaults,
+				})
+			}
+			f.opts = append(f.opts, WithDefaultsConfigMap(configMaps, "openshift-machine-config-operator", "pool-defaults"))
+			c := f.newController()
+
+			pool := newMachineConfigPool("worker", nil, test.spec, "v1")
+			c.applyDefaults(pool)
+			got, err := maxUnavailable(pool, nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Fatalf("expected maxUnavailable %d, got %d", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultsConfigMapChangeEnqueuesPools(t *testing.T) {
+	f := newFixture(t)
+	defaulted := newMachineConfigPool("worker", nil, nil, "v1")
+	explicit := newMachineConfigPool("infra", nil, intStrPtr(intstr.FromInt(2)), "v1")
+	f.mcpLister = append(f.mcpLister, defaulted, explicit)
+	configMaps := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), noResyncPeriodFunc()).Core().V1().ConfigMaps()
+	f.opts = append(f.opts, WithDefaultsConfigMap(configMaps, "openshift-machine-config-operator", "pool-defaults"))
+	c, enqueued := f.newControllerRecordingEnqueues()
+
+	c.enqueueDefaultedPools()
+	if len(*enqueued) != 1 || (*enqueued)[0] != "worker" {
+		t.Fatalf("expected only the pool without its own maxUnavailable to be enqueued, got %v", *enqueued)
+	}
+}
@@ -0,0 +1,35 @@
+package node
+
+import (
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// maxDegradedResult is the outcome of comparing the nodes of a pool failing its
+// target config with spec.maxDegraded.
+type maxDegradedResult struct {
+	// failing is the number of nodes failing to apply the target config.
+	failing int
+	// limit is spec.maxDegraded resolved against the number of nodes.
+	limit int
+	// reached is set if the rollout must halt.
+	reached bool
+}
+
+// checkMaxDegraded returns whether so many nodes of pool fail to apply its target config
+// that spec.maxDegraded halts the rollout.
+func checkMaxDegraded(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (maxDegradedResult, error) {
+	var result maxDegradedResult
+	if pool.Spec.MaxDegraded == nil {
+		return result, nil
+	}
+	limit, err := intstrutil.GetValueFromIntOrPercent(pool.Spec.MaxDegraded, len(nodes), true)
+	if err != nil {
+		return result, err
+	}
+	result.limit = limit
+	result.failing = countFailingThisConfig(pool, nodes)
+	result.reached = result.failing > 0 && result.failing >= limit
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCheckMaxDegraded(t *testing.T) {
+	failing := func(name string) *corev1.Node {
+		return newNodeWithReadyAndDaemonState(name, "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded)
+	}
+	tests := []struct {
+		name        string
+		maxDegraded *intstr.IntOrString
+		nodes       []*corev1.Node
+		reached     bool
+	}{{
+		name:  "unset",
+		nodes: []*corev1.Node{failing("node-0"), failing("node-1")},
+	}, {
+		name:        "below the limit",
+		maxDegraded: intStrPtr(intstr.FromInt(2)),
+		nodes:       []*corev1.Node{failing("node-0"), newNode("node-1", "v0", "v0")},
+	}, {
+		name:        "at the limit",
+		maxDegraded: intStrPtr(intstr.FromInt(2)),
+		nodes:       []*corev1.Node{failing("node-0"), failing("node-1")},
+		reached:     true,
+	}, {
+		name:        "failing another config",
+		maxDegraded: intStrPtr(intstr.FromInt(1)),
+		nodes:       []*corev1.Node{newNodeWithReadyAndDaemonState("node-0", "v0", "v-1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded)},
+	}, {
+		name:        "percentage rounded up",
+		maxDegraded: intStrPtr(intstr.FromString("10%")),
+		nodes:       []*corev1.Node{failing("node-0"), newNode("node-1", "v0", "v0"), newNode("node-2", "v0", "v0")},
+		reached:     true,
+	}, {
+		name:        "zero with no failing node",
+		maxDegraded: intStrPtr(intstr.FromInt(0)),
+		nodes:       []*corev1.Node{newNode("node-0", "v0", "v0")},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, nil, "v1")
+			pool.Spec.MaxDegraded = test.maxDegraded
+			got, err := checkMaxDegraded(pool, test.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.reached != test.reached {
+				t.Fatalf("mismatch reached: got %t want: %t (%d failing, limit %d)", got.reached, test.reached, got.failing, got.limit)
+			}
+		})
+	}
+}
+
+func TestMaxDegradedHaltsRollout(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v1")
+	mcp.Spec.MaxDegraded = intStrPtr(intstr.FromInt(1))
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v1", labels),
+		newNodeWithLabel("node-1", "v0", "v0", labels),
+	}
+	nodes[0].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("expected no node to be updated once maxDegraded is reached, got %v", action)
+		}
+	}
+	select {
+	case got := <-recorder.Events:
+		if want := "Warning MaxDegradedReached"; !strings.HasPrefix(got, want) {
+			t.Fatalf("mismatch event: got %q want prefix: %q", got, want)
+		}
+	default:
+		t.Fatalf("expected an event about the halted rollout")
+	}
+
+	// The halted rollout is only reported again once the failures change.
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	for len(recorder.Events) > 0 {
+		if got := <-recorder.Events; strings.Contains(got, "MaxDegradedReached") {
+			t.Fatalf("expected the unchanged halt not to be reported again, got %q", got)
+		}
+	}
+	pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get("worker", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolDegraded)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != "MaxDegradedReached" {
+		t.Fatalf("expected the pool to be Degraded because maxDegraded was reached, got %v", cond)
+	}
+}
@@ -0,0 +1,347 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	drain "github.com/openshift/kubernetes-drain"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// drainRetryInterval is how long a node whose drain failed is left alone before the
+// drain is retried.
+const drainRetryInterval = time.Minute
+
+// drainTimeoutGrace is how much longer than the controller the drain library waits for
+// the pods of a node, so that the controller always notices a timeout first.
+const drainTimeoutGrace = time.Minute
+
+// defaultDrainTimeout bounds the drains of policies without a timeout, which would
+// otherwise wait forever for pods that can't be evicted.
+const defaultDrainTimeout = time.Hour
+
+// drainTimeoutError is the error of a drain whose pods weren't evicted within the
+// timeout of its policy.
+type drainTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *drainTimeoutError) Error() string {
+	return fmt.Sprintf("drain did not complete within %v", e.timeout)
+}
+
+// nodeDrain is a drain of a node ahead of its update to config.
+type nodeDrain struct {
+	config   string
+	running  bool
+	err      error
+	finished time.Time
+}
+
+// drainTracker tracks the drains of nodes the controller runs in the background.
+type drainTracker struct {
+	lock   sync.Mutex
+	drains map[string]nodeDrain
+}
+
+func newDrainTracker() *drainTracker {
+	return &drainTracker{drains: map[string]nodeDrain{}}
+}
+
+// start records that a drain of node ahead of its update to config started, unless one
+// is running already, and returns whether it did.
+func (d *drainTracker) start(node, config string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.drains[node].running {
+		return false
+	}
+	d.drains[node] = nodeDrain{config: config, running: true}
+	return true
+}
+
+// finish records that the drain of node finished at now with err.
+func (d *drainTracker) finish(node string, err error, now time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	entry, ok := d.drains[node]
+	if !ok {
+		return
+	}
+	entry.running = false
+	entry.err = err
+	entry.finished = now
+	d.drains[node] = entry
+}
+
+// get returns the drain of node, if any.
+func (d *drainTracker) get(node string) (nodeDrain, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	entry, ok := d.drains[node]
+	return entry, ok
+}
+
+// forget drops the drain of node, e.g. because its update started.
+func (d *drainTracker) forget(node string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.drains, node)
+}
+
+// drainOptions maps policy to the options of the eviction phase of a drain.
+func drainOptions(policy *mcfgv1.MachineConfigPoolDrainPolicy) *drain.DrainOptions {
+	options := &drain.DrainOptions{
+		GracePeriodSeconds: -1,
+		Force:              policy.ForceOnTimeout,
+		IgnoreDaemonsets:   policy.IgnoreDaemonSets,
+		DeleteLocalData:    policy.DeleteEmptyDirData,
+	}
+	if policy.GracePeriodSeconds != nil {
+		options.GracePeriodSeconds = int(*policy.GracePeriodSeconds)
+	}
+	options.Timeout = defaultDrainTimeout
+	if policy.Timeout != nil && policy.Timeout.Duration > 0 {
+		options.Timeout = policy.Timeout.Duration
+	}
+	return options
+}
+
+// evict cordons node and evicts its pods honoring PodDisruptionBudgets.
+func (ctrl *Controller) evict(node *corev1.Node, options *drain.DrainOptions) error {
+	return drain.Drain(ctrl.kubeClient, []*corev1.Node{node}, options)
+}
+
+// drainNode drains node with policy. If evicting its pods times out and the policy forces
+// on timeout, the pods left are deleted without eviction instead.
+func (ctrl *Controller) drainNode(node *corev1.Node, policy *mcfgv1.MachineConfigPoolDrainPolicy) error {
+	err := ctrl.evictWithin(node, drainOptions(policy))
+	if _, timedOut := err.(*drainTimeoutError); !timedOut || !policy.ForceOnTimeout {
+		return err
+	}
+	glog.Warningf("Evicting the pods of node %s timed out, deleting them by force: %v", node.Name, err)
+	return ctrl.forceDeletePods(node, policy)
+}
+
+// evictWithin evicts the pods of node, or returns a *drainTimeoutError once the timeout
+// of options elapsed. The drain library only gives up drainTimeoutGrace later, so the
+// eviction may go on in the background for that long.
+func (ctrl *Controller) evictWithin(node *corev1.Node, options *drain.DrainOptions) error {
+	timeout := options.Timeout
+	evictOptions := *options
+	evictOptions.Timeout = timeout + drainTimeoutGrace
+	done := make(chan error, 1)
+	go func() {
+		done <- ctrl.evictNode(node, &evictOptions)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return &drainTimeoutError{timeout: timeout}
+	}
+}
+
+// forceDeletePods deletes the pods of node without eviction, with the grace period of
+// policy, except for mirror and DaemonSet pods, which wouldn't go away anyway.
+func (ctrl *Controller) forceDeletePods(node *corev1.Node, policy *mcfgv1.MachineConfigPoolDrainPolicy) error {
+	pods, err := ctrl.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": node.Name}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	options := &metav1.DeleteOptions{}
+	if policy.GracePeriodSeconds != nil {
+		gracePeriod := int64(*policy.GracePeriodSeconds)
+		options.GracePeriodSeconds = &gracePeriod
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != node.Name || isMirrorPod(&pod) || isDaemonSetPod(&pod) {
+			continue
+		}
+		if hasEmptyDir(&pod) && !policy.DeleteEmptyDirData {
+			return fmt.Errorf("pod %s/%s has emptyDir data and the drain policy doesn't delete it", pod.Namespace, pod.Name)
+		}
+		err := ctrl.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, options)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseDrainCordon makes the named node schedulable again if a drain cordoned it, and
+// drops the marker of that drain.
+func (ctrl *Controller) releaseDrainCordon(nodeName string) error {
+	return ctrl.retryNodeUpdate(func() error {
+		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := oldNode.Annotations[drainCordonedAnnotationKey]; !ok {
+			return nil
+		}
+		oldData, err := json.Marshal(oldNode)
+		if err != nil {
+			return err
+		}
+		newNode := oldNode.DeepCopy()
+		newNode.Spec.Unschedulable = false
+		delete(newNode.Annotations, drainCordonedAnnotationKey)
+		newData, err := json.Marshal(newNode)
+		if err != nil {
+			return err
+		}
+		patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+		if err != nil {
+			return fmt.Errorf("failed to create patch for node %q: %v", nodeName, err)
+		}
+		_, err = ctrl.kubeClient.CoreV1().Nodes().Patch(nodeName, types.StrategicMergePatchType, patchBytes)
+		return err
+	})
+}
+
+func isMirrorPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return ok
+}
+
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+func hasEmptyDir(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// startDrain drains node in the background ahead of its update to config, and syncs
+// pool once the drain finished. Unless an admin cordoned node, it is marked as cordoned
+// by the drain first, so that it is uncordoned should the drain be dropped, even after a
+// restart of the controller.
+func (ctrl *Controller) startDrain(pool *mcfgv1.MachineConfigPool, node *corev1.Node, config string) {
+	if !ctrl.drains.start(node.Name, config) {
+		return
+	}
+	if marked, ok := node.Annotations[drainCordonedAnnotationKey]; (ok || !node.Spec.Unschedulable) && marked != config {
+		if err := ctrl.setNodeAnnotation(node.Name, drainCordonedAnnotationKey, config); err != nil {
+			glog.Warningf("Pool %s: failed to mark node %s as cordoned for its drain, retrying in %v: %v", pool.Name, node.Name, drainRetryInterval, err)
+			ctrl.drains.finish(node.Name, err, time.Now())
+			ctrl.enqueueAfter(pool, drainRetryInterval)
+			return
+		}
+	}
+	glog.Infof("Pool %s: draining node %s ahead of its update to %s", pool.Name, node.Name, config)
+	policy := pool.Spec.DrainPolicy.DeepCopy()
+	ctrl.runDrain(func() {
+		err := ctrl.drainNode(node, policy)
+		ctrl.drains.finish(node.Name, err, time.Now())
+		if err != nil {
+			glog.Warningf("Pool %s: draining node %s failed: %v", pool.Name, node.Name, err)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "DrainFailed", "Draining node %s ahead of its update to %s failed, retrying in %v: %v", node.Name, config, drainRetryInterval, err)
+		}
+		ctrl.enqueueAfter(pool, 0)
+	})
+}
+
+// handleDrains goes through the drains of the nodes of pool. Nodes drained for the
+// config they are due to update to are returned to be updated right away, while nodes
+// still draining, or whose drain failed, are held back. Failed drains are retried after
+// drainRetryInterval. Drains are only run if pool has a drain policy; nodes cordoned by a
+// drain that is dropped, because the policy was removed or the node is now due to update
+// to another config, are uncordoned. Drains the controller lost track of, e.g. because it
+// restarted, are picked up again from the marker of the nodes they cordoned.
+func (ctrl *Controller) handleDrains(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) ([]*corev1.Node, sets.String) {
+	var drained []*corev1.Node
+	draining := sets.NewString()
+	now := time.Now()
+	for _, node := range nodes {
+		config := nextConfig(pool, node)
+		entry, ok := ctrl.drains.get(node.Name)
+		if !ok {
+			marked, ok := node.Annotations[drainCordonedAnnotationKey]
+			switch {
+			case !ok:
+			case marked == node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]:
+				ctrl.handOverDrainCordon(pool, node.Name)
+			case pool.Spec.DrainPolicy != nil && marked == config:
+				draining.Insert(node.Name)
+				ctrl.startDrain(pool, node, config)
+			default:
+				ctrl.dropDrain(pool, node.Name, marked)
+			}
+			continue
+		}
+		if pool.Spec.DrainPolicy == nil || entry.config != config {
+			if !entry.running {
+				ctrl.drains.forget(node.Name)
+				ctrl.dropDrain(pool, node.Name, entry.config)
+			}
+			continue
+		}
+		switch {
+		case entry.running:
+			draining.Insert(node.Name)
+		case entry.err == nil:
+			drained = append(drained, node)
+		default:
+			draining.Insert(node.Name)
+			if remaining := entry.finished.Add(drainRetryInterval).Sub(now); remaining > 0 {
+				ctrl.enqueueAfter(pool, remaining)
+				continue
+			}
+			ctrl.startDrain(pool, node, config)
+		}
+	}
+	return drained, draining
+}
+
+// dropDrain uncordons the named node if the dropped drain for config cordoned it.
+func (ctrl *Controller) dropDrain(pool *mcfgv1.MachineConfigPool, nodeName, config string) {
+	glog.Infof("Pool %s: dropping the drain of node %s for %s", pool.Name, nodeName, config)
+	if err := ctrl.releaseDrainCordon(nodeName); err != nil {
+		glog.Warningf("Pool %s: failed to uncordon node %s: %v", pool.Name, nodeName, err)
+	}
+}
+
+// handOverDrainCordon turns the marker of the drain that cordoned the named node into
+// that of a node cordoned for its update, once its desired config was set, so that it is
+// uncordoned should the daemon not complete that update. See releaseSelectCordons.
+func (ctrl *Controller) handOverDrainCordon(pool *mcfgv1.MachineConfigPool, nodeName string) {
+	err := ctrl.updateNodeAnnotations(nodeName, func(annotations map[string]string) {
+		if config, ok := annotations[drainCordonedAnnotationKey]; ok {
+			delete(annotations, drainCordonedAnnotationKey)
+			annotations[cordonedOnSelectAnnotationKey] = config
+		}
+	})
+	if err != nil {
+		glog.Warningf("Pool %s: failed to hand over the drain cordon of node %s: %v", pool.Name, nodeName, err)
+	}
+}
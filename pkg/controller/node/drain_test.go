@@ -0,0 +1,271 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	drain "github.com/openshift/kubernetes-drain"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDrainPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		force   bool
+		hang    bool
+		err     string
+		updated bool
+		deleted bool
+	}{{
+		name: "strict",
+		hang: true,
+	}, {
+		name:    "force on timeout",
+		force:   true,
+		hang:    true,
+		updated: true,
+		deleted: true,
+	}, {
+		name:  "force on other errors",
+		force: true,
+		err:   "error when evicting pod-0: the server could not find the requested resource",
+	}, {
+		// Only the timeout of the controller forces the drain, whatever the drain library reports.
+		name:  "force on timeouts of the drain library",
+		force: true,
+		err:   "Drain did not complete within 1m0s",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+			timeout := 10 * time.Millisecond
+			mcp.Spec.DrainPolicy = &mcfgv1.MachineConfigPoolDrainPolicy{Timeout: &metav1.Duration{Duration: timeout}, ForceOnTimeout: test.force}
+			node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+			// An unmanaged pod, protected from eviction.
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: "node-0"},
+			}
+			f.mcpLister = append(f.mcpLister, mcp)
+			f.objects = append(f.objects, mcp)
+			f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+			f.nodeLister = append(f.nodeLister, node)
+			f.kubeobjects = append(f.kubeobjects, node, pod)
+			c := f.newController()
+			recorder := record.NewFakeRecorder(10)
+			c.eventRecorder = recorder
+			c.runDrain = func(run func()) { run() }
+			hung := make(chan struct{})
+			defer close(hung)
+			c.evictNode = func(node *corev1.Node, options *drain.DrainOptions) error {
+				if options.Timeout != timeout+drainTimeoutGrace || options.Force != test.force {
+					t.Errorf("expected an eviction outlasting the timeout forcing as the policy does, got %+v", options)
+				}
+				if test.hang {
+					<-hung
+				}
+				return fmt.Errorf("%s", test.err)
+			}
+
+			// The first sync drains the node, the second one updates it once drained.
+			for i := 0; i < 2; i++ {
+				if err := c.syncHandler(getKey(mcp, t)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated := got.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == "v1"; updated != test.updated {
+				t.Fatalf("mismatch node updated: got %t want: %t", updated, test.updated)
+			}
+			_, err = f.kubeclient.CoreV1().Pods("default").Get("pod-0", metav1.GetOptions{})
+			if deleted := err != nil; deleted != test.deleted {
+				t.Fatalf("mismatch pod deleted: got %t want: %t", deleted, test.deleted)
+			}
+			select {
+			case event := <-recorder.Events:
+				if test.updated || !strings.HasPrefix(event, "Warning DrainFailed") {
+					t.Fatalf("unexpected event %q", event)
+				}
+			default:
+				if !test.updated {
+					t.Fatalf("expected an event about the failed drain")
+				}
+			}
+		})
+	}
+}
+
+func TestDroppedDrainUncordons(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.DrainPolicy = &mcfgv1.MachineConfigPoolDrainPolicy{}
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	c.eventRecorder = record.NewFakeRecorder(10)
+	c.runDrain = func(run func()) { run() }
+	c.evictNode = func(node *corev1.Node, options *drain.DrainOptions) error {
+		// Like drain.Drain, cordon the node before failing to evict its pods.
+		cordoned, err := f.kubeclient.CoreV1().Nodes().Get(node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cordoned.Spec.Unschedulable = true
+		if _, err := f.kubeclient.CoreV1().Nodes().Update(cordoned); err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Errorf("cannot evict pod as it would violate the pod's disruption budget")
+	}
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The drain policy is removed while the drain is failing.
+	mcp.Spec.DrainPolicy = nil
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	uncordoned := false
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok && strings.Contains(string(patch.GetPatch()), `"`+drainCordonedAnnotationKey+`":null`) && strings.Contains(string(patch.GetPatch()), `"unschedulable":null`) {
+			uncordoned = true
+		}
+	}
+	if !uncordoned {
+		t.Fatalf("expected the node cordoned by the dropped drain to be uncordoned, got %v", f.kubeclient.Actions())
+	}
+	if _, ok := c.drains.get("node-0"); ok {
+		t.Fatalf("expected the dropped drain to be forgotten")
+	}
+}
+
+func TestDrainOptionsDefaultTimeout(t *testing.T) {
+	if got := drainOptions(&mcfgv1.MachineConfigPoolDrainPolicy{}).Timeout; got != defaultDrainTimeout {
+		t.Fatalf("expected a policy without timeout to drain for up to %v, got %v", defaultDrainTimeout, got)
+	}
+}
+
+func TestDrainAfterRestart(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  bool
+		desired string
+		// drained is set if the drain is expected to be restarted, otherwise the patch
+		// of the node is expected to contain every string of patch.
+		drained bool
+		patch   []string
+	}{{
+		name:    "drain resumed",
+		policy:  true,
+		desired: "v0",
+		drained: true,
+	}, {
+		name:    "drain dropped",
+		desired: "v0",
+		patch:   []string{`"` + drainCordonedAnnotationKey + `":null`, `"unschedulable":null`},
+	}, {
+		name:    "update started",
+		policy:  true,
+		desired: "v1",
+		patch:   []string{`"` + drainCordonedAnnotationKey + `":null`, `"` + cordonedOnSelectAnnotationKey + `":"v1"`},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+			if test.policy {
+				pool.Spec.DrainPolicy = &mcfgv1.MachineConfigPoolDrainPolicy{}
+			}
+			// The node was cordoned by a drain before the controller restarted.
+			node := withAnnotation(newNode("node-0", "v0", test.desired), drainCordonedAnnotationKey, "v1")
+			node.Spec.Unschedulable = true
+			f.kubeobjects = append(f.kubeobjects, node)
+			c := f.newController()
+			c.eventRecorder = record.NewFakeRecorder(10)
+			c.runDrain = func(run func()) { run() }
+			drained := false
+			c.evictNode = func(*corev1.Node, *drain.DrainOptions) error {
+				drained = true
+				return nil
+			}
+
+			_, draining := c.handleDrains(pool, []*corev1.Node{node})
+			if drained != test.drained || draining.Has("node-0") != test.drained {
+				t.Fatalf("mismatch drain restarted: got %t, held %t want: %t", drained, draining.Has("node-0"), test.drained)
+			}
+			var patches []string
+			for _, action := range f.kubeclient.Actions() {
+				if patch, ok := action.(core.PatchAction); ok {
+					patches = append(patches, string(patch.GetPatch()))
+				}
+			}
+			if test.drained {
+				if len(patches) != 0 {
+					t.Fatalf("expected the node to stay marked, got patches %v", patches)
+				}
+				return
+			}
+			if len(patches) != 1 {
+				t.Fatalf("expected a single patch of the node, got %v", patches)
+			}
+			for _, want := range test.patch {
+				if !strings.Contains(patches[0], want) {
+					t.Fatalf("expected patch %s to contain %s", patches[0], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWaitsForDrains(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithDrainTimeout(time.Minute))
+	c := f.newController()
+
+	release := make(chan struct{})
+	c.runDrain(func() { <-release })
+	synced := make(chan struct{})
+	c.syncHandler = func(key string) error {
+		close(synced)
+		return nil
+	}
+	c.queue.Add("worker")
+
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		c.Run(1, stopCh)
+		close(stopped)
+	}()
+	<-synced
+	close(stopCh)
+	select {
+	case <-stopped:
+		t.Fatalf("expected Run to wait for the running drain")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected Run to return once the drain finished")
+	}
+}
@@ -0,0 +1,32 @@
+package node
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WithDrainNotRequiredAnnotation lets nodes whose annotation key is set to "true", e.g.
+// nodes running no workloads worth draining, skip the drain of their updates. The
+// controller only passes the opt-out on to the daemon with skipDrainAnnotationKey when
+// setting the desired config; the daemon decides whether to honor it. Disabled if key
+// is empty.
+func WithDrainNotRequiredAnnotation(key string) Option {
+	return func(ctrl *Controller) {
+		ctrl.drainNotRequiredAnnotation = key
+	}
+}
+
+// isDrainNotRequired returns whether node opted out of draining.
+func (ctrl *Controller) isDrainNotRequired(node *corev1.Node) bool {
+	return ctrl.drainNotRequiredAnnotation != "" && node.Annotations[ctrl.drainNotRequiredAnnotation] == "true"
+}
+
+// signalSkipDrain sets the skip-drain signal in annotations, the annotations node is
+// written with along with desiredConfig, if node opted out of draining, and drops a
+// stale signal otherwise.
+func (ctrl *Controller) signalSkipDrain(node *corev1.Node, annotations map[string]string, desiredConfig string) {
+	if ctrl.isDrainNotRequired(node) {
+		annotations[skipDrainAnnotationKey] = desiredConfig
+		return
+	}
+	delete(annotations, skipDrainAnnotationKey)
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestDrainNotRequiredAnnotation(t *testing.T) {
+	const drainNotRequired = "example.com/drain-not-required"
+	f := newFixture(t)
+	f.opts = append(f.opts, WithDrainNotRequiredAnnotation(drainNotRequired))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(3)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		withAnnotation(newNodeWithLabel("node-0", "v0", "v0", labels), drainNotRequired, "true"),
+		withAnnotation(newNodeWithLabel("node-1", "v0", "v0", labels), drainNotRequired, "false"),
+		// A signal left from an earlier opt-out is dropped.
+		withAnnotation(newNodeWithLabel("node-2", "v0", "v0", labels), skipDrainAnnotationKey, "v0"),
+		// Opted-out nodes that aren't picked aren't signaled.
+		withAnnotation(newNodeWithLabel("node-3", "v0", "v0", labels), drainNotRequired, "true"),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	patches := map[string]string{}
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok {
+			patches[patch.GetName()] = string(patch.GetPatch())
+		}
+	}
+	if len(patches) != 3 || patches["node-3"] != "" {
+		t.Fatalf("expected node-0 to node-2 to be updated, got patches %v", patches)
+	}
+	if !strings.Contains(patches["node-0"], `"`+skipDrainAnnotationKey+`":"v1"`) {
+		t.Fatalf("expected the opted-out node-0 to be signaled to skip the drain, got %s", patches["node-0"])
+	}
+	if strings.Contains(patches["node-1"], skipDrainAnnotationKey) {
+		t.Fatalf("expected node-1 not to be signaled to skip the drain, got %s", patches["node-1"])
+	}
+	if !strings.Contains(patches["node-2"], `"`+skipDrainAnnotationKey+`":null`) {
+		t.Fatalf("expected the stale signal of node-2 to be dropped, got %s", patches["node-2"])
+	}
+}
+
+func TestDrainNotRequiredAnnotationDisabled(t *testing.T) {
+	c := newFixture(t).newController()
+	node := withAnnotation(newNode("node-0", "v0", "v0"), "example.com/drain-not-required", "true")
+	annotations := map[string]string{}
+	c.signalSkipDrain(node, annotations, "v1")
+	if _, ok := annotations[skipDrainAnnotationKey]; ok {
+		t.Fatalf("expected no skip-drain signal without a drain-not-required annotation key, got %v", annotations)
+	}
+}
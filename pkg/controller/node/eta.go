@@ -0,0 +1,107 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxRecentUpdateDurations is how many node update durations are kept per pool
+// to estimate the time remaining for a rollout.
+const maxRecentUpdateDurations = 10
+
+// updateDurations tracks how long recent node updates took in every pool.
+type updateDurations struct {
+	lock sync.Mutex
+	// started is when each node was last asked to move to a new desired config.
+	started map[string]time.Time
+	// recent holds the latest update durations of every pool, oldest first.
+	recent map[string][]time.Duration
+	now    func() time.Time
+}
+
+func newUpdateDurations() *updateDurations {
+	return &updateDurations{
+		started: map[string]time.Time{},
+		recent:  map[string][]time.Duration{},
+		now:     time.Now,
+	}
+}
+
+// start records that node began updating.
+func (d *updateDurations) start(node string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.started[node] = d.now()
+}
+
+// finish records that node of pool completed its update. Updates whose start
+// wasn't observed, e.g. because the controller restarted, are ignored.
+func (d *updateDurations) finish(pool, node string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	started, ok := d.started[node]
+	if !ok {
+		return
+	}
+	delete(d.started, node)
+	recent := append(d.recent[pool], d.now().Sub(started))
+	if len(recent) > maxRecentUpdateDurations {
+		recent = recent[len(recent)-maxRecentUpdateDurations:]
+	}
+	d.recent[pool] = recent
+}
+
+// forget drops the update of node in progress, e.g. because the node was deleted.
+func (d *updateDurations) forget(node string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.started, node)
+}
+
+// mean returns the mean of the recent update durations of pool, and false if
+// no update of the pool completed yet.
+func (d *updateDurations) mean(pool string) (time.Duration, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	recent := d.recent[pool]
+	if len(recent) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, r := range recent {
+		total += r
+	}
+	return total / time.Duration(len(recent)), true
+}
+
+// estimateTimeRemaining estimates how long the rollout of pool will take by
+// multiplying the mean recent node update duration by the number of batches
+// of maxUnavailable nodes still to update. It returns nil if the pool is paused,
+// done, or none of its node updates completed yet.
+func (d *updateDurations) estimateTimeRemaining(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, status mcfgv1.MachineConfigPoolStatus) *metav1.Duration {
+	if pool.Spec.Paused {
+		return nil
+	}
+	remaining := int(status.MachineCount - status.UpdatedMachineCount)
+	if remaining <= 0 {
+		return nil
+	}
+	mean, ok := d.mean(pool.Name)
+	if !ok {
+		return nil
+	}
+	m, err := calculateMaxUnavailable(pool, nodes)
+	if err != nil || m.effective <= 0 {
+		return nil
+	}
+	batches := (remaining + m.effective - 1) / m.effective
+	return &metav1.Duration{Duration: mean * time.Duration(batches)}
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestUpdateDurations(t *testing.T) {
+	now := time.Now()
+	d := newUpdateDurations()
+	d.now = func() time.Time { return now }
+
+	if _, ok := d.mean("worker"); ok {
+		t.Fatalf("expected no mean without completed updates")
+	}
+
+	d.finish("worker", "node-0")
+	if _, ok := d.mean("worker"); ok {
+		t.Fatalf("expected updates without an observed start to be ignored")
+	}
+
+	d.start("node-0")
+	d.start("node-1")
+	now = now.Add(2 * time.Minute)
+	d.finish("worker", "node-0")
+	now = now.Add(2 * time.Minute)
+	d.finish("worker", "node-1")
+	if got, want := mustMean(t, d, "worker"), 3*time.Minute; got != want {
+		t.Fatalf("mismatch mean: got %v want: %v", got, want)
+	}
+
+	for i := 0; i < maxRecentUpdateDurations; i++ {
+		d.start("node-2")
+		now = now.Add(time.Minute)
+		d.finish("worker", "node-2")
+	}
+	if got, want := mustMean(t, d, "worker"), time.Minute; got != want {
+		t.Fatalf("expected only the latest durations to be kept: got %v want: %v", got, want)
+	}
+	if _, ok := d.mean("infra"); ok {
+		t.Fatalf("expected durations to be tracked per pool")
+	}
+}
+
+func mustMean(t *testing.T, d *updateDurations, pool string) time.Duration {
+	mean, ok := d.mean(pool)
+	if !ok {
+		t.Fatalf("expected a mean for pool %s", pool)
+	}
+	return mean
+}
+
+func TestEstimateTimeRemaining(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v0", "v1"),
+		newNode("node-2", "v0", "v0"),
+		newNode("node-3", "v0", "v0"),
+		newNode("node-4", "v0", "v0"),
+	}
+	tests := []struct {
+		name       string
+		maxUnavail intstr.IntOrString
+		paused     bool
+		durations  []time.Duration
+		expected   *metav1.Duration
+	}{{
+		name:       "no completed updates",
+		maxUnavail: intstr.FromInt(1),
+		expected:   nil,
+	}, {
+		name:       "one at a time",
+		maxUnavail: intstr.FromInt(1),
+		durations:  []time.Duration{time.Minute, 3 * time.Minute},
+		expected:   &metav1.Duration{Duration: 8 * time.Minute},
+	}, {
+		name:       "batches are rounded up",
+		maxUnavail: intstr.FromInt(3),
+		durations:  []time.Duration{2 * time.Minute},
+		expected:   &metav1.Duration{Duration: 4 * time.Minute},
+	}, {
+		name:       "paused",
+		maxUnavail: intstr.FromInt(1),
+		paused:     true,
+		durations:  []time.Duration{2 * time.Minute},
+		expected:   nil,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, &test.maxUnavail, "v1")
+			pool.Spec.Paused = test.paused
+			d := newUpdateDurations()
+			d.recent["worker"] = test.durations
+
+			got := d.estimateTimeRemaining(pool, nodes, calculateStatus(pool, nodes, checkNodeReady))
+			if (got == nil) != (test.expected == nil) || (got != nil && *got != *test.expected) {
+				t.Fatalf("mismatch estimate: got %v want: %v", got, test.expected)
+			}
+		})
+	}
+
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	done := []*corev1.Node{newNode("node-0", "v1", "v1")}
+	d := newUpdateDurations()
+	d.recent["worker"] = []time.Duration{time.Minute}
+	if got := d.estimateTimeRemaining(pool, done, calculateStatus(pool, done, checkNodeReady)); got != nil {
+		t.Fatalf("expected no estimate for an updated pool, got %v", got)
+	}
+}
+
+func TestUpdateNodeTracksUpdateDurations(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	f.mcpLister = append(f.mcpLister, mcp)
+	c := f.newController()
+	now := time.Now()
+	c.updateDurations.now = func() time.Time { return now }
+
+	labels := map[string]string{"node-role/worker": ""}
+	idle := newNodeWithLabel("node-0", "v0", "v0", labels)
+	updating := newNodeWithLabel("node-0", "v0", "v1", labels)
+	updated := newNodeWithLabel("node-0", "v1", "v1", labels)
+	c.updateNode(idle, updating)
+	now = now.Add(5 * time.Minute)
+	c.updateNode(updating, updated)
+
+	if got, want := mustMean(t, c.updateDurations, "worker"), 5*time.Minute; got != want {
+		t.Fatalf("mismatch mean: got %v want: %v", got, want)
+	}
+}
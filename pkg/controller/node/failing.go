@@ -0,0 +1,91 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// allFailingBackoffBase is how long a pool whose nodes all fail to apply its target
+	// config is first left alone.
+	allFailingBackoffBase = time.Minute
+	// allFailingBackoffMax caps the doubling of that wait.
+	allFailingBackoffMax = 30 * time.Minute
+)
+
+// failingBackoff tracks the exponential backoff of pools whose nodes all fail to apply
+// their target config.
+type failingBackoff struct {
+	lock    sync.Mutex
+	base    time.Duration
+	max     time.Duration
+	entries map[string]*failingBackoffEntry
+}
+
+type failingBackoffEntry struct {
+	steps int
+	until time.Time
+}
+
+func newFailingBackoff(base, max time.Duration) *failingBackoff {
+	return &failingBackoff{base: base, max: max, entries: map[string]*failingBackoffEntry{}}
+}
+
+// wait returns how long pool is left alone from now on. Once the previous wait is over,
+// a new one twice as long starts, and stepped is set.
+func (b *failingBackoff) wait(pool string, now time.Time) (delay time.Duration, stepped bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entry, ok := b.entries[pool]
+	if !ok {
+		entry = &failingBackoffEntry{}
+		b.entries[pool] = entry
+	}
+	if now.Before(entry.until) {
+		return entry.until.Sub(now), false
+	}
+	delay = b.base
+	for i := 0; i < entry.steps && delay < b.max; i++ {
+		delay *= 2
+	}
+	if delay > b.max {
+		delay = b.max
+	}
+	entry.steps++
+	entry.until = now.Add(delay)
+	return delay, true
+}
+
+// forget ends the backoff of pool, e.g. because one of its nodes recovered, and returns
+// whether it was backing off.
+func (b *failingBackoff) forget(pool string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, ok := b.entries[pool]
+	delete(b.entries, pool)
+	return ok
+}
+
+// countFailingThisConfig returns the number of nodes failing to apply the target config
+// of pool.
+func countFailingThisConfig(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) int {
+	failing := 0
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == pool.Spec.Configuration.Name && isNodeMCDFailing(node) {
+			failing++
+		}
+	}
+	return failing
+}
+
+// allNodesFailing returns whether every node of pool fails to apply its target config,
+// in which case no node can make progress until one of them recovers.
+func allNodesFailing(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) bool {
+	return len(nodes) > 0 && countFailingThisConfig(pool, nodes) == len(nodes)
+}
@@ -0,0 +1,106 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailingBackoff(t *testing.T) {
+	now := time.Now()
+	b := newFailingBackoff(time.Minute, 3*time.Minute)
+
+	for _, step := range []struct {
+		after   time.Duration
+		delay   time.Duration
+		stepped bool
+	}{
+		{0, time.Minute, true},
+		{30 * time.Second, 30 * time.Second, false},
+		{30 * time.Second, 2 * time.Minute, true},
+		{2 * time.Minute, 3 * time.Minute, true},
+		{3 * time.Minute, 3 * time.Minute, true},
+	} {
+		now = now.Add(step.after)
+		delay, stepped := b.wait("worker", now)
+		if delay != step.delay || stepped != step.stepped {
+			t.Fatalf("after %v: got a wait of %v (stepped %t), want %v (stepped %t)", step.after, delay, stepped, step.delay, step.stepped)
+		}
+	}
+	if !b.forget("worker") {
+		t.Fatalf("expected the pool to have been backing off")
+	}
+	if delay, _ := b.wait("worker", now); delay != time.Minute {
+		t.Fatalf("expected the backoff to start over after forget, got %v", delay)
+	}
+}
+
+func TestAllNodesFailingBackoff(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v1", labels),
+		newNodeWithLabel("node-1", "v0", "v1", labels),
+	}
+	for _, node := range nodes {
+		node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-recorder.Events:
+		if want := "Warning AllNodesFailing"; !strings.HasPrefix(got, want) {
+			t.Fatalf("mismatch event: got %q want prefix: %q", got, want)
+		}
+	default:
+		t.Fatalf("expected an event about the backoff")
+	}
+	pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get("worker", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolDegraded)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != "AllNodesFailing" {
+		t.Fatalf("expected the pool to be Degraded because all nodes are failing, got %v", cond)
+	}
+
+	// Syncing again within the backoff doesn't step it.
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-recorder.Events:
+		t.Fatalf("expected no event within the backoff, got %q", got)
+	default:
+	}
+
+	// Once a node recovers the backoff ends.
+	recovered := newNodeWithLabel("node-1", "v1", "v1", labels)
+	f.kubeinformers.Core().V1().Nodes().Informer().GetIndexer().Update(recovered)
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if c.failingBackoffs.forget("worker") {
+		t.Fatalf("expected the backoff to have ended once a node recovered")
+	}
+}
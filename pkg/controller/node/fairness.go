@@ -0,0 +1,75 @@
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+// poolFairness tracks how much worker time each pool consumed in the current
+// window so that a pool syncing over and over can be deferred while other pools
+// are waiting for a worker.
+type poolFairness struct {
+	lock   sync.Mutex
+	window time.Duration
+	budget time.Duration
+	usage  map[string]*poolUsage
+	now    func() time.Time
+}
+
+type poolUsage struct {
+	windowStart time.Time
+	spent       time.Duration
+}
+
+func newPoolFairness(window, budget time.Duration) *poolFairness {
+	return &poolFairness{
+		window: window,
+		budget: budget,
+		usage:  map[string]*poolUsage{},
+		now:    time.Now,
+	}
+}
+
+// WithPoolFairness limits every pool to budget worker time per window while other
+// pools are queued; a pool over budget is requeued for the rest of its window.
+// Each pool is still synced by at most one worker at a time.
+func WithPoolFairness(window, budget time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.fairness = newPoolFairness(window, budget)
+	}
+}
+
+// record adds the time spent syncing key to its usage in the current window.
+func (f *poolFairness) record(key string, spent time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := f.now()
+	u, ok := f.usage[key]
+	if !ok || now.Sub(u.windowStart) >= f.window {
+		u = &poolUsage{windowStart: now}
+		f.usage[key] = u
+	}
+	u.spent += spent
+}
+
+// deferral returns how long key should wait before its next sync, which is the
+// rest of its window once it spent its budget, and zero otherwise.
+func (f *poolFairness) deferral(key string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	u, ok := f.usage[key]
+	if !ok {
+		return 0
+	}
+	elapsed := f.now().Sub(u.windowStart)
+	if elapsed >= f.window {
+		delete(f.usage, key)
+		return 0
+	}
+	if u.spent < f.budget {
+		return 0
+	}
+	return f.window - elapsed
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolFairness(t *testing.T) {
+	now := time.Now()
+	f := newPoolFairness(time.Minute, 10*time.Second)
+	f.now = func() time.Time { return now }
+
+	if got := f.deferral("worker"); got != 0 {
+		t.Fatalf("expected no deferral for an unseen pool, got %v", got)
+	}
+
+	f.record("worker", 6*time.Second)
+	if got := f.deferral("worker"); got != 0 {
+		t.Fatalf("expected no deferral under budget, got %v", got)
+	}
+
+	now = now.Add(20 * time.Second)
+	f.record("worker", 6*time.Second)
+	if got, want := f.deferral("worker"), 40*time.Second; got != want {
+		t.Fatalf("mismatch deferral over budget: got %v want: %v", got, want)
+	}
+	if got := f.deferral("infra"); got != 0 {
+		t.Fatalf("expected other pools not to be deferred, got %v", got)
+	}
+
+	now = now.Add(40 * time.Second)
+	if got := f.deferral("worker"); got != 0 {
+		t.Fatalf("expected the budget to reset with a new window, got %v", got)
+	}
+}
+
+func TestProcessNextWorkItemFairness(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	WithPoolFairness(time.Minute, time.Second)(c)
+	c.fairness.record("worker", 2*time.Second)
+
+	var synced []string
+	c.syncHandler = func(key string) error {
+		synced = append(synced, key)
+		return nil
+	}
+
+	c.queue.Add("worker")
+	c.queue.Add("infra")
+	c.processNextWorkItem()
+	c.processNextWorkItem()
+	if len(synced) != 1 || synced[0] != "infra" {
+		t.Fatalf("expected only infra to be synced while worker is over budget, got %v", synced)
+	}
+
+	// With nothing else waiting, a pool over budget is not held back.
+	c.queue.Add("worker")
+	c.processNextWorkItem()
+	if len(synced) != 2 || synced[1] != "worker" {
+		t.Fatalf("expected worker to be synced once the queue is otherwise empty, got %v", synced)
+	}
+}
@@ -0,0 +1,25 @@
+package node
+
+// RolloutGate is consulted before the node controller moves any more nodes of a
+// pool to its target config. It lets e.g. firing cluster alerts hold back all
+// rollouts without changing the pools themselves.
+type RolloutGate interface {
+	// Allowed returns whether new node updates may be started, and if not, a
+	// human readable reason why.
+	Allowed() (allowed bool, reason string, err error)
+}
+
+// alwaysAllowed is the default RolloutGate that never holds back a rollout.
+type alwaysAllowed struct{}
+
+func (alwaysAllowed) Allowed() (bool, string, error) {
+	return true, "", nil
+}
+
+// WithRolloutGate holds back starting node updates while gate disallows them.
+// Nodes that are already updating are left to finish.
+func WithRolloutGate(gate RolloutGate) Option {
+	return func(ctrl *Controller) {
+		ctrl.rolloutGate = gate
+	}
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"sync"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// globalBudget caps the number of nodes updating at the same time across all pools.
+type globalBudget struct {
+	lock sync.Mutex
+	max  int
+	// started maps the nodes given a new desired config to that config until the
+	// node lister catches up with it, so concurrent syncs don't overrun the budget.
+	started map[string]string
+	// demand maps pools to the candidates their last sync is waiting to start.
+	demand map[string]poolDemand
+}
+
+func newGlobalBudget(max int) *globalBudget {
+	return &globalBudget{
+		max:     max,
+		started: map[string]string{},
+		demand:  map[string]poolDemand{},
+	}
+}
+
+// WithGlobalMaxUnavailable caps the number of nodes updating at once across all
+// pools to max, on top of the maxUnavailable of every pool. Zero, the default,
+// means no global cap.
+func WithGlobalMaxUnavailable(max int) Option {
+	return func(ctrl *Controller) {
+		if max > 0 {
+			ctrl.globalBudget = newGlobalBudget(max)
+		}
+	}
+}
+
+// reserve returns as many of candidates as fit in the budget given all nodes of the
+// cluster, leaving ahead slots to pools of a higher priority, and counts them as updating
+// to their target config from now on.
+func (b *globalBudget) reserve(allNodes, candidates []*corev1.Node, ahead int, target func(*corev1.Node) string) []*corev1.Node {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	inflight := 0
+	for _, node := range allNodes {
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		if started, ok := b.started[node.Name]; ok {
+			if desired != started {
+				inflight++
+				continue
+			}
+			delete(b.started, node.Name)
+		}
+		if desired != node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+			inflight++
+		}
+	}
+
+	available := b.max - inflight - ahead
+	if available <= 0 {
+		return nil
+	}
+	if len(candidates) > available {
+		candidates = candidates[:available]
+	}
+	for _, node := range candidates {
+		b.started[node.Name] = target(node)
+	}
+	return candidates
+}
+
+// release forgets the reservation of a node whose update couldn't be started.
+func (b *globalBudget) release(node string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.started, node)
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestGlobalBudgetReserve(t *testing.T) {
+	b := newGlobalBudget(2)
+	toV1 := func(*corev1.Node) string { return "v1" }
+	allNodes := []*corev1.Node{
+		newNode("node-0", "v0", "v1"),
+		newNode("node-1", "v0", "v0"),
+		newNode("node-2", "v0", "v0"),
+	}
+
+	got := b.reserve(allNodes, allNodes[1:], 0, toV1)
+	if len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected only node-1 to fit next to the updating node-0, got %v", got)
+	}
+	if got := b.reserve(allNodes, allNodes[2:], 0, toV1); len(got) != 0 {
+		t.Fatalf("expected the reservation of node-1 to count before the lister catches up, got %v", got)
+	}
+
+	// node-0 finished and the lister caught up with node-1.
+	allNodes[0] = newNode("node-0", "v1", "v1")
+	allNodes[1] = newNode("node-1", "v0", "v1")
+	if got := b.reserve(allNodes, allNodes[2:], 0, toV1); len(got) != 1 {
+		t.Fatalf("expected node-2 to fit once node-0 finished, got %v", got)
+	}
+
+	b.release("node-2")
+	if got := b.reserve(allNodes, allNodes[2:], 0, toV1); len(got) != 1 {
+		t.Fatalf("expected a released reservation to free its slot, got %v", got)
+	}
+}
+
+func TestGlobalMaxUnavailableAcrossPools(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithGlobalMaxUnavailable(1))
+	for _, role := range []string{"worker", "infra"} {
+		mcp := newMachineConfigPool(role, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/"+role, ""), intStrPtr(intstr.FromInt(1)), "v1")
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.objects = append(f.objects, mcp)
+		f.mcLister = append(f.mcLister, newMachineConfig("v1"))
+		node := newNodeWithLabel(role+"-0", "v0", "v0", map[string]string{"node-role/" + role: ""})
+		f.nodeLister = append(f.nodeLister, node)
+		f.kubeobjects = append(f.kubeobjects, node)
+	}
+	c := f.newController()
+
+	for _, key := range []string{"worker", "infra"} {
+		if err := c.syncHandler(key); err != nil {
+			t.Fatalf("error syncing %s: %v", key, err)
+		}
+	}
+
+	var patched []string
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok {
+			patched = append(patched, patch.GetName())
+		}
+	}
+	if len(patched) != 1 || patched[0] != "worker-0" {
+		t.Fatalf("expected only the first pool synced to start an update, got %v", patched)
+	}
+}
@@ -0,0 +1,124 @@
+package node
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// healthStatus is the body returned by the node controller's /healthz endpoint.
+type healthStatus struct {
+	QueueDepth                     int       `json:"queueDepth"`
+	DegradedPools                  int       `json:"degradedPools"`
+	LastSuccessfulSync             time.Time `json:"lastSuccessfulSync"`
+	SecondsSinceLastSuccessfulSync float64   `json:"secondsSinceLastSuccessfulSync"`
+}
+
+// WithHealthServer serves /healthz and /debug/vars on addr while the controller runs.
+// /healthz reports the workqueue depth, the number of degraded pools and the time since
+// the last successful pool sync, and fails once that sync is older than staleAfter.
+// Pools are resynced periodically, so staleAfter should exceed the informer resync period.
+// The endpoints are unauthenticated.
+func WithHealthServer(addr string, staleAfter time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.healthAddr = addr
+		ctrl.healthStaleAfter = staleAfter
+	}
+}
+
+// recordSuccessfulSync notes the time of the latest successful pool sync.
+func (ctrl *Controller) recordSuccessfulSync(t time.Time) {
+	atomic.StoreInt64(&ctrl.lastSuccessfulSync, t.UnixNano())
+}
+
+func (ctrl *Controller) getHealthStatus(now time.Time) (healthStatus, error) {
+	last := time.Unix(0, atomic.LoadInt64(&ctrl.lastSuccessfulSync))
+	status := healthStatus{
+		QueueDepth:                     ctrl.queue.Len(),
+		LastSuccessfulSync:             last,
+		SecondsSinceLastSuccessfulSync: now.Sub(last).Seconds(),
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return status, err
+	}
+	for _, pool := range pools {
+		if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolDegraded) {
+			status.DegradedPools++
+		}
+	}
+	return status, nil
+}
+
+// healthMux returns the handler of the health endpoints, including /resync if enabled.
+func (ctrl *Controller) healthMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", &healthHandler{ctrl: ctrl})
+	mux.Handle("/debug/vars", expvar.Handler())
+	if ctrl.resyncEndpoint {
+		mux.Handle("/resync", &resyncHandler{ctrl: ctrl})
+	}
+	return mux
+}
+
+// runHealthServer serves the health endpoints until stopCh is closed.
+func (ctrl *Controller) runHealthServer(stopCh <-chan struct{}) {
+	srv := &http.Server{
+		Addr:    ctrl.healthAddr,
+		Handler: ctrl.healthMux(),
+	}
+	go func() {
+		<-stopCh
+		srv.Close()
+	}()
+
+	glog.Infof("Serving node controller health on %s", ctrl.healthAddr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		glog.Errorf("Node controller health server exited with error: %v", err)
+	}
+}
+
+type healthHandler struct {
+	ctrl *Controller
+}
+
+// ServeHTTP handles /healthz requests.
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	status, err := h.ctrl.getHealthStatus(time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	code := http.StatusOK
+	if h.ctrl.healthStaleAfter > 0 && status.SecondsSinceLastSuccessfulSync > h.ctrl.healthStaleAfter.Seconds() {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		glog.Errorf("failed to write health response: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		lastSync time.Duration
+		code     int
+	}{{
+		lastSync: time.Minute,
+		code:     http.StatusOK,
+	}, {
+		lastSync: 2 * time.Hour,
+		code:     http.StatusServiceUnavailable,
+	}}
+
+	for _, test := range tests {
+		f := newFixture(t)
+		degraded := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "worker"), nil, "v1")
+		mcfgv1.SetMachineConfigPoolCondition(&degraded.Status, *mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "", ""))
+		healthy := newMachineConfigPool("master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), nil, "v1")
+		f.mcpLister = append(f.mcpLister, degraded, healthy)
+
+		c := f.newController()
+		WithHealthServer(":0", time.Hour)(c)
+		c.recordSuccessfulSync(time.Now().Add(-test.lastSync))
+		c.queue.Add("worker")
+
+		rec := httptest.NewRecorder()
+		(&healthHandler{ctrl: c}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != test.code {
+			t.Fatalf("mismatch status code: got %d want: %d", rec.Code, test.code)
+		}
+
+		var status healthStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.QueueDepth != 1 {
+			t.Fatalf("mismatch queue depth: got %d want: 1", status.QueueDepth)
+		}
+		if status.DegradedPools != 1 {
+			t.Fatalf("mismatch degraded pools: got %d want: 1", status.DegradedPools)
+		}
+		if status.SecondsSinceLastSuccessfulSync < test.lastSync.Seconds() {
+			t.Fatalf("expected at least %v since the last sync, got %vs", test.lastSync, status.SecondsSinceLastSuccessfulSync)
+		}
+	}
+}
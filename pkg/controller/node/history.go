@@ -0,0 +1,45 @@
+package node
+
+import (
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rolloutHistoryLimit is how many rollouts the status of a pool keeps.
+const rolloutHistoryLimit = 10
+
+// updateRolloutHistory records a rollout on newStatus when the target config of pool
+// changes, tracks its failed nodes, and marks it completed at now once all nodes are
+// updated. The rollout starts at the last config change time of newStatus, so it is
+// only recorded once that is known.
+func updateRolloutHistory(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, now time.Time) {
+	history := append([]mcfgv1.MachineConfigPoolRollout(nil), pool.Status.RolloutHistory...)
+	target := pool.Spec.Configuration.Name
+	if len(history) == 0 || history[len(history)-1].Config != target {
+		if newStatus.Configuration.Name == target || newStatus.LastConfigChangeTime == nil {
+			newStatus.RolloutHistory = pool.Status.RolloutHistory
+			return
+		}
+		history = append(history, mcfgv1.MachineConfigPoolRollout{
+			Config:    target,
+			StartTime: *newStatus.LastConfigChangeTime,
+		})
+		if len(history) > rolloutHistoryLimit {
+			history = history[len(history)-rolloutHistoryLimit:]
+		}
+	}
+
+	last := &history[len(history)-1]
+	if last.CompletionTime == nil {
+		if newStatus.DegradedMachineCount > last.FailedMachineCount {
+			last.FailedMachineCount = newStatus.DegradedMachineCount
+		}
+		if newStatus.Configuration.Name == target {
+			completed := metav1.NewTime(now)
+			last.CompletionTime = &completed
+		}
+	}
+	newStatus.RolloutHistory = history
+}
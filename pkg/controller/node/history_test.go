@@ -0,0 +1,71 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateRolloutHistory(t *testing.T) {
+	now := time.Now()
+	changed := metav1.NewTime(now.Add(-time.Hour))
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Status.Configuration.Name = "v0"
+	pool.Status.LastConfigChangeTime = &changed
+
+	// The target config changed, a rollout starts.
+	status := pool.Status
+	status.DegradedMachineCount = 2
+	updateRolloutHistory(pool, &status, now)
+	if len(status.RolloutHistory) != 1 {
+		t.Fatalf("expected a rollout to be recorded, got %v", status.RolloutHistory)
+	}
+	rollout := status.RolloutHistory[0]
+	if rollout.Config != "v1" || !rollout.StartTime.Equal(&changed) || rollout.CompletionTime != nil || rollout.FailedMachineCount != 2 {
+		t.Fatalf("mismatch rollout: got %+v", rollout)
+	}
+	pool.Status = status
+
+	// The failed nodes recover and the rollout completes.
+	status = pool.Status
+	status.DegradedMachineCount = 0
+	status.Configuration.Name = "v1"
+	updateRolloutHistory(pool, &status, now)
+	if len(status.RolloutHistory) != 1 {
+		t.Fatalf("expected the rollout to be updated in place, got %v", status.RolloutHistory)
+	}
+	rollout = status.RolloutHistory[0]
+	if rollout.CompletionTime == nil || !rollout.CompletionTime.Time.Equal(now) || rollout.FailedMachineCount != 2 {
+		t.Fatalf("expected the rollout to complete and keep its failed nodes, got %+v", rollout)
+	}
+	if pool.Status.RolloutHistory[0].CompletionTime != nil {
+		t.Fatalf("expected the status of the pool not to be modified")
+	}
+}
+
+func TestUpdateRolloutHistoryTruncation(t *testing.T) {
+	changed := metav1.Now()
+	pool := newMachineConfigPool("worker", nil, nil, "v0")
+	for i := 1; i <= rolloutHistoryLimit+2; i++ {
+		pool.Spec.Configuration.Name = fmt.Sprintf("v%d", i)
+		status := pool.Status
+		status.LastConfigChangeTime = &changed
+		updateRolloutHistory(pool, &status, changed.Time)
+		pool.Status = status
+	}
+
+	history := pool.Status.RolloutHistory
+	if len(history) != rolloutHistoryLimit {
+		t.Fatalf("expected %d rollouts, got %d", rolloutHistoryLimit, len(history))
+	}
+	if first, last := history[0].Config, history[len(history)-1].Config; first != "v3" || last != fmt.Sprintf("v%d", rolloutHistoryLimit+2) {
+		t.Fatalf("expected the oldest rollouts to be dropped, got %s to %s", first, last)
+	}
+	for _, rollout := range history {
+		if rollout.CompletionTime != nil {
+			t.Fatalf("expected superseded rollouts not to be completed, got %+v", rollout)
+		}
+	}
+}
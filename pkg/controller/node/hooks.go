@@ -0,0 +1,72 @@
+package node
+
+import (
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isPreUpdateHookPending returns whether the pre-update hook of node was requested for
+// a config the node isn't set to yet.
+func isPreUpdateHookPending(node *corev1.Node) bool {
+	hook := node.Annotations[preUpdateHookAnnotationKey]
+	return hook != "" && hook != node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+}
+
+// isPostUpdateHookPending returns whether node finished updating through the hooks of
+// pool, but its post-update hook didn't run yet. Such a node isn't updated yet and
+// counts as unavailable.
+func isPostUpdateHookPending(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	if !pool.Spec.UpdateHooks || !isNodeDone(node) {
+		return false
+	}
+	current := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	return node.Annotations[preUpdateHookAnnotationKey] == current && node.Annotations[postUpdateDoneAnnotationKey] != current
+}
+
+// isPostUpdateHookUnavailable returns whether node counts as unavailable only because its
+// post-update hook is pending.
+func isPostUpdateHookUnavailable(pool *mcfgv1.MachineConfigPool, node *corev1.Node, checkReady NodeReadyChecker) bool {
+	return isPostUpdateHookPending(pool, node) && !IsNodeUnavailable(node, pool.Spec.UnavailabilityPolicy, checkReady)
+}
+
+// withoutPendingPostUpdateHooks returns the nodes whose post-update hook isn't pending.
+func withoutPendingPostUpdateHooks(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []*corev1.Node {
+	if !pool.Spec.UpdateHooks {
+		return nodes
+	}
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		if !isPostUpdateHookPending(pool, node) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// runPreUpdateHooks requests the pre-update hook of the candidates of a pool with update
+// hooks, and returns the candidates whose hook ran and that may be updated now. The pool
+// is synced again once the hook of another candidate reports done.
+func (ctrl *Controller) runPreUpdateHooks(pool *mcfgv1.MachineConfigPool, candidates []*corev1.Node) ([]*corev1.Node, error) {
+	if !pool.Spec.UpdateHooks {
+		return candidates, nil
+	}
+	var ready []*corev1.Node
+	for _, node := range candidates {
+		config := nextConfig(pool, node)
+		if node.Annotations[preUpdateHookAnnotationKey] == config && node.Annotations[preUpdateDoneAnnotationKey] == config {
+			ready = append(ready, node)
+			continue
+		}
+		if node.Annotations[preUpdateHookAnnotationKey] == config {
+			glog.V(2).Infof("Pool %s: waiting for the pre-update hook of node %s for %s", pool.Name, node.Name, config)
+			continue
+		}
+		glog.Infof("Pool %s: requesting the pre-update hook of node %s for %s", pool.Name, node.Name, config)
+		if err := ctrl.setNodeAnnotation(node.Name, preUpdateHookAnnotationKey, config); err != nil {
+			return nil, err
+		}
+	}
+	return ready, nil
+}
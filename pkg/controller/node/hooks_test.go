@@ -0,0 +1,120 @@
+package node
+
+import (
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestPreUpdateHook(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		hook        string
+		desired     string
+	}{{
+		name:    "requested",
+		hook:    "v1",
+		desired: "v0",
+	}, {
+		name:        "waiting",
+		annotations: map[string]string{preUpdateHookAnnotationKey: "v1"},
+		hook:        "v1",
+		desired:     "v0",
+	}, {
+		name:        "done",
+		annotations: map[string]string{preUpdateHookAnnotationKey: "v1", preUpdateDoneAnnotationKey: "v1"},
+		hook:        "v1",
+		desired:     "v1",
+	}, {
+		// The hook ran for an earlier update, it is requested again.
+		name:        "stale",
+		annotations: map[string]string{preUpdateHookAnnotationKey: "v0", preUpdateDoneAnnotationKey: "v0", postUpdateDoneAnnotationKey: "v0"},
+		hook:        "v1",
+		desired:     "v0",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+			mcp.Spec.UpdateHooks = true
+			labels := map[string]string{"node-role/worker": ""}
+			node := newNodeWithLabel("node-0", "v0", "v0", labels)
+			for key, value := range test.annotations {
+				node.Annotations[key] = value
+			}
+			nodes := []*corev1.Node{node, newNodeWithLabel("node-1", "v1", "v1", labels)}
+			f.mcpLister = append(f.mcpLister, mcp)
+			f.objects = append(f.objects, mcp)
+			f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+			f.nodeLister = append(f.nodeLister, nodes...)
+			for idx := range nodes {
+				f.kubeobjects = append(f.kubeobjects, nodes[idx])
+			}
+			c := f.newController()
+
+			if err := c.syncHandler(getKey(mcp, t)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hook := got.Annotations[preUpdateHookAnnotationKey]; hook != test.hook {
+				t.Fatalf("mismatch pre-update hook: got %q want: %q", hook, test.hook)
+			}
+			if desired := got.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != test.desired {
+				t.Fatalf("mismatch desired config: got %q want: %q", desired, test.desired)
+			}
+		})
+	}
+}
+
+func TestPostUpdateHook(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	pool.Spec.UpdateHooks = true
+	updated := withAnnotation(newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue), preUpdateHookAnnotationKey, "v1")
+	nodes := []*corev1.Node{
+		updated,
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	// Until its post-update hook ran, the node isn't updated and holds back the next one.
+	status := calculateStatus(pool, nodes, checkNodeReady)
+	if status.UpdatedMachineCount != 0 || status.UnavailableMachineCount != 1 {
+		t.Fatalf("expected the node to wait for its post-update hook, got %d updated and %d unavailable", status.UpdatedMachineCount, status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil); len(got) != 0 {
+		t.Fatalf("expected no candidates while the post-update hook is pending, got %v", got)
+	}
+
+	updated.Annotations[postUpdateDoneAnnotationKey] = "v1"
+	status = calculateStatus(pool, nodes, checkNodeReady)
+	if status.UpdatedMachineCount != 1 || status.UnavailableMachineCount != 0 {
+		t.Fatalf("expected the node to be updated once its post-update hook ran, got %d updated and %d unavailable", status.UpdatedMachineCount, status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil); len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected node-1 to be the next candidate, got %v", got)
+	}
+}
+
+func TestPostUpdateHookNotReady(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+	pool.Spec.UpdateHooks = true
+	nodes := []*corev1.Node{
+		withAnnotation(newNodeWithReady("node-0", "v1", "v1", corev1.ConditionFalse), preUpdateHookAnnotationKey, "v1"),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	// A node that isn't ready while its post-update hook is pending only counts once.
+	status := calculateStatus(pool, nodes, checkNodeReady)
+	if status.UnavailableMachineCount != 1 {
+		t.Fatalf("expected 1 unavailable node, got %d", status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil); len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected node-1 to be the next candidate, got %v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"sort"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// PoolsAffectedBy returns the pools among pools that roll out a change to the MachineConfig
+// named config: the pools targeting it directly, and the pools whose target config is
+// rendered from it according to the sources recorded in their spec. The pools are
+// returned sorted by name.
+func PoolsAffectedBy(config string, pools []*mcfgv1.MachineConfigPool) []*mcfgv1.MachineConfigPool {
+	var affected []*mcfgv1.MachineConfigPool
+	for _, pool := range pools {
+		if isPoolAffectedBy(config, pool) {
+			affected = append(affected, pool)
+		}
+	}
+	sortPoolsByName(affected)
+	return affected
+}
+
+// isPoolAffectedBy returns whether the target config of pool is config, or is rendered
+// from it.
+func isPoolAffectedBy(config string, pool *mcfgv1.MachineConfigPool) bool {
+	if pool.Spec.Configuration.Name == config {
+		return true
+	}
+	for _, source := range pool.Spec.Configuration.Source {
+		if source.Name == config {
+			return true
+		}
+	}
+	return false
+}
+
+// AffectedPools returns the pools that roll out a change to the MachineConfig named config,
+// like PoolsAffectedBy, from the listers of the controller. Pools that don't record the
+// sources of their target config yet are matched like the render controller matches them,
+// by their MachineConfigSelector against the labels of config.
+func (ctrl *Controller) AffectedPools(config string) ([]*mcfgv1.MachineConfigPool, error) {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	mc, err := ctrl.mcLister.Get(config)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	var affected []*mcfgv1.MachineConfigPool
+	for _, pool := range pools {
+		if isPoolAffectedBy(config, pool) {
+			affected = append(affected, pool)
+			continue
+		}
+		if mc == nil || len(pool.Spec.Configuration.Source) > 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(mc.Labels)) {
+			affected = append(affected, pool)
+		}
+	}
+	sortPoolsByName(affected)
+	return affected, nil
+}
+
+func sortPoolsByName(pools []*mcfgv1.MachineConfigPool) {
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withSources(pool *mcfgv1.MachineConfigPool, sources ...string) *mcfgv1.MachineConfigPool {
+	for _, source := range sources {
+		pool.Spec.Configuration.Source = append(pool.Spec.Configuration.Source, corev1.ObjectReference{Name: source})
+	}
+	return pool
+}
+
+func poolNames(pools []*mcfgv1.MachineConfigPool) []string {
+	var names []string
+	for _, pool := range pools {
+		names = append(names, pool.Name)
+	}
+	return names
+}
+
+func TestPoolsAffectedBy(t *testing.T) {
+	pools := []*mcfgv1.MachineConfigPool{
+		withSources(newMachineConfigPool("worker", nil, nil, "rendered-worker-1"), "00-worker", "99-ssh"),
+		withSources(newMachineConfigPool("master", nil, nil, "rendered-master-1"), "00-master", "99-ssh"),
+		withSources(newMachineConfigPool("infra", nil, nil, "rendered-infra-1"), "00-worker", "50-infra"),
+	}
+	tests := []struct {
+		config   string
+		expected []string
+	}{
+		{"99-ssh", []string{"master", "worker"}},
+		{"00-worker", []string{"infra", "worker"}},
+		{"50-infra", []string{"infra"}},
+		{"rendered-master-1", []string{"master"}},
+		{"99-unused", nil},
+	}
+	for _, test := range tests {
+		if got := poolNames(PoolsAffectedBy(test.config, pools)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected pools %v, got %v", test.config, test.expected, got)
+		}
+	}
+}
+
+func TestAffectedPoolsFromListers(t *testing.T) {
+	f := newFixture(t)
+	worker := withSources(newMachineConfigPool("worker", nil, nil, "rendered-worker-1"), "00-worker", "99-ssh")
+	// A pool that wasn't rendered yet is matched by its MachineConfigSelector.
+	infra := newMachineConfigPool("infra", nil, nil, "")
+	infra.Spec.MachineConfigSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "machineconfiguration.openshift.io/role", "infra")
+	master := withSources(newMachineConfigPool("master", nil, nil, "rendered-master-1"), "00-master")
+	master.Spec.MachineConfigSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "machineconfiguration.openshift.io/role", "infra")
+	f.mcpLister = append(f.mcpLister, worker, infra, master)
+	ssh := newMachineConfig("99-ssh")
+	ssh.Labels = map[string]string{"machineconfiguration.openshift.io/role": "infra"}
+	f.mcLister = append(f.mcLister, ssh)
+	c := f.newController()
+
+	got, err := c.AffectedPools("99-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The master pool records its sources, which don't include the config, so its
+	// selector isn't consulted.
+	if names := poolNames(got); !reflect.DeepEqual(names, []string{"infra", "worker"}) {
+		t.Fatalf("expected the infra and worker pools, got %v", names)
+	}
+
+	got, err = c.AffectedPools("99-missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no pools for a missing config, got %v", poolNames(got))
+	}
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	batchinformersv1 "k8s.io/client-go/informers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reasons of the JobGated condition.
+const (
+	jobGateRunning    = "JobRunning"
+	jobGateFailed     = "JobFailed"
+	jobGateNotFound   = "JobNotFound"
+	jobGateNotWatched = "JobsNotWatched"
+)
+
+// WithJobInformer lets pools hold back their rollout until the Job named by their
+// spec.blockingJob completed. Changes to a Job resync the pools it blocks. Without it,
+// pools with a blocking Job hold back their rollout.
+func WithJobInformer(jobInformer batchinformersv1.JobInformer) Option {
+	return func(ctrl *Controller) {
+		if jobInformer == nil {
+			return
+		}
+		informer := jobInformer.Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    ctrl.enqueueJobBlockedPools,
+			UpdateFunc: func(_, cur interface{}) { ctrl.enqueueJobBlockedPools(cur) },
+			DeleteFunc: ctrl.enqueueJobBlockedPools,
+		})
+		ctrl.jobLister = jobInformer.Lister()
+		ctrl.jobListerSynced = informer.HasSynced
+	}
+}
+
+// enqueueJobBlockedPools enqueues the pools whose blocking Job is obj.
+func (ctrl *Controller) enqueueJobBlockedPools(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		glog.Warningf("Failed to list pools blocked by Job %s/%s: %v", job.Namespace, job.Name, err)
+		return
+	}
+	for _, pool := range pools {
+		if ref := pool.Spec.BlockingJob; ref != nil && ref.Namespace == job.Namespace && ref.Name == job.Name {
+			glog.V(4).Infof("Job %s/%s changed, syncing pool %s", job.Namespace, job.Name, pool.Name)
+			ctrl.enqueueMachineConfigPool(pool)
+		}
+	}
+}
+
+// checkBlockingJob returns the reason and message of the JobGated condition if the
+// blocking Job of pool holds back new node updates, or an empty reason if it doesn't.
+// Only a completed Job lets the rollout proceed.
+func (ctrl *Controller) checkBlockingJob(pool *mcfgv1.MachineConfigPool) (reason, message string, err error) {
+	ref := pool.Spec.BlockingJob
+	if ref == nil {
+		return "", "", nil
+	}
+	if ctrl.jobLister == nil {
+		return jobGateNotWatched, fmt.Sprintf("The node controller doesn't watch Jobs, so Job %s/%s can't be checked", ref.Namespace, ref.Name), nil
+	}
+	job, err := ctrl.jobLister.Jobs(ref.Namespace).Get(ref.Name)
+	if errors.IsNotFound(err) {
+		return jobGateNotFound, fmt.Sprintf("Job %s/%s does not exist", ref.Namespace, ref.Name), nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error getting blocking Job %s/%s: %v", ref.Namespace, ref.Name, err)
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "", "", nil
+		case batchv1.JobFailed:
+			return jobGateFailed, fmt.Sprintf("Job %s/%s failed: %s", ref.Namespace, ref.Name, condition.Message), nil
+		}
+	}
+	return jobGateRunning, fmt.Sprintf("Waiting for Job %s/%s to complete", ref.Namespace, ref.Name), nil
+}
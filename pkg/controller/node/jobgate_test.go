@@ -0,0 +1,127 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func newBackupJob(conditions ...batchv1.JobCondition) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "backups"},
+		Status:     batchv1.JobStatus{Conditions: conditions},
+	}
+}
+
+func TestBlockingJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		job     *batchv1.Job
+		reason  string
+		patches int
+		noWatch bool
+	}{{
+		name:   "running",
+		job:    newBackupJob(),
+		reason: jobGateRunning,
+	}, {
+		name:   "failed",
+		job:    newBackupJob(batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}),
+		reason: jobGateFailed,
+	}, {
+		name:   "not found",
+		reason: jobGateNotFound,
+	}, {
+		name:    "Jobs not watched",
+		job:     newBackupJob(),
+		reason:  jobGateNotWatched,
+		noWatch: true,
+	}, {
+		name:    "complete",
+		job:     newBackupJob(batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+		patches: 1,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+			mcp.Spec.BlockingJob = &mcfgv1.MachineConfigPoolJobReference{Namespace: "backups", Name: "backup"}
+			nodes := []*corev1.Node{
+				newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+				newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+			}
+			f.mcpLister = append(f.mcpLister, mcp)
+			f.objects = append(f.objects, mcp)
+			f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+			f.nodeLister = append(f.nodeLister, nodes...)
+			for idx := range nodes {
+				f.kubeobjects = append(f.kubeobjects, nodes[idx])
+			}
+			if test.noWatch {
+				f.opts = append(f.opts, WithJobInformer(nil))
+			} else {
+				jobs := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), noResyncPeriodFunc()).Batch().V1().Jobs()
+				if test.job != nil {
+					jobs.Informer().GetIndexer().Add(test.job)
+				}
+				f.opts = append(f.opts, WithJobInformer(jobs))
+			}
+			c := f.newController()
+
+			if err := c.syncHandler(getKey(mcp, t)); err != nil {
+				t.Fatal(err)
+			}
+
+			patches := 0
+			for _, action := range f.kubeclient.Actions() {
+				if action.Matches("patch", "nodes") {
+					patches++
+				}
+			}
+			if patches != test.patches {
+				t.Fatalf("expected %d nodes to be patched, got %d", test.patches, patches)
+			}
+			var status *mcfgv1.MachineConfigPoolStatus
+			for _, action := range filterInformerActions(f.client.Actions()) {
+				if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+					status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+				}
+			}
+			if test.reason == "" {
+				if status != nil && mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolJobGated) {
+					t.Fatalf("expected the pool not to be gated, got %v", status.Conditions)
+				}
+				return
+			}
+			if status == nil {
+				t.Fatalf("expected the status of the pool to be updated")
+			}
+			gated := mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolJobGated)
+			if gated == nil || gated.Status != corev1.ConditionTrue || gated.Reason != test.reason {
+				t.Fatalf("expected the pool to be gated with reason %s, got %v", test.reason, gated)
+			}
+		})
+	}
+}
+
+func TestBlockingJobChangeEnqueuesPools(t *testing.T) {
+	f := newFixture(t)
+	blocked := newMachineConfigPool("worker", nil, nil, "v1")
+	blocked.Spec.BlockingJob = &mcfgv1.MachineConfigPoolJobReference{Namespace: "backups", Name: "backup"}
+	other := newMachineConfigPool("infra", nil, nil, "v1")
+	other.Spec.BlockingJob = &mcfgv1.MachineConfigPoolJobReference{Namespace: "other", Name: "backup"}
+	f.mcpLister = append(f.mcpLister, blocked, other)
+	c, enqueued := f.newControllerRecordingEnqueues()
+
+	c.enqueueJobBlockedPools(newBackupJob())
+	if len(*enqueued) != 1 || (*enqueued)[0] != "worker" {
+		t.Fatalf("expected only the pool blocked by the Job to be enqueued, got %v", *enqueued)
+	}
+}
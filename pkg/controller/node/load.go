@@ -0,0 +1,71 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podNodeNameIndex indexes pods by the name of the node they are scheduled to.
+const podNodeNameIndex = "spec.nodeName"
+
+// WithPodInformer lets pools that prefer their least loaded nodes count the pods of their
+// nodes with podInformer. Pods aren't watched if podInformer is nil.
+func WithPodInformer(podInformer coreinformersv1.PodInformer) Option {
+	return func(ctrl *Controller) {
+		if podInformer == nil {
+			return
+		}
+		informer := podInformer.Informer()
+		if err := informer.AddIndexers(cache.Indexers{podNodeNameIndex: indexPodByNodeName}); err != nil {
+			glog.Errorf("Failed to index pods by node, not counting the pods of nodes: %v", err)
+			return
+		}
+		ctrl.podIndexer = informer.GetIndexer()
+		ctrl.podListerSynced = informer.HasSynced
+	}
+}
+
+func indexPodByNodeName(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a pod, got %T", obj)
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+// getPodCounts returns the number of pods a drain would evict from every node of pool, or
+// nil unless pool prefers its least loaded nodes and pods are watched. Pods that finished
+// running, DaemonSet pods and mirror pods don't count.
+func (ctrl *Controller) getPodCounts(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) map[string]int {
+	if !pool.Spec.PreferLeastLoaded {
+		return nil
+	}
+	if ctrl.podIndexer == nil {
+		glog.V(4).Infof("Pool %s prefers its least loaded nodes, but pods aren't watched", pool.Name)
+		return nil
+	}
+	counts := map[string]int{}
+	for _, node := range nodes {
+		objs, err := ctrl.podIndexer.ByIndex(podNodeNameIndex, node.Name)
+		if err != nil {
+			glog.Warningf("Pool %s: failed to count the pods of node %s: %v", pool.Name, node.Name, err)
+			return nil
+		}
+		for _, obj := range objs {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || isDaemonSetPod(pod) || isMirrorPod(pod) {
+				continue
+			}
+			counts[node.Name]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newPodOnNode(name, node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestGetCandidateMachinesPreferLeastLoaded(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+	}
+	podCounts := map[string]int{"node-0": 12, "node-1": 3, "node-2": 7, "node-3": 3}
+
+	tests := []struct {
+		name      string
+		prefer    bool
+		podCounts map[string]int
+		expected  []string
+	}{
+		{"disabled", false, podCounts, []string{"node-0", "node-1"}},
+		{"fewest pods first, ties by name", true, podCounts, []string{"node-1", "node-3"}},
+		{"pods not counted", true, nil, []string{"node-0", "node-1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+			pool.Spec.PreferLeastLoaded = test.prefer
+			var got []string
+			for _, node := range getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, test.podCounts) {
+				got = append(got, node.Name)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("expected candidates %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetPodCounts(t *testing.T) {
+	daemon := newPodOnNode("daemon", "node-0")
+	daemon.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "daemon", Controller: func(b bool) *bool { return &b }(true)}}
+	mirror := newPodOnNode("mirror", "node-0")
+	mirror.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: ""}
+	completed := newPodOnNode("completed", "node-1")
+	completed.Status.Phase = corev1.PodSucceeded
+	pods := []*corev1.Pod{
+		newPodOnNode("web-0", "node-0"),
+		newPodOnNode("web-1", "node-0"),
+		newPodOnNode("web-2", "node-1"),
+		newPodOnNode("other", "node-9"),
+		daemon, mirror, completed,
+	}
+
+	f := newFixture(t)
+	podInformer := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), noResyncPeriodFunc()).Core().V1().Pods()
+	f.opts = append(f.opts, WithPodInformer(podInformer))
+	c := f.newController()
+	for _, pod := range pods {
+		podInformer.Informer().GetIndexer().Add(pod)
+	}
+
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	nodes := []*corev1.Node{newNode("node-0", "v0", "v0"), newNode("node-1", "v0", "v0"), newNode("node-2", "v0", "v0")}
+	if counts := c.getPodCounts(pool, nodes); counts != nil {
+		t.Fatalf("expected no pod counts for a pool that doesn't prefer its least loaded nodes, got %v", counts)
+	}
+	pool.Spec.PreferLeastLoaded = true
+	expected := map[string]int{"node-0": 2, "node-1": 1}
+	if counts := c.getPodCounts(pool, nodes); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected pod counts %v, got %v", expected, counts)
+	}
+}
+
+func TestGetPodCountsWithoutPodInformer(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithPodInformer(nil))
+	c := f.newController()
+
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Spec.PreferLeastLoaded = true
+	if counts := c.getPodCounts(pool, []*corev1.Node{newNode("node-0", "v0", "v0")}); counts != nil {
+		t.Fatalf("expected no pod counts while pods aren't watched, got %v", counts)
+	}
+}
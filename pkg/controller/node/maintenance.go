@@ -0,0 +1,197 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// maintenanceRecheckInterval is how often whether the maintenance resource is served is
+// checked again while it isn't.
+const maintenanceRecheckInterval = 5 * time.Minute
+
+// NodeMaintenanceLister lists the nodes under an active maintenance, e.g. by a node
+// maintenance operator draining them. The node controller leaves them alone so that
+// two controllers don't drain the same node.
+type NodeMaintenanceLister interface {
+	// NodesUnderMaintenance returns the names of the nodes under an active maintenance.
+	NodesUnderMaintenance() (sets.String, error)
+}
+
+// noNodeMaintenance is the default NodeMaintenanceLister, which puts no node under
+// maintenance.
+type noNodeMaintenance struct{}
+
+func (noNodeMaintenance) NodesUnderMaintenance() (sets.String, error) {
+	return sets.NewString(), nil
+}
+
+// WithNodeMaintenanceLister leaves nodes under an active maintenance according to lister
+// at their current config. Like nodes held for other reasons, they count as unavailable.
+// A NodeMaintenanceInformer is run along with the controller.
+func WithNodeMaintenanceLister(lister NodeMaintenanceLister) Option {
+	return func(ctrl *Controller) {
+		if lister != nil {
+			ctrl.nodeMaintenance = lister
+		}
+		if informer, ok := lister.(NodeMaintenanceInformer); ok {
+			informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: ctrl.enqueueMaintenancePool,
+				UpdateFunc: func(old, cur interface{}) {
+					ctrl.enqueueMaintenancePool(old)
+					ctrl.enqueueMaintenancePool(cur)
+				},
+				DeleteFunc: ctrl.enqueueMaintenancePool,
+			})
+			ctrl.nodeMaintenanceInformer = informer.Informer()
+		}
+	}
+}
+
+// getMaintenanceNodes returns the names of the nodes of pool under an active maintenance.
+func (ctrl *Controller) getMaintenanceNodes(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (sets.String, error) {
+	all, err := ctrl.nodeMaintenance.NodesUnderMaintenance()
+	if err != nil {
+		return nil, err
+	}
+	maintenance := sets.NewString()
+	for _, node := range nodes {
+		if all.Has(node.Name) {
+			maintenance.Insert(node.Name)
+		}
+	}
+	if maintenance.Len() > 0 {
+		glog.V(2).Infof("Pool %s: nodes %v are under maintenance, not updating them", pool.Name, maintenance.List())
+	}
+	return maintenance, nil
+}
+
+// NodeMaintenanceInformer is a NodeMaintenanceLister served from a watch of the
+// maintenances. The node controller runs it, waits for it to sync and syncs the pool of a
+// node whose maintenance changes.
+type NodeMaintenanceInformer interface {
+	NodeMaintenanceLister
+	Informer() cache.SharedIndexInformer
+}
+
+// enqueueMaintenancePool enqueues the pool of the node of a NodeMaintenance-style object.
+func (ctrl *Controller) enqueueMaintenancePool(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	name, _, _ := unstructured.NestedString(item.Object, "spec", "nodeName")
+	if name == "" {
+		return
+	}
+	node, err := ctrl.nodeLister.Get(name)
+	if err != nil {
+		glog.V(4).Infof("Node %s of maintenance %s isn't known: %v", name, item.GetName(), err)
+		return
+	}
+	pool, err := ctrl.getPoolForNode(node)
+	if err != nil || pool == nil {
+		return
+	}
+	ctrl.enqueue(pool)
+}
+
+// resourceMaintenanceInformer watches NodeMaintenance-style resources naming their node
+// in spec.nodeName.
+type resourceMaintenanceInformer struct {
+	informer cache.SharedIndexInformer
+}
+
+// NewNodeMaintenanceInformer returns a NodeMaintenanceInformer watching resource through
+// client, e.g. nodemaintenances.v1beta1.nodemaintenance.kubevirt.io through the REST
+// client of the discovery client. Every object of resource that isn't being deleted puts
+// the node named by its spec.nodeName under maintenance. While resource isn't served,
+// e.g. because its CRD isn't installed, no node is under maintenance, and whether it is
+// served is checked again whenever the watch would time out.
+func NewNodeMaintenanceInformer(client rest.Interface, resource schema.GroupVersionResource) NodeMaintenanceInformer {
+	path := []string{"/apis", resource.Group, resource.Version, resource.Resource}
+	if resource.Group == "" {
+		path = []string{"/api", resource.Version, resource.Resource}
+	}
+	// Watch events are decoded as usual, the objects they carry as unstructured ones.
+	events := json.NewSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, false)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			data, err := client.Get().AbsPath(path...).VersionedParams(&options, metav1.ParameterCodec).Do().Raw()
+			if errors.IsNotFound(err) {
+				glog.V(4).Infof("%s isn't served, no node is under maintenance", resource)
+				return &unstructured.UnstructuredList{Object: map[string]interface{}{}}, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error listing %s: %v", resource, err)
+			}
+			var list unstructured.UnstructuredList
+			if err := list.UnmarshalJSON(data); err != nil {
+				return nil, fmt.Errorf("error decoding %s: %v", resource, err)
+			}
+			return &list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			w, err := client.Get().AbsPath(path...).VersionedParams(&options, metav1.ParameterCodec).WatchWithSpecificDecoders(func(body io.ReadCloser) streaming.Decoder {
+				return streaming.NewDecoder(json.Framer.NewFrameReader(body), events)
+			}, unstructured.UnstructuredJSONScheme)
+			if errors.IsNotFound(err) {
+				return newIdleWatch(options.TimeoutSeconds), nil
+			}
+			return w, err
+		},
+	}
+	return &resourceMaintenanceInformer{
+		informer: cache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, 0, cache.Indexers{}),
+	}
+}
+
+// newIdleWatch returns a watch without events that ends after timeoutSeconds, if set, or
+// after maintenanceRecheckInterval.
+func newIdleWatch(timeoutSeconds *int64) watch.Interface {
+	timeout := maintenanceRecheckInterval
+	if timeoutSeconds != nil {
+		timeout = time.Duration(*timeoutSeconds) * time.Second
+	}
+	w := watch.NewFake()
+	time.AfterFunc(timeout, w.Stop)
+	return w
+}
+
+func (i *resourceMaintenanceInformer) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func (i *resourceMaintenanceInformer) NodesUnderMaintenance() (sets.String, error) {
+	nodes := sets.NewString()
+	for _, obj := range i.informer.GetStore().List() {
+		item, ok := obj.(*unstructured.Unstructured)
+		if !ok || item.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(item.Object, "spec", "nodeName"); name != "" {
+			nodes.Insert(name)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,140 @@
+package node
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeMaintenanceLister puts a fixed set of nodes under maintenance.
+type fakeMaintenanceLister struct {
+	nodes []string
+	err   error
+}
+
+func (l fakeMaintenanceLister) NodesUnderMaintenance() (sets.String, error) {
+	return sets.NewString(l.nodes...), l.err
+}
+
+// syncMaintenancePool syncs a worker pool allowing two nodes to update at once, whose
+// three nodes are updating to v1, with lister. It returns the patched nodes and the
+// error of the sync.
+func syncMaintenancePool(t *testing.T, lister NodeMaintenanceLister) ([]string, error) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithNodeMaintenanceLister(lister))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-2", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	err := c.syncHandler(getKey(mcp, t))
+
+	var patched []string
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok {
+			patched = append(patched, patch.GetName())
+		}
+	}
+	return patched, err
+}
+
+func TestNodeMaintenanceHoldsNodes(t *testing.T) {
+	// node-0 isn't updated, and takes one of the two slots.
+	patched, err := syncMaintenancePool(t, fakeMaintenanceLister{nodes: []string{"node-0", "elsewhere"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patched, []string{"node-1"}) {
+		t.Fatalf("expected only node-1 to be updated next to a node under maintenance, got patches for %v", patched)
+	}
+
+	if _, err := syncMaintenancePool(t, fakeMaintenanceLister{err: fmt.Errorf("connection refused")}); syncErrorReason(err) != syncErrorNodeMaintenance {
+		t.Fatalf("expected the sync to fail while maintenances can't be listed, got %v", err)
+	}
+}
+
+func TestResourceMaintenanceInformer(t *testing.T) {
+	served := true
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !served || r.URL.Path != "/apis/nodemaintenance.kubevirt.io/v1beta1/nodemaintenances" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			fmt.Fprint(w, `{"type": "ADDED", "object": {"apiVersion": "nodemaintenance.kubevirt.io/v1beta1", "kind": "NodeMaintenance", "metadata": {"name": "c", "resourceVersion": "2"}, "spec": {"nodeName": "node-2"}}}`+"\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+			return
+		}
+		fmt.Fprint(w, `{"apiVersion": "nodemaintenance.kubevirt.io/v1beta1", "kind": "NodeMaintenanceList", "metadata": {"resourceVersion": "1"}, "items": [
+			{"apiVersion": "nodemaintenance.kubevirt.io/v1beta1", "kind": "NodeMaintenance", "metadata": {"name": "a"}, "spec": {"nodeName": "node-0"}},
+			{"apiVersion": "nodemaintenance.kubevirt.io/v1beta1", "kind": "NodeMaintenance", "metadata": {"name": "b", "deletionTimestamp": "2019-01-01T00:00:00Z"}, "spec": {"nodeName": "node-1"}}
+		]}`)
+	}))
+	defer server.Close()
+	defer close(done)
+	client, err := rest.UnversionedRESTClientFor(&rest.Config{
+		Host:          server.URL,
+		ContentConfig: rest.ContentConfig{NegotiatedSerializer: serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := schema.GroupVersionResource{Group: "nodemaintenance.kubevirt.io", Version: "v1beta1", Resource: "nodemaintenances"}
+	underMaintenance := func(informer NodeMaintenanceInformer) []string {
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		go informer.Informer().Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, informer.Informer().HasSynced) {
+			t.Fatal("informer didn't sync")
+		}
+		var nodes sets.String
+		if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			nodes, err = informer.NodesUnderMaintenance()
+			return err != nil || served == nodes.Has("node-2"), err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return nodes.List()
+	}
+
+	// The maintenance of node-1 is being deleted, the one of node-2 comes through the watch.
+	if nodes := underMaintenance(NewNodeMaintenanceInformer(client, resource)); !reflect.DeepEqual(nodes, []string{"node-0", "node-2"}) {
+		t.Fatalf("expected node-0 and node-2 to be under maintenance, got %v", nodes)
+	}
+
+	// Without the CRD, no node is under maintenance.
+	served = false
+	if nodes := underMaintenance(NewNodeMaintenanceInformer(client, resource)); len(nodes) != 0 {
+		t.Fatalf("expected no node under maintenance while the resource isn't served, got %v", nodes)
+	}
+}
@@ -0,0 +1,116 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// desiredConfigTracker remembers the desired config the controller last set on every node.
+type desiredConfigTracker struct {
+	lock sync.Mutex
+	set  map[string]desiredConfig
+	// reported is the manually edited desired config last reported for a node.
+	reported map[string]string
+}
+
+// desiredConfig is the desired config set on a node.
+type desiredConfig struct {
+	config string
+	// staleResourceVersion is the resource version of the node before it was set, which
+	// the node lister may still return for a while.
+	staleResourceVersion string
+}
+
+func newDesiredConfigTracker() *desiredConfigTracker {
+	return &desiredConfigTracker{
+		set:      map[string]desiredConfig{},
+		reported: map[string]string{},
+	}
+}
+
+// WithHonorManualDesiredConfigEdits decides what happens when the desired config of a
+// node is edited by hand while the target of its pool stays the same. Either way a
+// warning event is recorded. If honor is true the node is left at the edited config and
+// counts as unavailable, like a pinned node, until the target of its pool changes.
+// Otherwise, the default, the node is set back to the target of its pool.
+func WithHonorManualDesiredConfigEdits(honor bool) Option {
+	return func(ctrl *Controller) {
+		ctrl.honorManualEdits = honor
+	}
+}
+
+// record remembers that the desired config of node, as it was before, was set to config.
+func (t *desiredConfigTracker) record(node *corev1.Node, config string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.set[node.Name] = desiredConfig{config: config, staleResourceVersion: node.ResourceVersion}
+	delete(t.reported, node.Name)
+}
+
+// forget drops what is known about node, e.g. because it was deleted.
+func (t *desiredConfigTracker) forget(node string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.set, node)
+	delete(t.reported, node)
+}
+
+// manualEdit returns the desired config last set on node and whether node now has a
+// different one although the target of the pool is still that config. The second
+// bool reports whether this edit was already reported.
+func (t *desiredConfigTracker) manualEdit(node *corev1.Node, target string) (string, bool, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	set, ok := t.set[node.Name]
+	desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+	if !ok || set.config != target || desired == set.config || node.ResourceVersion == set.staleResourceVersion {
+		return set.config, false, false
+	}
+	reported := t.reported[node.Name] == desired
+	t.reported[node.Name] = desired
+	return set.config, true, reported
+}
+
+// handleManualDesiredConfigEdits reports nodes whose desired config was edited behind the
+// controller's back and either sets them back to the target of the pool, or returns them
+// as held if manual edits are honored.
+func (ctrl *Controller) handleManualDesiredConfigEdits(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (sets.String, error) {
+	held := sets.NewString()
+	for _, node := range nodes {
+		if _, ok := getPinnedConfig(node); ok {
+			continue
+		}
+		set, edited, reported := ctrl.desiredConfigs.manualEdit(node, pool.Spec.Configuration.Name)
+		if !edited {
+			continue
+		}
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		if !reported {
+			action := "overriding it"
+			if ctrl.honorManualEdits {
+				action = "leaving it alone"
+			}
+			glog.Warningf("Pool %s: desired config of node %s was manually changed from %s to %s, %s", pool.Name, node.Name, set, desired, action)
+			ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "ManualDesiredConfigEdit", "Desired config was manually changed from %s to %s, %s", set, desired, action)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ManualDesiredConfigEdit", "Desired config of node %s was manually changed from %s to %s, %s", node.Name, set, desired, action)
+		}
+		if ctrl.honorManualEdits {
+			held.Insert(node.Name)
+			continue
+		}
+		err := ctrl.setDesiredMachineConfigAnnotation(node.Name, pool.Spec.Configuration.Name)
+		ctrl.recordDesiredConfigResult(pool, node, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return held, nil
+}
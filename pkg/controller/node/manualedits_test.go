@@ -0,0 +1,80 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestHandleManualDesiredConfigEdits(t *testing.T) {
+	for _, honor := range []bool{true, false} {
+		f := newFixture(t)
+		pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+		before := newNode("node-0", "v0", "v0")
+		before.ResourceVersion = "1"
+		edited := newNode("node-0", "v0", "v-manual")
+		edited.ResourceVersion = "2"
+		untouched := newNode("node-1", "v0", "v1")
+		f.kubeobjects = append(f.kubeobjects, edited, untouched)
+		f.opts = []Option{WithHonorManualDesiredConfigEdits(honor)}
+		c := f.newController()
+		recorder := record.NewFakeRecorder(10)
+		c.eventRecorder = recorder
+		c.desiredConfigs.record(before, "v1")
+		c.desiredConfigs.record(untouched, "v1")
+
+		// The lister may not have caught up with the controller's own update yet.
+		held, err := c.handleManualDesiredConfigEdits(pool, []*corev1.Node{before, untouched})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(held) != 0 || len(recorder.Events) != 0 {
+			t.Fatalf("honor=%v: expected a stale node not to count as edited, held %v", honor, held.List())
+		}
+
+		for i := 0; i < 2; i++ {
+			held, err = c.handleManualDesiredConfigEdits(pool, []*corev1.Node{edited, untouched})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if honor && !(held.Has("node-0") && held.Len() == 1) {
+			t.Fatalf("expected node-0 to be held, got %v", held.List())
+		}
+		if !honor && held.Len() != 0 {
+			t.Fatalf("expected no node to be held, got %v", held.List())
+		}
+		if len(recorder.Events) != 2 {
+			t.Fatalf("honor=%v: expected a node and a pool event reported once, got %d events", honor, len(recorder.Events))
+		}
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ManualDesiredConfigEdit") {
+				t.Fatalf("unexpected event: %s", event)
+			}
+		}
+
+		patched := false
+		for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+			if action.GetVerb() == "patch" {
+				patched = true
+			}
+		}
+		if patched == honor {
+			t.Fatalf("honor=%v: unexpected desired config reset: %v", honor, patched)
+		}
+		if !honor {
+			node, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != "v1" {
+				t.Fatalf("expected node-0 set back to v1, got %s", desired)
+			}
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package node
+
+import "expvar"
+
+var (
+	// masterMaxUnavailableClamped counts the syncs in which the master pool's
+	// maxUnavailable was lowered to preserve etcd quorum.
+	masterMaxUnavailableClamped = expvar.NewInt("mcc_master_maxunavailable_clamped_total")
+
+	// nodePatchLatency holds the count and total seconds of the node patches
+	// setting desired configs.
+	nodePatchLatency = expvar.NewMap("mcc_node_patch_latency")
+
+	// nodePatchBackpressure is 1 while pool syncs are serialized because node
+	// patches are slow, and 0 otherwise.
+	nodePatchBackpressure = expvar.NewInt("mcc_node_patch_backpressure")
+
+	// nodeUpdateRetriesExhausted counts the node updates that still conflicted with
+	// other writers after all retries of the node update backoff.
+	nodeUpdateRetriesExhausted = expvar.NewInt("mcc_node_update_retries_exhausted_total")
+
+	// poolConfigAge holds the seconds since the target config of each pool last changed.
+	poolConfigAge = newAgeMap("mcc_pool_config_age_seconds")
+)
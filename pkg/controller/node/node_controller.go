@@ -3,10 +3,15 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
+	drain "github.com/openshift/kubernetes-drain"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
@@ -21,11 +26,13 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	batchlisterv1 "k8s.io/client-go/listers/batch/v1"
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -44,11 +51,96 @@ const (
 	// updateDelay is a pause to deal with churn in MachineConfigs; see
 	// https://github.com/openshift/machine-config-operator/issues/301
 	updateDelay = 5 * time.Second
+
+	// allowAllAtOnceAnnotationKey acknowledges that a pool may update every node at the same time.
+	// Without it a maxUnavailable covering the whole pool is clamped to leave one node untouched.
+	allowAllAtOnceAnnotationKey = "machineconfiguration.openshift.io/allow-all-at-once"
+
+	// pinConfigAnnotationKey pins a node to the named MachineConfig. The controller
+	// leaves the desired config of a pinned node alone and counts it as unavailable.
+	pinConfigAnnotationKey = "machineconfiguration.openshift.io/pin-config"
+
+	// forceSyncAnnotationKey is set on a pool to an arbitrary nonce; changing it syncs the
+	// pool right away instead of after updateDelay. No safety checks are skipped.
+	forceSyncAnnotationKey = "machineconfiguration.openshift.io/force-sync"
+
+	// maxUnavailableOverrideAnnotationKey is set on a pool to a number or percentage of nodes
+	// used instead of the pool's maxUnavailable, e.g. to update one node at a time during a
+	// risky rollout without touching the spec. The usual protections still apply.
+	maxUnavailableOverrideAnnotationKey = "machineconfiguration.openshift.io/max-unavailable-override"
+
+	// quarantinedAnnotationKey is set by the controller on nodes that failed to apply their
+	// desired config too often, to the name of that config. Quarantined nodes are left alone.
+	quarantinedAnnotationKey = "machineconfiguration.openshift.io/quarantined"
+
+	// pausedAnnotationKey is set to "true" on nodes to pause them individually. The
+	// controller leaves paused nodes alone while the rest of their pool is updated.
+	pausedAnnotationKey = "machineconfiguration.openshift.io/paused"
+
+	// urgentConfigAnnotationKey is set on a pool to the name of a MachineConfig that the
+	// nodes listed in urgentNodesAnnotationKey are set to right away, bypassing the target
+	// of the pool and maxUnavailable, e.g. to respond to a CVE. It only takes effect once
+	// urgentConfigAckAnnotationKey is set to the same name.
+	urgentConfigAnnotationKey = "machineconfiguration.openshift.io/urgent-config"
+	// urgentNodesAnnotationKey is set on a pool to the comma separated names of the nodes
+	// to set to the urgent config.
+	urgentNodesAnnotationKey = "machineconfiguration.openshift.io/urgent-nodes"
+	// urgentConfigAckAnnotationKey acknowledges the urgent config of a pool when set to its name.
+	urgentConfigAckAnnotationKey = "machineconfiguration.openshift.io/urgent-config-ack"
+
+	// preUpdateHookAnnotationKey is set by the controller on nodes of pools with update hooks
+	// to the config it is about to update them to, and preUpdateDoneAnnotationKey is set to
+	// the same config once the pre-update hook ran. postUpdateDoneAnnotationKey is set to the
+	// config a node updated to once the post-update hook ran.
+	preUpdateHookAnnotationKey  = "machineconfiguration.openshift.io/pre-update-hook"
+	preUpdateDoneAnnotationKey  = "machineconfiguration.openshift.io/pre-update-done"
+	postUpdateDoneAnnotationKey = "machineconfiguration.openshift.io/post-update-done"
+
+	// cordonedOnSelectAnnotationKey is set by the controller on nodes it cordoned when
+	// setting their desired config, to the config, so it can uncordon them should the
+	// daemon not complete that update, e.g. because the desired config was reverted.
+	cordonedOnSelectAnnotationKey = "machineconfiguration.openshift.io/cordoned-on-select"
+
+	// drainCordonedAnnotationKey is set by the controller on nodes it cordons to drain
+	// them ahead of their update, to the config they are drained for, until their desired
+	// config is set.
+	drainCordonedAnnotationKey = "machineconfiguration.openshift.io/cordoned-for-drain"
+
+	// approvedConfigAnnotationKey is set by an operator on pools requiring approval to the
+	// name of the target config whose rollout they approve.
+	approvedConfigAnnotationKey = "machineconfiguration.openshift.io/approved-config"
+
+	// verifiedAnnotationKey is set to "true" by an external verifier on nodes of pools
+	// verifying updates once a node passed verification after its update. The controller
+	// removes it when the node starts its next update. verificationFailedAnnotationKey is
+	// set by the controller to the config of nodes it marked failing because their
+	// verification timed out.
+	verifiedAnnotationKey           = "machineconfiguration.openshift.io/verified"
+	verificationFailedAnnotationKey = "machineconfiguration.openshift.io/verification-failed"
+
+	// candidateSummaryAnnotationKey is set by the controller on pools, if enabled, to a
+	// JSON summary of how the latest sync picked the nodes to update.
+	candidateSummaryAnnotationKey = "machineconfiguration.openshift.io/candidate-summary"
+
+	// propagatedAnnotationsAnnotationKey is set by the controller on nodes to the comma
+	// separated keys of the annotations it copied from their pool, so it can remove them
+	// once the pool stops propagating them.
+	propagatedAnnotationsAnnotationKey = "machineconfiguration.openshift.io/propagated-annotations"
+
+	// skipDrainAnnotationKey is set by the controller, along with the desired config, on
+	// nodes opted out of draining to that config, telling the daemon it may skip the drain
+	// for that update. Set to another config than the desired one, it is stale.
+	skipDrainAnnotationKey = "machineconfiguration.openshift.io/skip-drain"
+
+	// rolloutGateRecheckDelay is how long a pool held back by the rollout gate waits before
+	// the gate is consulted again.
+	rolloutGateRecheckDelay = 30 * time.Second
 )
 
 // controllerKind contains the schema.GroupVersionKind for this controller type.
 var controllerKind = mcfgv1.SchemeGroupVersion.WithKind("MachineConfigPool")
 
+// nodeUpdateBackoff is the default backoff of node updates retried on conflicts.
 var nodeUpdateBackoff = wait.Backoff{
 	Steps:    5,
 	Duration: 100 * time.Millisecond,
@@ -57,6 +149,14 @@ var nodeUpdateBackoff = wait.Backoff{
 
 // Controller defines the node controller.
 type Controller struct {
+	// The 64-bit fields accessed with sync/atomic come first to keep them 64-bit aligned
+	// on 32-bit platforms.
+
+	// lastSuccessfulSync is the UnixNano time of the latest successful pool sync.
+	lastSuccessfulSync int64
+	// runStarted is the UnixNano time Run was called, or zero before.
+	runStarted int64
+
 	client        mcfgclientset.Interface
 	kubeClient    clientset.Interface
 	eventRecorder record.EventRecorder
@@ -65,20 +165,225 @@ type Controller struct {
 	enqueueMachineConfigPool func(*mcfgv1.MachineConfigPool)
 
 	mcpLister  mcfglistersv1.MachineConfigPoolLister
+	mcLister   mcfglistersv1.MachineConfigLister
 	nodeLister corelisterv1.NodeLister
 
 	mcpListerSynced  cache.InformerSynced
+	mcListerSynced   cache.InformerSynced
 	nodeListerSynced cache.InformerSynced
 
 	queue workqueue.RateLimitingInterface
+
+	// nodeReadyChecker decides whether a node is ready; see NodeReadyChecker.
+	nodeReadyChecker NodeReadyChecker
+
+	// healthAddr is where the health endpoints are served, if set.
+	healthAddr       string
+	healthStaleAfter time.Duration
+	// resyncEndpoint is whether /resync is served along with the health endpoints.
+	resyncEndpoint bool
+
+	// fairness defers pools that used more than their share of worker time, if set.
+	fairness *poolFairness
+
+	// candidateJitter is the base delay between setting the desired config
+	// of consecutive candidates within a single sync.
+	candidateJitter time.Duration
+
+	// updateDurations tracks recent node update durations to estimate rollout times.
+	updateDurations *updateDurations
+
+	// rolloutGate decides whether new node updates may be started.
+	rolloutGate RolloutGate
+
+	// drainTimeout bounds how long Run waits for in-flight syncs and node drains once stopped.
+	drainTimeout time.Duration
+	// drainsRunning tracks the node drains running in the background.
+	drainsRunning sync.WaitGroup
+	// draining is set to 1 once Run is stopped; queued pools are then left alone.
+	draining int32
+
+	// masterOrdering reorders the update candidates of the master pool, if set.
+	masterOrdering MasterOrdering
+
+	// globalBudget caps the nodes updating across all pools, if set.
+	globalBudget *globalBudget
+
+	// rolloutComplete receives a notification when a pool finishes updating, if set.
+	rolloutComplete chan<- RolloutComplete
+
+	// applyNode server-side applies node fields; strategic merge patches are used if unset.
+	applyNode nodeApplier
+
+	// desiredConfigs remembers the desired config last set on every node to spot manual edits.
+	desiredConfigs *desiredConfigTracker
+	// honorManualEdits leaves manually edited desired configs alone instead of overriding them.
+	honorManualEdits bool
+
+	// patchLatency tracks node patch latencies to hold back syncs while they are slow, if set.
+	patchLatency *patchLatency
+
+	// prePullLookahead is how many nodes due to update next are asked to pre-pull the target config.
+	prePullLookahead int
+
+	// startupGracePeriod delays the first sync of pools added within this long after Run started.
+	startupGracePeriod time.Duration
+
+	// tracer starts the spans of pool syncs.
+	tracer TracerProvider
+
+	// quarantineThreshold is how many failures in a row quarantine a node, if set.
+	quarantineThreshold int
+	// failures counts the failures in a row of every node.
+	failures *failureTracker
+
+	// nodeUpdateBackoff is how node updates are retried on conflicts.
+	nodeUpdateBackoff wait.Backoff
+
+	// scaleDownAnnotations are the annotation keys marking nodes about to be removed.
+	scaleDownAnnotations []string
+
+	// pausedWarnAfter is how long a pool may stay paused before it is warned about, if set.
+	pausedWarnAfter time.Duration
+	// pauses remembers when pools were paused.
+	pauses *pauseTracker
+
+	// conflictThreshold is how many syncs in a row conflicting on a node escalate it, if set.
+	conflictThreshold int
+	// conflicts counts the syncs in a row conflicting on every node.
+	conflicts *conflictTracker
+
+	// updateCooldown is how long nodes are left alone after completing an update, if set.
+	updateCooldown time.Duration
+	// completions remembers when nodes last completed an update.
+	completions *completionTracker
+	// configChanges remembers when the target config of pools was seen changing.
+	configChanges *configChangeTracker
+
+	// statusDebounce is how long status recomputations of a pool are coalesced, if set.
+	statusDebounce time.Duration
+	// statusRecomputes remembers when the status of pools was last recomputed.
+	statusRecomputes *statusDebouncer
+	// defaultsConfigMap names the ConfigMap with the defaults of pools, if set.
+	defaultsConfigMapNamespace string
+	defaultsConfigMap          string
+	configMapLister            corelisterv1.ConfigMapLister
+	configMapListerSynced      cache.InformerSynced
+	// failingBackoffs slows down syncing pools whose nodes all fail their target config.
+	failingBackoffs *failingBackoff
+	// drains tracks the drains of nodes of pools with a drain policy.
+	drains *drainTracker
+	// evictNode evicts the pods of a node, and runDrain runs a drain in the background.
+	evictNode func(node *corev1.Node, options *drain.DrainOptions) error
+	runDrain  func(run func())
+	// candidateSummary has syncs summarize the nodes they picked in an annotation of the pool.
+	candidateSummary bool
+	// jobLister looks up the blocking Jobs of pools, if Jobs are watched.
+	jobLister       batchlisterv1.JobLister
+	jobListerSynced cache.InformerSynced
+	// nodeEvents also records the progress of node updates on the nodes themselves.
+	nodeEvents bool
+	// verifications remembers since when nodes wait for the verification of their update.
+	verifications *verificationTracker
+	// podIndexer looks up the pods of nodes, if pods are watched.
+	podIndexer      cache.Indexer
+	podListerSynced cache.InformerSynced
+	// auditSink records the decisions changing which config nodes run.
+	auditSink AuditSink
+	// quorumClamps remembers which pools are limited to preserve etcd quorum.
+	quorumClamps *quorumClampTracker
+	// poolEvents remembers what the events recorded by every sync of pools last reported.
+	poolEvents *poolEventTracker
+	// nodeMaintenance lists the nodes under an active maintenance, which aren't updated.
+	nodeMaintenance NodeMaintenanceLister
+	// nodeMaintenanceInformer is run along with the controller if maintenances are watched.
+	nodeMaintenanceInformer cache.SharedIndexInformer
+	// observeSyncTimings receives how long every sync took, overall and in each phase.
+	observeSyncTimings func(key string, total time.Duration, timings syncTimings)
+	// drainNotRequiredAnnotation is the annotation key opting nodes out of draining.
+	drainNotRequiredAnnotation string
+}
+
+// Option configures optional behavior of the node controller.
+type Option func(*Controller)
+
+// NodeReadyChecker returns a non-nil error describing why a node is not ready.
+type NodeReadyChecker func(node *corev1.Node) error
+
+// WithNodeReadyChecker replaces the default node readiness check, which
+// looks at the Ready, OutOfDisk and NetworkUnavailable conditions and at
+// whether the node is schedulable.
+func WithNodeReadyChecker(checker NodeReadyChecker) Option {
+	return func(ctrl *Controller) {
+		ctrl.nodeReadyChecker = checker
+	}
+}
+
+// WithCandidateJitter staggers the desired config updates of the candidates
+// selected in a single sync by waiting a random duration in [jitter, 2*jitter)
+// between them, so their daemons don't all start draining at the same instant.
+// A zero jitter, the default, updates all candidates back to back.
+func WithCandidateJitter(jitter time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.candidateJitter = jitter
+	}
+}
+
+// MasterOrdering receives the master nodes that still need to be updated and
+// returns them in the order they should be updated in, e.g. with the etcd leader
+// last. It must return the same nodes it was given.
+type MasterOrdering func(nodes []*corev1.Node) []*corev1.Node
+
+// WithMasterOrdering has the master pool update its nodes in the order given by
+// order. By default nodes are updated by name, or with spec.preferLeastLoaded by pod
+// count first. Nodes already updating are retargeted ahead of the order either way, and
+// the etcd quorum protection of maxUnavailable applies regardless.
+func WithMasterOrdering(order MasterOrdering) Option {
+	return func(ctrl *Controller) {
+		ctrl.masterOrdering = order
+	}
+}
+
+// WithDrainTimeout makes Run wait up to timeout for in-flight pool syncs and node drains
+// to finish once it is stopped, so that a sync isn't cut off between patching nodes. Pools
+// still queued are not synced anymore either way.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.drainTimeout = timeout
+	}
+}
+
+// WithStartupGracePeriod delays syncing the pools enqueued within period after Run
+// starts, e.g. all of them on startup, until period has passed so that the renderer and
+// listers can settle first. Zero, the default, syncs them after the usual short delay.
+func WithStartupGracePeriod(period time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.startupGracePeriod = period
+	}
+}
+
+// WithNodeUpdateBackoff retries node updates that conflict with other writers with
+// backoff instead of the default of 5 steps starting at 100ms with full jitter, e.g.
+// with more steps on clusters where nodes are updated by many clients. A backoff
+// without steps would never attempt the update at all, so the default is kept then.
+func WithNodeUpdateBackoff(backoff wait.Backoff) Option {
+	return func(ctrl *Controller) {
+		if backoff.Steps < 1 {
+			glog.Warningf("Ignoring node update backoff with %d steps, using the default of %d", backoff.Steps, nodeUpdateBackoff.Steps)
+			return
+		}
+		ctrl.nodeUpdateBackoff = backoff
+	}
 }
 
 // New returns a new node controller.
 func New(
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
 	nodeInformer coreinformersv1.NodeInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
+	opts ...Option,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -89,6 +394,29 @@ func New(
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "machineconfigcontroller-nodecontroller"}),
 		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-nodecontroller"),
+
+		nodeReadyChecker: checkNodeReady,
+		updateDurations:  newUpdateDurations(),
+		rolloutGate:      alwaysAllowed{},
+		desiredConfigs:   newDesiredConfigTracker(),
+		tracer:           noopTracer{},
+		failures:         newFailureTracker(),
+		pauses:           newPauseTracker(),
+		conflicts:        newConflictTracker(),
+		completions:      newCompletionTracker(),
+		configChanges:    newConfigChangeTracker(),
+		statusRecomputes: newStatusDebouncer(),
+		failingBackoffs:  newFailingBackoff(allFailingBackoffBase, allFailingBackoffMax),
+		drains:           newDrainTracker(),
+		verifications:    newVerificationTracker(),
+		auditSink:        noopAuditSink{},
+		quorumClamps:     newQuorumClampTracker(),
+		poolEvents:       newPoolEventTracker(),
+		nodeMaintenance:  noNodeMaintenance{},
+
+		observeSyncTimings: logSyncTimings,
+
+		nodeUpdateBackoff: nodeUpdateBackoff,
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -101,42 +429,121 @@ func New(
 		UpdateFunc: ctrl.updateNode,
 		DeleteFunc: ctrl.deleteNode,
 	})
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.enqueueSelectingPools,
+		UpdateFunc: func(_, cur interface{}) { ctrl.enqueueSelectingPools(cur) },
+		DeleteFunc: ctrl.enqueueSelectingPools,
+	})
 
 	ctrl.syncHandler = ctrl.syncMachineConfigPool
 	ctrl.enqueueMachineConfigPool = ctrl.enqueueDefault
+	ctrl.evictNode = ctrl.evict
+	ctrl.runDrain = func(run func()) {
+		ctrl.drainsRunning.Add(1)
+		go func() {
+			defer ctrl.drainsRunning.Done()
+			run()
+		}()
+	}
 
 	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
 	ctrl.nodeLister = nodeInformer.Lister()
 	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
 	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
 
+	for _, opt := range opts {
+		opt(ctrl)
+	}
+
 	return ctrl
 }
 
 // Run executes the render controller.
 func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer ctrl.queue.ShutDown()
+	atomic.StoreInt64(&ctrl.runStarted, time.Now().UnixNano())
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.nodeListerSynced) {
+	synced := []cache.InformerSynced{ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.nodeListerSynced}
+	if ctrl.configMapListerSynced != nil {
+		synced = append(synced, ctrl.configMapListerSynced)
+	}
+	if ctrl.jobListerSynced != nil {
+		synced = append(synced, ctrl.jobListerSynced)
+	}
+	if ctrl.podListerSynced != nil {
+		synced = append(synced, ctrl.podListerSynced)
+	}
+	if ctrl.nodeMaintenanceInformer != nil {
+		go ctrl.nodeMaintenanceInformer.Run(stopCh)
+		synced = append(synced, ctrl.nodeMaintenanceInformer.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
+		ctrl.queue.ShutDown()
 		return
 	}
 
 	glog.Info("Starting MachineConfigController-NodeController")
 	defer glog.Info("Shutting down MachineConfigController-NodeController")
 
+	ctrl.recordSuccessfulSync(time.Now())
+	if ctrl.healthAddr != "" {
+		go ctrl.runHealthServer(stopCh)
+	}
+
+	var workersDone sync.WaitGroup
 	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			wait.Until(ctrl.worker, time.Second, stopCh)
+		}()
 	}
 
 	<-stopCh
+	ctrl.drain(&workersDone)
+}
+
+// drain stops the workers from picking up queued pools and waits up to drainTimeout
+// for the syncs and node drains already in flight to finish.
+func (ctrl *Controller) drain(workersDone *sync.WaitGroup) {
+	atomic.StoreInt32(&ctrl.draining, 1)
+	ctrl.queue.ShutDown()
+
+	done := make(chan struct{})
+	go func() {
+		workersDone.Wait()
+		ctrl.drainsRunning.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(ctrl.drainTimeout):
+		glog.Warningf("Gave up waiting for in-flight machineconfigpool syncs after %v", ctrl.drainTimeout)
+	}
 }
 
 func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
 	pool := obj.(*mcfgv1.MachineConfigPool)
 	glog.V(4).Infof("Adding MachineConfigPool %s", pool.Name)
 	ctrl.enqueueMachineConfigPool(pool)
+}
 
+// startupDelay returns how much of the startup grace period is left at now. Run not
+// having started yet leaves all of it.
+func (ctrl *Controller) startupDelay(now time.Time) time.Duration {
+	if ctrl.startupGracePeriod <= 0 {
+		return 0
+	}
+	started := atomic.LoadInt64(&ctrl.runStarted)
+	if started == 0 {
+		return ctrl.startupGracePeriod
+	}
+	if remaining := time.Unix(0, started).Add(ctrl.startupGracePeriod).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
 }
 
 func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
@@ -144,7 +551,78 @@ func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
 	curPool := cur.(*mcfgv1.MachineConfigPool)
 
 	glog.V(4).Infof("Updating MachineConfigPool %s", oldPool.Name)
-	ctrl.enqueueMachineConfigPool(curPool)
+	if !oldPool.Spec.Paused && curPool.Spec.Paused {
+		ctrl.pauses.pause(curPool.Name, time.Now())
+	}
+	if oldPool.Spec.Paused != curPool.Spec.Paused {
+		ctrl.auditPause(curPool)
+	}
+	// The config named by pools with a configuration selector is ignored, their syncs
+	// detect changes of the config it resolves to.
+	if oldPool.Spec.Configuration.Name != curPool.Spec.Configuration.Name && curPool.Spec.ConfigurationSelector == nil {
+		ctrl.configChanges.change(curPool.Name, time.Now())
+	}
+	if oldPool.Annotations[forceSyncAnnotationKey] != curPool.Annotations[forceSyncAnnotationKey] {
+		glog.Infof("Pool %s: forced sync requested (%s=%s)", curPool.Name, forceSyncAnnotationKey, curPool.Annotations[forceSyncAnnotationKey])
+		ctrl.enqueue(curPool)
+	} else {
+		ctrl.enqueueMachineConfigPool(curPool)
+	}
+
+	if !reflect.DeepEqual(nodeSelectorsOf(oldPool), nodeSelectorsOf(curPool)) {
+		ctrl.enqueuePoolsForMovedNodes(oldPool, curPool)
+	}
+}
+
+// enqueuePoolsForMovedNodes enqueues the pools now responsible for the nodes that
+// entered or left pool because its node selectors changed from those of oldPool, so
+// that nodes handed back to another pool get their desired config corrected.
+func (ctrl *Controller) enqueuePoolsForMovedNodes(oldPool, pool *mcfgv1.MachineConfigPool) {
+	moved, err := ctrl.getNodesWithChangedMembership(oldPool, pool)
+	if err != nil {
+		glog.Errorf("error finding nodes moved by selector change of pool %s: %v", pool.Name, err)
+		return
+	}
+	enqueued := map[string]bool{pool.Name: true}
+	for _, node := range moved {
+		newPool, err := ctrl.getPoolForNode(node)
+		if err != nil {
+			glog.Errorf("error finding pool for node: %v", err)
+			continue
+		}
+		if newPool == nil || enqueued[newPool.Name] {
+			continue
+		}
+		glog.V(4).Infof("Node %s moved to pool %s after selector change of pool %s", node.Name, newPool.Name, pool.Name)
+		enqueued[newPool.Name] = true
+		ctrl.enqueueMachineConfigPool(newPool)
+	}
+}
+
+// getNodesWithChangedMembership returns the nodes selected by exactly one of the two pools.
+// Like in getPoolForNode, a nil or empty selector matches nothing.
+func (ctrl *Controller) getNodesWithChangedMembership(oldPool, newPool *mcfgv1.MachineConfigPool) ([]*corev1.Node, error) {
+	oldSel, err := newPoolNodeSelector(oldPool)
+	if err != nil {
+		return nil, err
+	}
+	newSel, err := newPoolNodeSelector(newPool)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := ctrl.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var moved []*corev1.Node
+	for _, node := range nodes {
+		oldMatch := oldSel.Matches(labels.Set(node.Labels))
+		newMatch := newSel.Matches(labels.Set(node.Labels))
+		if oldMatch != newMatch {
+			moved = append(moved, node)
+		}
+	}
+	return moved, nil
 }
 
 func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
@@ -162,6 +640,12 @@ func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
 		}
 	}
 	glog.V(4).Infof("Deleting MachineConfigPool %s", pool.Name)
+	ctrl.configChanges.forget(pool.Name)
+	ctrl.statusRecomputes.forget(pool.Name)
+	ctrl.failingBackoffs.forget(pool.Name)
+	ctrl.poolEvents.forget(pool.Name)
+	ctrl.pauses.forget(pool.Name)
+	poolConfigAge.delete(pool.Name)
 	// TODO(abhinavdahiya): handle deletes.
 }
 
@@ -181,14 +665,21 @@ func (ctrl *Controller) addNode(obj interface{}) {
 		return
 	}
 	glog.V(4).Infof("Node %s added", node.Name)
-	ctrl.enqueueMachineConfigPool(pool)
+	ctrl.enqueueForNodeEvent(pool)
 }
 
 func (ctrl *Controller) updateNode(old, cur interface{}) {
 	oldNode := old.(*corev1.Node)
 	curNode := cur.(*corev1.Node)
 
-	if !isNodeManaged(curNode) {
+	// A label change may move the node from one pool to another, and both need to
+	// account for it.
+	moved := !labels.Equals(oldNode.Labels, curNode.Labels) && ctrl.enqueuePoolsForMovedNode(oldNode, curNode)
+
+	// Nodes the MCD never ran on only matter to their pool once they can be given
+	// their initial config.
+	if !isNodeManaged(curNode) && (!needsInitialConfig(curNode) ||
+		getErrorString(ctrl.nodeReadyChecker(oldNode)) == getErrorString(ctrl.nodeReadyChecker(curNode))) {
 		return
 	}
 
@@ -203,12 +694,21 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 	glog.V(4).Infof("Node %s updated", curNode.Name)
 
 	var changed bool
-	oldReadyErr := checkNodeReady(oldNode)
-	newReadyErr := checkNodeReady(curNode)
+	checkReady := withRequiredConditions(pool, ctrl.nodeReadyChecker)
+	oldReadyErr := checkReady(oldNode)
+	newReadyErr := checkReady(curNode)
 
 	oldReady := getErrorString(oldReadyErr)
 	newReady := getErrorString(newReadyErr)
 
+	if oldPressure, newPressure := checkNodePressure(oldNode), checkNodePressure(curNode); pool.Spec.SkipNodesUnderPressure && getErrorString(oldPressure) != getErrorString(newPressure) {
+		changed = true
+		if newPressure != nil {
+			glog.Infof("Pool %s: %v, not updating it until it clears", pool.Name, newPressure)
+		} else {
+			glog.Infof("Pool %s: node %s is no longer under pressure", pool.Name, curNode.Name)
+		}
+	}
 	if oldReady != newReady {
 		changed = true
 		if newReadyErr != nil {
@@ -222,9 +722,30 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 	if oldNode.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] != oldNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] &&
 		isNodeDone(curNode) {
 		glog.Infof("Pool %s: node %s has completed update to %s", pool.Name, curNode.Name, curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+		ctrl.updateDurations.finish(pool.Name, curNode.Name)
+		ctrl.failures.forget(curNode.Name)
+		ctrl.completions.complete(curNode.Name)
+		ctrl.nodeEventf(curNode, v1.EventTypeNormal, "NodeUpdateCompleted", "Node completed its update to %s", curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
 		changed = true
 	} else {
-		annos := []string{daemonconsts.CurrentMachineConfigAnnotationKey, daemonconsts.DesiredMachineConfigAnnotationKey, daemonconsts.MachineConfigDaemonStateAnnotationKey}
+		if isNodeMCDState(curNode, daemonconsts.MachineConfigDaemonStateWorking) && !isNodeMCDState(oldNode, daemonconsts.MachineConfigDaemonStateWorking) &&
+			curNode.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] != curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] {
+			desired := curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+			ctrl.nodeEventf(curNode, v1.EventTypeNormal, "NodeUpdateStarted", "Node %s started updating to %s", curNode.Name, desired)
+			ctrl.eventRecorder.Eventf(pool, v1.EventTypeNormal, "NodeUpdateStarted", "Node %s started updating to %s", curNode.Name, desired)
+		}
+		if desired := curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != oldNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] &&
+			desired != curNode.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+			ctrl.updateDurations.start(curNode.Name)
+		}
+		if isNodeMCDFailing(curNode) && !isNodeMCDFailing(oldNode) {
+			desired := curNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+			failures := ctrl.failures.fail(curNode.Name, desired)
+			glog.Infof("Pool %s: node %s failed to apply %s, %d times in a row", pool.Name, curNode.Name, desired, failures)
+			ctrl.nodeEventf(curNode, v1.EventTypeWarning, "NodeUpdateStuck", "Node failed to apply %s, %d times in a row: %s", desired, failures, curNode.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey])
+		}
+		annos := []string{daemonconsts.CurrentMachineConfigAnnotationKey, daemonconsts.DesiredMachineConfigAnnotationKey, daemonconsts.MachineConfigDaemonStateAnnotationKey,
+			preUpdateDoneAnnotationKey, postUpdateDoneAnnotationKey, verifiedAnnotationKey}
 		for _, anno := range annos {
 			if oldNode.Annotations[anno] != curNode.Annotations[anno] {
 				glog.Infof("Pool %s: node %s changed %s = %s", pool.Name, curNode.Name, anno, curNode.Annotations[anno])
@@ -233,11 +754,37 @@ func (ctrl *Controller) updateNode(old, cur interface{}) {
 		}
 	}
 
-	if !changed {
+	// The pool of a moved node was enqueued already.
+	if !changed || moved {
 		return
 	}
 
-	ctrl.enqueueMachineConfigPool(pool)
+	ctrl.enqueueForNodeEvent(pool)
+}
+
+// enqueuePoolsForMovedNode enqueues the pools of a node before and after a change of its
+// labels, and returns true, if the change moved the node from one pool to another.
+func (ctrl *Controller) enqueuePoolsForMovedNode(oldNode, curNode *corev1.Node) bool {
+	oldPool, err := ctrl.getPoolForNode(oldNode)
+	if err != nil {
+		glog.Errorf("error finding pool for node: %v", err)
+		return false
+	}
+	curPool, err := ctrl.getPoolForNode(curNode)
+	if err != nil {
+		glog.Errorf("error finding pool for node: %v", err)
+		return false
+	}
+	if oldPool == nil && curPool == nil || oldPool != nil && curPool != nil && oldPool.Name == curPool.Name {
+		return false
+	}
+	for _, pool := range []*mcfgv1.MachineConfigPool{oldPool, curPool} {
+		if pool != nil {
+			glog.V(4).Infof("Node %s moved, enqueueing pool %s", curNode.Name, pool.Name)
+			ctrl.enqueueForNodeEvent(pool)
+		}
+	}
+	return true
 }
 
 func (ctrl *Controller) deleteNode(obj interface{}) {
@@ -265,7 +812,23 @@ func (ctrl *Controller) deleteNode(obj interface{}) {
 		return
 	}
 	glog.V(4).Infof("Node %s delete", node.Name)
-	ctrl.enqueueMachineConfigPool(pool)
+	ctrl.updateDurations.forget(node.Name)
+	ctrl.desiredConfigs.forget(node.Name)
+	ctrl.failures.forget(node.Name)
+	ctrl.completions.forget(node.Name)
+	ctrl.drains.forget(node.Name)
+	ctrl.verifications.forget(node.Name)
+	ctrl.conflicts.forget(node.Name)
+	if ctrl.globalBudget != nil {
+		ctrl.globalBudget.release(node.Name)
+	}
+	// A node deleted while updating, e.g. by a scale down, frees up capacity right away.
+	if desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+		glog.Infof("Pool %s: node %s was deleted while updating to %s", pool.Name, node.Name, desired)
+		ctrl.enqueue(pool)
+		return
+	}
+	ctrl.enqueueForNodeEvent(pool)
 }
 
 // getPoolForNode chooses the MachineConfigPool that should be used for a given node.
@@ -279,13 +842,13 @@ func (ctrl *Controller) getPoolForNode(node *corev1.Node) (*mcfgv1.MachineConfig
 
 	var pools []*mcfgv1.MachineConfigPool
 	for _, p := range pl {
-		selector, err := metav1.LabelSelectorAsSelector(p.Spec.NodeSelector)
+		selector, err := newPoolNodeSelector(p)
 		if err != nil {
-			return nil, fmt.Errorf("invalid label selector: %v", err)
+			return nil, err
 		}
 
 		// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
-		if selector.Empty() || !selector.Matches(labels.Set(node.Labels)) {
+		if !selector.Matches(labels.Set(node.Labels)) {
 			continue
 		}
 
@@ -296,7 +859,11 @@ func (ctrl *Controller) getPoolForNode(node *corev1.Node) (*mcfgv1.MachineConfig
 		// This is not an error, as there might be nodes in cluster that are not managed by machineconfigpool.
 		return nil, nil
 	}
+	return choosePool(node.Name, pools)
+}
 
+// choosePool picks the pool of the named node among the pools selecting it.
+func choosePool(nodeName string, pools []*mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfigPool, error) {
 	var master, worker *mcfgv1.MachineConfigPool
 	var custom []*mcfgv1.MachineConfigPool
 	for _, pool := range pools {
@@ -310,11 +877,11 @@ func (ctrl *Controller) getPoolForNode(node *corev1.Node) (*mcfgv1.MachineConfig
 	}
 
 	if len(custom) > 1 {
-		return nil, fmt.Errorf("node %s belongs to %d custom roles, cannot proceed with this Node", node.Name, len(custom))
+		return nil, fmt.Errorf("node %s belongs to %d custom roles, cannot proceed with this Node", nodeName, len(custom))
 	} else if len(custom) == 1 {
 		// We don't support making custom pools for masters
 		if master != nil {
-			return nil, fmt.Errorf("node %s has both master role and custom role %s", node.Name, custom[0].Name)
+			return nil, fmt.Errorf("node %s has both master role and custom role %s", nodeName, custom[0].Name)
 		}
 		// One custom role, let's use its pool
 		return custom[0], nil
@@ -329,35 +896,39 @@ func (ctrl *Controller) getPoolForNode(node *corev1.Node) (*mcfgv1.MachineConfig
 	return worker, nil
 }
 
+// poolKey returns the queue key of pool. Pools are cluster-scoped, so the key is just
+// the name, even if a stray namespace is set on the object.
+func poolKey(pool *mcfgv1.MachineConfigPool) string {
+	return pool.Name
+}
+
 func (ctrl *Controller) enqueue(pool *mcfgv1.MachineConfigPool) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(pool)
-	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", pool, err))
+	if ctrl.startupDelay(time.Now()) > 0 {
+		ctrl.enqueueAfter(pool, 0)
 		return
 	}
-
-	ctrl.queue.Add(key)
+	ctrl.queue.Add(poolKey(pool))
 }
 
 func (ctrl *Controller) enqueueRateLimited(pool *mcfgv1.MachineConfigPool) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(pool)
-	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", pool, err))
-		return
-	}
-
-	ctrl.queue.AddRateLimited(key)
+	ctrl.queue.AddRateLimited(poolKey(pool))
 }
 
-// enqueueAfter will enqueue a pool after the provided amount of time.
+// enqueueAfter will enqueue a pool after the provided amount of time, or once the
+// startup grace period is over if that is later.
 func (ctrl *Controller) enqueueAfter(pool *mcfgv1.MachineConfigPool, after time.Duration) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(pool)
-	if err != nil {
-		utilruntime.HandleError(fmt.Errorf("Couldn't get key for object %#v: %v", pool, err))
-		return
+	if delay := ctrl.startupDelay(time.Now()); delay > after {
+		glog.V(4).Infof("Delaying sync of MachineConfigPool %s by %v during startup", pool.Name, delay)
+		after = delay
 	}
+	ctrl.queue.AddAfter(poolKey(pool), after)
+}
 
-	ctrl.queue.AddAfter(key, after)
+// enqueueForNodeEvent enqueues pool for a change of one of its nodes. The status
+// recomputation of such a sync may be debounced, see WithStatusDebounce.
+func (ctrl *Controller) enqueueForNodeEvent(pool *mcfgv1.MachineConfigPool) {
+	ctrl.statusRecomputes.nodeEvent(pool.Name)
+	ctrl.enqueueMachineConfigPool(pool)
 }
 
 // enqueueDefault calls a default enqueue function
@@ -379,7 +950,28 @@ func (ctrl *Controller) processNextWorkItem() bool {
 	}
 	defer ctrl.queue.Done(key)
 
+	if atomic.LoadInt32(&ctrl.draining) == 1 {
+		return false
+	}
+
+	if ctrl.fairness != nil && ctrl.queue.Len() > 0 {
+		if after := ctrl.fairness.deferral(key.(string)); after > 0 {
+			glog.V(4).Infof("Deferring machineconfigpool %q for %v, it used its worker time budget", key, after)
+			ctrl.queue.AddAfter(key, after)
+			return true
+		}
+	}
+
+	done := ctrl.patchLatency.throttle()
+	startTime := time.Now()
 	err := ctrl.syncHandler(key.(string))
+	done()
+	if ctrl.fairness != nil {
+		ctrl.fairness.record(key.(string), time.Since(startTime))
+	}
+	if err == nil {
+		ctrl.recordSuccessfulSync(time.Now())
+	}
 	ctrl.handleErr(err, key)
 
 	return true
@@ -403,29 +995,45 @@ func (ctrl *Controller) handleErr(err error, key interface{}) {
 	ctrl.queue.AddAfter(key, 1*time.Minute)
 }
 
+// SyncPool syncs the named pool once, synchronously, with the same logic the workers
+// run for queued pools. It is meant for tests and tools that drive a single reconcile,
+// e.g. to assert on the resulting node updates, and must not be called while the
+// controller is running, as it bypasses the queue that serializes syncs of a pool.
+func (ctrl *Controller) SyncPool(name string) error {
+	return ctrl.syncMachineConfigPool(name)
+}
+
 // syncMachineConfigPool will sync the machineconfig pool with the given key.
 // This function is not meant to be invoked concurrently with the same key.
-func (ctrl *Controller) syncMachineConfigPool(key string) error {
+func (ctrl *Controller) syncMachineConfigPool(key string) (err error) {
 	startTime := time.Now()
 	glog.V(4).Infof("Started syncing machineconfigpool %q (%v)", key, startTime)
+	timings := syncTimings{}
 	defer func() {
-		glog.V(4).Infof("Finished syncing machineconfigpool %q (%v)", key, time.Since(startTime))
+		ctrl.observeSyncTimings(key, time.Since(startTime), timings)
+	}()
+	span := ctrl.tracer.StartSpan("syncMachineConfigPool")
+	span.SetStringAttribute("pool", key)
+	defer func() {
+		span.SetError(err)
+		span.End()
 	}()
 
-	_, name, err := cache.SplitMetaNamespaceKey(key)
-	if err != nil {
-		return err
-	}
+	// Keys are pool names, see poolKey.
+	name := key
 	machineconfigpool, err := ctrl.mcpLister.Get(name)
 	if errors.IsNotFound(err) {
 		glog.V(2).Infof("MachineConfigPool %v has been deleted", key)
+		if ctrl.globalBudget != nil {
+			ctrl.globalBudget.setDemand(name, 0, 0)
+		}
 		return nil
 	}
 	if err != nil {
 		return err
 	}
 
-	if machineconfigpool.Spec.Configuration.Name == "" {
+	if machineconfigpool.Spec.Configuration.Name == "" && machineconfigpool.Spec.ConfigurationSelector == nil {
 		// Previously we spammed the logs about empty pools.
 		// Let's just pause for a bit here to let the renderer
 		// initialize them.
@@ -437,48 +1045,363 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 	// Deep-copy otherwise we are mutating our cache.
 	// TODO: Deep-copy only when needed.
 	pool := machineconfigpool.DeepCopy()
-	everything := metav1.LabelSelector{}
+	// Only the candidates this sync selects hold back pools of a lower rollout priority.
+	waiting := 0
+	if ctrl.globalBudget != nil {
+		defer func() {
+			ctrl.globalBudget.setDemand(pool.Name, pool.Spec.RolloutPriority, waiting)
+		}()
+	}
+	syncStatus := func() error {
+		defer timings.since(syncPhaseSyncStatus, time.Now())
+		return ctrl.syncStatusOnly(pool)
+	}
 
-	if reflect.DeepEqual(pool.Spec.NodeSelector, &everything) {
-		ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "SelectingAll", "This machineconfigpool is selecting all nodes. A non-empty selector is required.")
+	if errs := ValidatePool(pool); len(errs) > 0 {
+		glog.Warningf("Pool %s is invalid: %v", pool.Name, errs.ToAggregate())
+		ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "InvalidPool", "This machineconfigpool is invalid: %v", errs.ToAggregate())
 		return nil
 	}
+	ctrl.applyDefaults(pool)
 
+	// Report the outcome of this sync in the SyncDegraded condition of the pool.
+	setSyncDegradedCondition(&pool.Status, nil)
+	defer func() {
+		if err == nil {
+			return
+		}
+		setSyncDegradedCondition(&pool.Status, err)
+		if serr := syncStatus(); serr != nil {
+			glog.Warningf("Pool %s: failed to report sync failure: %v", pool.Name, serr)
+		}
+	}()
+
+	useResolvedConfig(pool)
 	if pool.DeletionTimestamp != nil {
-		return ctrl.syncStatusOnly(pool)
+		return syncStatus()
 	}
 
+	ctrl.checkPaused(pool, time.Now())
 	if pool.Spec.Paused {
-		return ctrl.syncStatusOnly(pool)
+		return syncStatus()
+	}
+
+	if err := ctrl.resolveTargetConfig(pool); err != nil {
+		return err
+	}
+
+	if err := ctrl.checkTargetConfig(pool); err != nil {
+		return err
+	}
+
+	selector, err := newPoolNodeSelector(pool)
+	if err != nil {
+		return newSyncError(syncErrorInvalidNodeSelector, err)
+	}
+	listStart := time.Now()
+	nodes, err := ctrl.getNodesForPool(pool)
+	timings.since(syncPhaseListNodes, listStart)
+	if err != nil {
+		return newSyncError(syncErrorListNodes, err)
+	}
+	ctrl.checkEmptyPool(pool, selector, nodes)
+
+	// While a rollback is requested, nodes are moved to the rollback config instead.
+	target := rollbackTarget(pool)
+	setRollbackCondition(pool, nodes)
+
+	if err := ctrl.propagateAnnotations(pool, nodes); err != nil {
+		glog.Warningf("Pool %s: failed to propagate annotations to its nodes: %v", pool.Name, err)
+	}
+
+	if err := ctrl.releaseSelectCordons(nodes); err != nil {
+		glog.Warningf("Pool %s: failed to uncordon nodes cordoned for an update they didn't get: %v", pool.Name, err)
+	}
+
+	if err := ctrl.handleVerifications(target, nodes); err != nil {
+		glog.Warningf("Pool %s: failed to update the verification of nodes: %v", pool.Name, err)
 	}
 
-	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err := ctrl.resetOrphanedDesiredConfigs(target, nodes); err != nil {
+		return err
+	}
+	held, err := ctrl.handleManualDesiredConfigEdits(target, nodes)
+	if err != nil {
+		return newSyncError(syncErrorSetDesiredConfig, err)
+	}
+	unresolved, err := ctrl.getUnresolvedNodes(nodes)
+	if err != nil {
+		return newSyncError(syncErrorPoolLookup, err)
+	}
+	held = held.Union(unresolved).Union(ctrl.getScaleDownNodes(nodes))
+	maintenance, err := ctrl.getMaintenanceNodes(pool, nodes)
 	if err != nil {
-		return fmt.Errorf("invalid label selector: %v", err)
+		return newSyncError(syncErrorNodeMaintenance, err)
 	}
-	nodes, err := ctrl.nodeLister.List(selector)
+	held = held.Union(maintenance)
+	urgent, err := ctrl.applyUrgentConfig(pool, nodes)
 	if err != nil {
 		return err
 	}
+	coolingDown, cooledDownIn := ctrl.getCoolingDownNodes(nodes)
+	if coolingDown.Len() > 0 {
+		glog.V(2).Infof("Pool %s: nodes %v recently completed an update and are cooling down", pool.Name, coolingDown.List())
+		held = held.Union(coolingDown)
+		ctrl.enqueueAfter(pool, cooledDownIn)
+	}
+	drained, draining := ctrl.handleDrains(target, nodes)
+	for _, node := range drained {
+		err := ctrl.setDesiredMachineConfig(node.Name, nextConfig(target, node), target.Spec.CordonOnSelect)
+		ctrl.recordDesiredConfigResult(pool, node, err)
+		if err != nil {
+			return newSyncError(syncErrorSetDesiredConfig, err)
+		}
+		ctrl.handOverDrainCordon(pool, node.Name)
+		ctrl.drains.forget(node.Name)
+		ctrl.nodeEventf(node, v1.EventTypeNormal, "SetDesiredConfig", "Pool %s set the desired config of the node to %s", pool.Name, nextConfig(target, node))
+		draining.Insert(node.Name)
+	}
+	held = held.Union(draining)
+	nodes, err = ctrl.updateQuarantine(target, nodes)
+	if err != nil {
+		return newSyncError(syncErrorQuarantine, err)
+	}
+	checkReady := ctrl.poolReadyChecker(target, nodes)
 
-	maxunavail, err := maxUnavailable(pool, nodes)
+	limits, err := resolveMaxUnavailable(pool, nodes)
+	if err != nil {
+		return newSyncError(syncErrorInvalidMaxUnavailable, err)
+	}
+	ctrl.auditQuorumClamp(pool, limits)
+	ctrl.checkMaxUnavailableSize(pool, limits, len(nodes))
+	maxunavail := limits.effective
+	if pool.Spec.PerNodeSoak != nil {
+		maxunavail = 1
+		ready := int32(len(getReadyMachines(pool.Spec.Configuration.Name, nodes, checkReady)))
+		if remaining := soakRemaining(pool, ready, time.Now()); remaining > 0 {
+			glog.Infof("Pool %s: soaking the latest node update, next node update in %v", pool.Name, remaining)
+			ctrl.enqueueAfter(pool, remaining)
+			return syncStatus()
+		}
+	}
+	degraded, err := checkMaxDegraded(target, nodes)
+	if err != nil {
+		return newSyncError(syncErrorInvalidMaxDegraded, err)
+	}
+	if degraded.reached {
+		if ctrl.poolEvents.changed(pool.Name, "MaxDegradedReached", fmt.Sprintf("%s/%d", target.Spec.Configuration.Name, degraded.failing)) {
+			glog.Warningf("Pool %s: %d nodes are failing to apply %s, halting the rollout", pool.Name, degraded.failing, target.Spec.Configuration.Name)
+			ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "MaxDegradedReached", "%d nodes are failing to apply %s, reaching maxDegraded %d; halting the rollout", degraded.failing, target.Spec.Configuration.Name, degraded.limit)
+		}
+		return syncStatus()
+	}
+	ctrl.poolEvents.clear(pool.Name, "MaxDegradedReached")
+	if allNodesFailing(target, nodes) {
+		delay, stepped := ctrl.failingBackoffs.wait(pool.Name, time.Now())
+		if stepped {
+			glog.Warningf("Pool %s: all %d nodes are failing to apply %s, backing off for %v", pool.Name, len(nodes), target.Spec.Configuration.Name, delay)
+			ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "AllNodesFailing", "All %d nodes are failing to apply %s; backing off for %v until one of them recovers", len(nodes), target.Spec.Configuration.Name, delay)
+		}
+		ctrl.enqueueAfter(pool, delay)
+		return syncStatus()
+	}
+	if ctrl.failingBackoffs.forget(pool.Name) {
+		glog.Infof("Pool %s: nodes are no longer all failing to apply %s, resuming", pool.Name, target.Spec.Configuration.Name)
+	}
+	if override, ok := pool.Annotations[maxUnavailableOverrideAnnotationKey]; !ok {
+		ctrl.poolEvents.clear(pool.Name, "MaxUnavailableOverridden")
+	} else if ctrl.poolEvents.changed(pool.Name, "MaxUnavailableOverridden", override) {
+		ctrl.eventRecorder.Eventf(pool, v1.EventTypeNormal, "MaxUnavailableOverridden", "maxUnavailable is overridden to %s by the %s annotation, updating up to %d nodes at once", override, maxUnavailableOverrideAnnotationKey, maxunavail)
+	}
+
+	for _, node := range nodes {
+		if pinned, ok := getPinnedConfig(node); !ok {
+			ctrl.poolEvents.clear(pool.Name, "NodePinned/"+node.Name)
+		} else if ctrl.poolEvents.changed(pool.Name, "NodePinned/"+node.Name, pinned+"/"+target.Spec.Configuration.Name) {
+			ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "NodePinned", "Node %s is pinned to %s and will not be updated to %s", node.Name, pinned, target.Spec.Configuration.Name)
+		}
+	}
+
+	allowed, reason, err := ctrl.rolloutGate.Allowed()
+	if err != nil {
+		return newSyncError(syncErrorRolloutGate, fmt.Errorf("error consulting rollout gate: %v", err))
+	}
+	if !allowed {
+		glog.Infof("Pool %s: rollout held back by gate: %s", pool.Name, reason)
+		sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionTrue, "GateClosed", reason)
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sgated)
+		ctrl.enqueueAfter(pool, rolloutGateRecheckDelay)
+		return syncStatus()
+	}
+	if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRolloutGated) {
+		sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sgated)
+	}
+	reason, message, err := ctrl.checkBlockingJob(pool)
+	if err != nil {
+		return newSyncError(syncErrorBlockingJob, err)
+	}
+	if reason != "" {
+		glog.Infof("Pool %s: rollout held back by its blocking Job: %s", pool.Name, message)
+		sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolJobGated, corev1.ConditionTrue, reason, message)
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sgated)
+		ctrl.enqueueAfter(pool, rolloutGateRecheckDelay)
+		return syncStatus()
+	}
+	if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolJobGated) {
+		sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolJobGated, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sgated)
+	}
+	if remaining := ctrl.targetConfigDebounceRemaining(pool, time.Now()); remaining > 0 {
+		glog.Infof("Pool %s: target config %s changed recently, rolling it out in %v unless it is reverted", pool.Name, pool.Spec.Configuration.Name, remaining)
+		ctrl.enqueueAfter(pool, remaining)
+		return syncStatus()
+	}
+	if ctrl.checkApproval(pool, nodes) {
+		return syncStatus()
+	}
+	if pool.Spec.TargetConfigDebounce != nil {
+		pool.Status.LastActedConfig = pool.Spec.Configuration.Name
+	}
+	validating, shadowEligible, err := ctrl.checkShadowValidation(pool, nodes, checkReady, time.Now())
 	if err != nil {
 		return err
 	}
+	if validating {
+		return syncStatus()
+	}
 
-	candidates := getCandidateMachines(pool, nodes, maxunavail)
-	for _, node := range candidates {
-		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, pool.Spec.Configuration.Name); err != nil {
-			return err
+	span.SetIntAttribute("maxUnavailable", int64(maxunavail))
+	selectStart := time.Now()
+	podCounts := ctrl.getPodCounts(target, nodes)
+	regular := withoutUrgent(nodes, urgent)
+	candidates := selectCandidateMachines(target, regular, maxunavail, checkReady, ctrl.masterOrdering, held, podCounts, shadowEligible)
+	if shadowEligible != nil {
+		candidates = ctrl.pickShadowNode(pool, candidates)
+	}
+	candidates, err = ctrl.runPreUpdateHooks(target, candidates)
+	if err != nil {
+		return newSyncError(syncErrorSetDesiredConfig, err)
+	}
+	if ctrl.globalBudget != nil && len(candidates) > 0 {
+		allNodes, err := ctrl.nodeLister.List(labels.Everything())
+		if err != nil {
+			return newSyncError(syncErrorListNodes, err)
 		}
+		ahead := ctrl.globalBudget.higherPriorityDemand(pool.Spec.RolloutPriority)
+		reserved := ctrl.globalBudget.reserve(allNodes, candidates, ahead, func(node *corev1.Node) string {
+			return nextConfig(target, node)
+		})
+		if len(reserved) < len(candidates) {
+			glog.Infof("Pool %s: updating %d of %d candidates to stay within the global maxUnavailable %d", pool.Name, len(reserved), len(candidates), ctrl.globalBudget.max)
+			if ahead > 0 {
+				glog.Infof("Pool %s: leaving %d update slots to pools of a higher rollout priority", pool.Name, ahead)
+			}
+			waiting = len(candidates) - len(reserved)
+			ctrl.enqueueAfter(pool, preemptedRecheckDelay)
+		}
+		candidates = reserved
+	}
+	span.SetIntAttribute("candidates", int64(len(candidates)))
+	timings.since(syncPhaseSelectCandidates, selectStart)
+	if ctrl.candidateSummary {
+		summary := summarizeCandidates(target, nodes, maxunavail, candidates, checkReady)
+		if err := ctrl.writeCandidateSummary(pool, summary); err != nil {
+			glog.Warningf("Pool %s: failed to write the candidate summary: %v", pool.Name, err)
+		}
+	}
+	patchStart := time.Now()
+	for i, node := range candidates {
+		if i > 0 && ctrl.candidateJitter > 0 {
+			time.Sleep(wait.Jitter(ctrl.candidateJitter, 1.0))
+		}
+		if needsInitialConfig(node) {
+			glog.Infof("Pool %s: node %s has never been managed, giving it its initial config", pool.Name, node.Name)
+		}
+		if target.Spec.DrainPolicy != nil {
+			ctrl.startDrain(target, node, nextConfig(target, node))
+			continue
+		}
+		patchSpan := span.StartChild("setDesiredMachineConfigAnnotation")
+		patchSpan.SetStringAttribute("node", node.Name)
+		err := ctrl.setDesiredMachineConfig(node.Name, nextConfig(target, node), target.Spec.CordonOnSelect)
+		patchSpan.SetError(err)
+		patchSpan.End()
+		ctrl.recordDesiredConfigResult(pool, node, err)
+		if err != nil {
+			if ctrl.globalBudget != nil {
+				for _, n := range candidates[i:] {
+					ctrl.globalBudget.release(n.Name)
+				}
+			}
+			return newSyncError(syncErrorSetDesiredConfig, err)
+		}
+		ctrl.nodeEventf(node, v1.EventTypeNormal, "SetDesiredConfig", "Pool %s set the desired config of the node to %s", pool.Name, nextConfig(target, node))
 	}
-	return ctrl.syncStatusOnly(pool)
+	timings.since(syncPhasePatchNodes, patchStart)
+	ctrl.updatePrePullAnnotations(pool, target, nodes, getLookaheadMachines(target, regular, candidates, ctrl.prePullLookahead, checkReady, ctrl.masterOrdering, held, podCounts))
+	return syncStatus()
+}
+
+// checkTargetConfig returns an error, which degrades pool, if the config its nodes are
+// moved to, i.e. its rollback config during a rollback, doesn't exist, so that no node is
+// picked to update to it. The pool is synced again as for any other sync failure, and an
+// event is recorded about it the first time.
+func (ctrl *Controller) checkTargetConfig(pool *mcfgv1.MachineConfigPool) error {
+	config := rollbackTarget(pool).Spec.Configuration.Name
+	_, err := ctrl.mcLister.Get(config)
+	if errors.IsNotFound(err) {
+		if ctrl.poolEvents.changed(pool.Name, "MachineConfigNotFound", config) {
+			ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "MachineConfigNotFound", "Target config %s doesn't exist, not updating any node", config)
+		}
+		return newSyncError(syncErrorMachineConfigNotFound, fmt.Errorf("target config %s not found", config))
+	}
+	if err == nil {
+		ctrl.poolEvents.clear(pool.Name, "MachineConfigNotFound")
+	}
+	return newSyncError(syncErrorMachineConfigLookup, err)
+}
+
+// resetOrphanedDesiredConfigs retargets nodes that are updating to a MachineConfig which
+// no longer exists, and on which the daemon would otherwise be stuck, to the pool's target.
+// These nodes already count as unavailable, so this doesn't start any additional updates.
+func (ctrl *Controller) resetOrphanedDesiredConfigs(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	for _, node := range nodes {
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		if desired == "" || desired == pool.Spec.Configuration.Name || desired == node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] {
+			continue
+		}
+		_, err := ctrl.mcLister.Get(desired)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return newSyncError(syncErrorMachineConfigLookup, err)
+		}
+		ctrl.eventRecorder.Eventf(pool, v1.EventTypeWarning, "OrphanedDesiredConfig", "Node %s desired config %s no longer exists, resetting it to %s", node.Name, desired, pool.Spec.Configuration.Name)
+		err = ctrl.setDesiredMachineConfigAnnotation(node.Name, pool.Spec.Configuration.Name)
+		ctrl.recordDesiredConfigResult(pool, node, err)
+		if err != nil {
+			return newSyncError(syncErrorSetDesiredConfig, err)
+		}
+	}
+	return nil
 }
 
 func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfig string) error {
+	return ctrl.setDesiredMachineConfig(nodeName, currentConfig, false)
+}
+
+// setDesiredMachineConfig sets the desired config of the named node, and with cordon also
+// cordons it in the same write.
+func (ctrl *Controller) setDesiredMachineConfig(nodeName, currentConfig string, cordon bool) error {
 	glog.Infof("Setting node %s to desired config %s", nodeName, currentConfig)
-	return clientretry.RetryOnConflict(nodeUpdateBackoff, func() error {
+	return ctrl.retryNodeUpdate(func() error {
 		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			glog.Infof("Node %s was deleted, not setting desired config %s", nodeName, currentConfig)
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -487,19 +1410,117 @@ func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfi
 			return err
 		}
 
+		if pinned, ok := getPinnedConfig(oldNode); ok {
+			glog.Infof("Node %s is pinned to %s, not setting desired config %s", nodeName, pinned, currentConfig)
+			return nil
+		}
+
 		newNode := oldNode.DeepCopy()
 		if newNode.Annotations == nil {
 			newNode.Annotations = map[string]string{}
 		}
 		if newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == currentConfig {
+			ctrl.desiredConfigs.record(oldNode, currentConfig)
+			return nil
+		}
+		if ctrl.applyNode != nil {
+			patchStart := time.Now()
+			err := ctrl.applyDesiredMachineConfigAnnotation(oldNode, currentConfig, cordon)
+			ctrl.patchLatency.observe(time.Since(patchStart), time.Now())
+			if err != nil {
+				return err
+			}
+			ctrl.desiredConfigs.record(oldNode, currentConfig)
+			ctrl.auditDesiredConfig(oldNode, currentConfig)
 			return nil
 		}
 		newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = currentConfig
+		ctrl.signalSkipDrain(oldNode, newNode.Annotations, currentConfig)
+		if cordon {
+			cordonOnSelect(newNode, currentConfig)
+		}
 		newData, err := json.Marshal(newNode)
 		if err != nil {
 			return err
 		}
 
+		patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, v1.Node{})
+		if err != nil {
+			return fmt.Errorf("failed to create patch for node %q: %v", nodeName, err)
+		}
+		patchStart := time.Now()
+		_, err = ctrl.kubeClient.CoreV1().Nodes().Patch(nodeName, types.StrategicMergePatchType, patchBytes)
+		ctrl.patchLatency.observe(time.Since(patchStart), time.Now())
+		if err != nil {
+			return err
+		}
+		ctrl.desiredConfigs.record(oldNode, currentConfig)
+		ctrl.auditDesiredConfig(oldNode, currentConfig)
+		return nil
+	})
+}
+
+// retryNodeUpdate runs update, retrying it with the node update backoff while it
+// conflicts with other writers. Conflicts with other field managers aren't retried.
+func (ctrl *Controller) retryNodeUpdate(update func() error) error {
+	var ownership error
+	err := clientretry.RetryOnConflict(ctrl.nodeUpdateBackoff, func() error {
+		err := update()
+		if _, ok := err.(*fieldManagerConflictError); ok {
+			// Another field manager owns the fields, retrying won't help.
+			ownership = err
+			return nil
+		}
+		return err
+	})
+	if ownership != nil {
+		return ownership
+	}
+	if errors.IsConflict(err) {
+		nodeUpdateRetriesExhausted.Add(1)
+	}
+	return err
+}
+
+// setNodeAnnotation sets an annotation of a node to value, or removes it if value is empty.
+func (ctrl *Controller) setNodeAnnotation(nodeName, key, value string) error {
+	return ctrl.updateNodeAnnotations(nodeName, func(annotations map[string]string) {
+		if value == "" {
+			delete(annotations, key)
+		} else {
+			annotations[key] = value
+		}
+	})
+}
+
+// updateNodeAnnotations patches the annotations of a node as changed by update, which is
+// given a copy of the latest annotations on every attempt. Nothing is patched if update
+// leaves them as they were.
+func (ctrl *Controller) updateNodeAnnotations(nodeName string, update func(annotations map[string]string)) error {
+	return ctrl.retryNodeUpdate(func() error {
+		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		newNode := oldNode.DeepCopy()
+		if newNode.Annotations == nil {
+			newNode.Annotations = map[string]string{}
+		}
+		update(newNode.Annotations)
+		if len(oldNode.Annotations) == 0 && len(newNode.Annotations) == 0 || reflect.DeepEqual(oldNode.Annotations, newNode.Annotations) {
+			return nil
+		}
+		oldData, err := json.Marshal(oldNode)
+		if err != nil {
+			return err
+		}
+		newData, err := json.Marshal(newNode)
+		if err != nil {
+			return err
+		}
 		patchBytes, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, v1.Node{})
 		if err != nil {
 			return fmt.Errorf("failed to create patch for node %q: %v", nodeName, err)
@@ -509,25 +1530,66 @@ func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfi
 	})
 }
 
-func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int) []*corev1.Node {
+// getCandidateMachines returns the nodes of the pool to update next. Nodes named in held
+// are left at their desired config and count as unavailable, like pinned nodes. If the
+// pool prefers its least loaded nodes, those with the fewest pods in podCounts go first.
+func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int, checkReady NodeReadyChecker, masterOrdering MasterOrdering, held sets.String, podCounts map[string]int) []*corev1.Node {
+	return selectCandidateMachines(pool, nodesInPool, maxUnavailable, checkReady, masterOrdering, held, podCounts, nil)
+}
+
+// selectCandidateMachines is getCandidateMachines only picking nodes for which eligible,
+// if set, returns true. The other nodes are passed over without taking a slot.
+func selectCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int, checkReady NodeReadyChecker, masterOrdering MasterOrdering, held sets.String, podCounts map[string]int, eligible func(*corev1.Node) bool) []*corev1.Node {
 	targetConfig := pool.Spec.Configuration.Name
+	nodesInPool = withoutPaused(withoutQuarantined(withoutIgnoredTaints(pool, nodesInPool)))
 
-	unavail := getUnavailableMachines(nodesInPool)
+	unavail := getUnavailableMachines(nodesInPool, pool.Spec.UnavailabilityPolicy, checkReady)
+	// Pinned and held nodes are unavailable by choice, and so are cordoned ones if the pool skips them.
+	for _, node := range nodesInPool {
+		if (isNodeSkipped(pool, node) || held.Has(node.Name)) && !IsNodeUnavailable(node, pool.Spec.UnavailabilityPolicy, checkReady) ||
+			isNodeCordonedUnavailable(pool, node, checkReady) || isPostUpdateHookUnavailable(pool, node, checkReady) || isVerificationUnavailable(pool, node, checkReady) {
+			unavail = append(unavail, node)
+		}
+	}
 	// If we're at capacity, there's nothing to do.
 	if len(unavail) >= maxUnavailable {
 		return nil
 	}
 	capacity := maxUnavailable - len(unavail)
+	// Leave the nodes the pool retains on their previous config alone.
+	if retained := getPreviousConfigMachines(pool, nodesInPool) - int(pool.Spec.RetainPreviousConfig); retained < capacity {
+		if retained <= 0 {
+			return nil
+		}
+		capacity = retained
+	}
+	staggerGroup := getStaggerGroup(pool, nodesInPool)
 	failingThisConfig := 0
 	// We only look at nodes which aren't already targeting our desired config
 	var nodes []*corev1.Node
 	for _, node := range nodesInPool {
+		if isNodeSkipped(pool, node) || held.Has(node.Name) {
+			continue
+		}
 		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
 			if isNodeMCDFailing(node) {
 				failingThisConfig++
 			}
 			continue
 		}
+		if isNodeInSequenceStep(pool, node) {
+			continue
+		}
+		// Nodes under pressure wait for it to clear, without holding a slot.
+		if isNodeUnderPressure(pool, node) {
+			continue
+		}
+		if pool.Spec.StaggerByLabel != "" && node.Labels[pool.Spec.StaggerByLabel] != staggerGroup {
+			continue
+		}
+		if eligible != nil && !eligible(node) {
+			continue
+		}
 
 		nodes = append(nodes, node)
 	}
@@ -540,34 +1602,226 @@ func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.
 	}
 	capacity -= failingThisConfig
 
-	if len(nodes) < capacity {
-		return nodes
+	// Break ties by name, so that the same nodes are picked whatever order the lister
+	// returned them in.
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	if pool.Spec.PreferLeastLoaded && podCounts != nil {
+		sort.SliceStable(nodes, func(i, j int) bool { return podCounts[nodes[i].Name] < podCounts[nodes[j].Name] })
+	}
+	if pool.Name == "master" && masterOrdering != nil {
+		nodes = masterOrdering(nodes)
 	}
-	return nodes[:capacity]
+	nodes = inFlightFirst(nodes)
+
+	// Updating a healthy node takes it out of service, which must leave the pool with
+	// at least its minimum of healthy nodes.
+	healthy := countHealthyMachines(nodesInPool, checkReady)
+	var candidates []*corev1.Node
+	for _, node := range nodes {
+		if len(candidates) == capacity {
+			break
+		}
+		if isNodeHealthy(node, checkReady) {
+			if healthy <= int(pool.Spec.MinHealthy) {
+				continue
+			}
+			healthy--
+		}
+		candidates = append(candidates, node)
+	}
+	return candidates
 }
 
-func maxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (int, error) {
-	intOrPercent := intstrutil.FromInt(1)
-	if pool.Spec.MaxUnavailable != nil {
-		intOrPercent = *pool.Spec.MaxUnavailable
+// isNodeHealthy returns whether node is ready and not updating.
+func isNodeHealthy(node *corev1.Node, checkReady NodeReadyChecker) bool {
+	return !IsNodeUnavailable(node, mcfgv1.UnavailabilityPolicyStrict, checkReady)
+}
+
+// countHealthyMachines returns the number of nodes that are ready and not updating.
+func countHealthyMachines(nodes []*corev1.Node, checkReady NodeReadyChecker) int {
+	healthy := 0
+	for _, node := range nodes {
+		if isNodeHealthy(node, checkReady) {
+			healthy++
+		}
 	}
-	maxunavail, err := intstrutil.GetValueFromIntOrPercent(&intOrPercent, len(nodes), false)
+	return healthy
+}
+
+// inFlightFirst moves the nodes that are already updating or running their pre-update
+// hook ahead of the others, keeping the order of nodes otherwise. Those nodes are
+// disrupted already, so they are retargeted before any new node update is started.
+func inFlightFirst(nodes []*corev1.Node) []*corev1.Node {
+	var inFlight, rest []*corev1.Node
+	for _, node := range nodes {
+		if isNodeUpdating(node) || isPreUpdateHookPending(node) {
+			inFlight = append(inFlight, node)
+		} else {
+			rest = append(rest, node)
+		}
+	}
+	return append(inFlight, rest...)
+}
+
+// isNodeUpdating returns whether the daemon of node is applying a config, as opposed to
+// being done or having failed.
+func isNodeUpdating(node *corev1.Node) bool {
+	if needsInitialConfig(node) || isNodeMCDFailing(node) {
+		return false
+	}
+	return node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+}
+
+// maxUnavailable returns the number of nodes of the pool that may be unavailable at once.
+func maxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (int, error) {
+	m, err := resolveMaxUnavailable(pool, nodes)
 	if err != nil {
 		return 0, err
 	}
+	return m.effective, nil
+}
+
+// resolveMaxUnavailable is calculateMaxUnavailable, logging and recording metrics about
+// the protections that applied.
+func resolveMaxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (maxUnavailableResult, error) {
+	m, err := calculateMaxUnavailable(pool, nodes)
+	if err != nil {
+		return m, err
+	}
+	if m.allAtOnceLimited {
+		glog.Warningf("Pool %s: maxUnavailable %d would update all %d nodes at once, using %d instead; set %s=true to allow it", pool.Name, m.requested, len(nodes), len(nodes)-1, allowAllAtOnceAnnotationKey)
+	}
+	if m.quorumClamped {
+		glog.Warningf("Refusing to honor master pool maxUnavailable %d to prevent losing etcd quorum, using %d instead", m.requested, m.effective)
+		masterMaxUnavailableClamped.Add(1)
+	}
+	return m, nil
+}
+
+// checkMaxUnavailableSize records in the MaxUnavailableExceedsPoolSize condition of pool
+// whether m requested more nodes than the size of the pool, and warns about it when it
+// starts to. It doesn't change how many nodes are updated.
+func (ctrl *Controller) checkMaxUnavailableSize(pool *mcfgv1.MachineConfigPool, m maxUnavailableResult, size int) {
+	exceeds := mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize)
+	if !m.exceedsPoolSize {
+		if exceeds {
+			sexceeds := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sexceeds)
+		}
+		return
+	}
+	message := fmt.Sprintf("maxUnavailable %d is larger than the %d nodes of the pool", m.requested, size)
+	if !exceeds {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "MaxUnavailableExceedsPoolSize", "%s, check that it is intended", message)
+	}
+	sexceeds := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize, corev1.ConditionTrue, "MaxUnavailableTooLarge", message)
+	setConditionMessage(&pool.Status, sexceeds)
+}
+
+// maxUnavailableResult describes how the maxUnavailable of a pool was derived.
+type maxUnavailableResult struct {
+	// requested is the pool's maxUnavailable resolved against its size.
+	requested int
+	// overridden is set if requested comes from the max-unavailable-override annotation.
+	overridden bool
+	// allAtOnceLimited is set if requested covers the whole pool but the pool didn't opt in.
+	allAtOnceLimited bool
+	// quorumClamped is set if the master pool was limited to preserve etcd quorum.
+	quorumClamped bool
+	// exceedsPoolSize is set if requested is larger than the pool, likely a mistake.
+	exceedsPoolSize bool
+	// effective is the number of nodes that may actually be unavailable at once.
+	effective int
+}
+
+// calculateMaxUnavailable resolves the pool's maxUnavailable, or its override annotation, against its size and applies
+// the whole-pool and etcd quorum protections, without logging or recording metrics.
+func calculateMaxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (maxUnavailableResult, error) {
+	var maxunavail int
+	override, overridden := pool.Annotations[maxUnavailableOverrideAnnotationKey]
+	switch {
+	case overridden:
+		intOrPercent := intstrutil.Parse(override)
+		var err error
+		maxunavail, err = intstrutil.GetValueFromIntOrPercent(&intOrPercent, len(nodes), false)
+		if err != nil {
+			return maxUnavailableResult{}, fmt.Errorf("invalid %s annotation: %v", maxUnavailableOverrideAnnotationKey, err)
+		}
+	case pool.Spec.MaxUnavailableScaling == mcfgv1.MaxUnavailableScalingSqrt:
+		maxunavail = int(math.Sqrt(float64(len(nodes))))
+	case pool.Spec.MaxUnavailableScaling == mcfgv1.MaxUnavailableScalingLog2:
+		if len(nodes) > 0 {
+			maxunavail = int(math.Log2(float64(len(nodes))))
+		}
+	case pool.Spec.MaxUnavailableRamp != nil:
+		maxunavail = currentRampMaxUnavailable(pool)
+	default:
+		intOrPercent := intstrutil.FromInt(1)
+		if pool.Spec.MaxUnavailable != nil {
+			intOrPercent = *pool.Spec.MaxUnavailable
+		}
+		var err error
+		maxunavail, err = intstrutil.GetValueFromIntOrPercent(&intOrPercent, len(nodes), false)
+		if err != nil {
+			return maxUnavailableResult{}, err
+		}
+	}
 	if maxunavail == 0 {
 		maxunavail = 1
 	}
+	m := maxUnavailableResult{requested: maxunavail, overridden: overridden, effective: maxunavail}
+	m.exceedsPoolSize = len(nodes) > 0 && m.requested > len(nodes)
+	if len(nodes) > 1 && m.effective >= len(nodes) && pool.Annotations[allowAllAtOnceAnnotationKey] != "true" {
+		// Updating the whole pool at once is an outage; only do it when the pool explicitly opts in.
+		m.allAtOnceLimited = true
+		m.effective = len(nodes) - 1
+	}
 	if pool.Name == "master" {
 		// calculate the fault tolerance dynamically for the master pool
 		// to avoid risking losing etcd quorum.
 		tolerance := len(nodes) - ((len(nodes) / 2) + 1)
-		if maxunavail > tolerance {
-			glog.Warningf("Refusing to honor master pool maxUnavailable %d to prevent losing etcd quorum, using %d instead", maxunavail, tolerance)
-			return tolerance, nil
+		if m.effective > tolerance {
+			m.quorumClamped = true
+			m.effective = tolerance
 		}
 	}
-	return maxunavail, nil
+	return m, nil
+}
+
+// isNodeSkipped returns true if the node must not be selected for an update.
+func isNodeSkipped(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	if _, ok := getPinnedConfig(node); ok {
+		return true
+	}
+	return pool.Spec.SkipCordonedNodes && node.Spec.Unschedulable
+}
+
+// withoutIgnoredTaints returns the nodes not carrying any of the pool's ignored taints.
+func withoutIgnoredTaints(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []*corev1.Node {
+	if len(pool.Spec.IgnoreUnavailableTaints) == 0 {
+		return nodes
+	}
+	ignored := sets.NewString(pool.Spec.IgnoreUnavailableTaints...)
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		tainted := false
+		for _, taint := range node.Spec.Taints {
+			if ignored.Has(taint.Key) {
+				tainted = true
+				break
+			}
+		}
+		if !tainted {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// getPinnedConfig returns the MachineConfig a node was pinned to, if any.
+func getPinnedConfig(node *corev1.Node) (string, bool) {
+	pinned := node.Annotations[pinConfigAnnotationKey]
+	return pinned, pinned != ""
 }
 
 // getErrorString returns error string if not nil and empty string if error is nil
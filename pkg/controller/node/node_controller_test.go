@@ -3,7 +3,10 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -20,10 +24,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -39,6 +43,7 @@ type fixture struct {
 	kubeclient *k8sfake.Clientset
 
 	mcpLister  []*mcfgv1.MachineConfigPool
+	mcLister   []*mcfgv1.MachineConfig
 	nodeLister []*corev1.Node
 
 	kubeactions []core.Action
@@ -46,6 +51,12 @@ type fixture struct {
 
 	kubeobjects []runtime.Object
 	objects     []runtime.Object
+
+	opts []Option
+	// enqueue replaces the enqueue function of the controller before its informers start.
+	enqueue func(*mcfgv1.MachineConfigPool)
+
+	kubeinformers kubeinformers.SharedInformerFactory
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -77,12 +88,17 @@ func (f *fixture) newController() *Controller {
 
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
-	c := New(i.Machineconfiguration().V1().MachineConfigPools(), k8sI.Core().V1().Nodes(),
-		f.kubeclient, f.client)
+	f.kubeinformers = k8sI
+	c := New(i.Machineconfiguration().V1().MachineConfigPools(), i.Machineconfiguration().V1().MachineConfigs(), k8sI.Core().V1().Nodes(),
+		f.kubeclient, f.client, f.opts...)
 
 	c.mcpListerSynced = alwaysReady
+	c.mcListerSynced = alwaysReady
 	c.nodeListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
+	if f.enqueue != nil {
+		c.enqueueMachineConfigPool = f.enqueue
+	}
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
@@ -95,6 +111,10 @@ func (f *fixture) newController() *Controller {
 		i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(c)
 	}
 
+	for _, c := range f.mcLister {
+		i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(c)
+	}
+
 	for _, m := range f.nodeLister {
 		k8sI.Core().V1().Nodes().Informer().GetIndexer().Add(m)
 	}
@@ -102,6 +122,22 @@ func (f *fixture) newController() *Controller {
 	return c
 }
 
+// newControllerRecordingEnqueues is newController, but the controller records the names
+// of the pools it enqueues instead of queueing them. Pools must only be seeded through
+// mcpLister, the pool informer would otherwise deliver their Add events to the recorder.
+func (f *fixture) newControllerRecordingEnqueues() (*Controller, *[]string) {
+	for _, obj := range f.objects {
+		if _, ok := obj.(*mcfgv1.MachineConfigPool); ok {
+			f.t.Fatalf("pool %s is seeded through the informer", obj.(*mcfgv1.MachineConfigPool).Name)
+		}
+	}
+	var enqueued []string
+	f.enqueue = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+	return f.newController(), &enqueued
+}
+
 func (f *fixture) run(pool string) {
 	f.runController(pool, false)
 }
@@ -204,6 +240,8 @@ func filterInformerActions(actions []core.Action) []core.Action {
 		if len(action.GetNamespace()) == 0 &&
 			(action.Matches("list", "machineconfigpools") ||
 				action.Matches("watch", "machineconfigpools") ||
+				action.Matches("list", "machineconfigs") ||
+				action.Matches("watch", "machineconfigs") ||
 				action.Matches("list", "nodes") ||
 				action.Matches("watch", "nodes")) {
 			continue
@@ -330,8 +368,117 @@ func TestGetPoolForNode(t *testing.T) {
 	}
 }
 
+func TestUpdateMachineConfigPoolSelectorChange(t *testing.T) {
+	f := newFixture(t)
+	worker := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v0")
+	oldInfra := newMachineConfigPool("infra", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", ""), nil, "v0")
+	curInfra := oldInfra.DeepCopy()
+	curInfra.Spec.NodeSelector = metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", "gpu")
+
+	moved := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": "", "node-role/infra": ""})
+	unaffected := newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.nodeLister = append(f.nodeLister, moved, unaffected)
+	f.mcpLister = append(f.mcpLister, worker, curInfra)
+
+	c := f.newController()
+	var enqueued []string
+	c.enqueueMachineConfigPool = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+
+	c.updateMachineConfigPool(oldInfra, curInfra)
+	if expected := []string{"infra", "worker"}; !reflect.DeepEqual(enqueued, expected) {
+		t.Fatalf("mismatch enqueued pools: got %v want: %v", enqueued, expected)
+	}
+
+	enqueued = nil
+	c.updateMachineConfigPool(curInfra, curInfra.DeepCopy())
+	if expected := []string{"infra"}; !reflect.DeepEqual(enqueued, expected) {
+		t.Fatalf("mismatch enqueued pools: got %v want: %v", enqueued, expected)
+	}
+}
+
+func TestUpdateMachineConfigPoolForceSync(t *testing.T) {
+	f := newFixture(t)
+	oldPool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	f.mcpLister = append(f.mcpLister, oldPool)
+	c := f.newController()
+
+	// Without a nonce change the pool is only enqueued after updateDelay.
+	c.updateMachineConfigPool(oldPool, oldPool.DeepCopy())
+	if got := c.queue.Len(); got != 0 {
+		t.Fatalf("expected pool to be enqueued after a delay, got queue length %d", got)
+	}
+
+	curPool := oldPool.DeepCopy()
+	curPool.Annotations = map[string]string{forceSyncAnnotationKey: "1"}
+	c.updateMachineConfigPool(oldPool, curPool)
+	if got := c.queue.Len(); got != 1 {
+		t.Fatalf("expected pool to be enqueued immediately, got queue length %d", got)
+	}
+}
+
+func TestNodeReadyChecker(t *testing.T) {
+	f := newFixture(t)
+	pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	oldNode := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": ""})
+	curNode := oldNode.DeepCopy()
+	curNode.Labels["example.com/daemonset-ready"] = "true"
+	f.nodeLister = append(f.nodeLister, curNode)
+	f.mcpLister = append(f.mcpLister, pool)
+
+	daemonSetReady := func(node *corev1.Node) error {
+		if node.Labels["example.com/daemonset-ready"] != "true" {
+			return fmt.Errorf("node %s daemonset is not ready", node.Name)
+		}
+		return nil
+	}
+
+	c := f.newController()
+	WithNodeReadyChecker(daemonSetReady)(c)
+	var enqueued []string
+	c.enqueueMachineConfigPool = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+
+	c.updateNode(oldNode, curNode)
+	if expected := []string{"worker"}; !reflect.DeepEqual(enqueued, expected) {
+		t.Fatalf("mismatch enqueued pools: got %v want: %v", enqueued, expected)
+	}
+
+	if unavail := getUnavailableMachines([]*corev1.Node{oldNode, curNode}, "", c.nodeReadyChecker); len(unavail) != 1 || unavail[0] != oldNode {
+		t.Fatalf("expected only %s to be unavailable, got %v", oldNode.Name, unavail)
+	}
+}
+
+func newMachineConfig(name string) *mcfgv1.MachineConfig {
+	return &mcfgv1.MachineConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+}
+
 func intStrPtr(obj intstr.IntOrString) *intstr.IntOrString { return &obj }
 
+func newCordonedNode(name string, currentConfig, desiredConfig string) *corev1.Node {
+	node := newNodeWithReady(name, currentConfig, desiredConfig, corev1.ConditionTrue)
+	node.Spec.Unschedulable = true
+	return node
+}
+
+func withTaint(node *corev1.Node, key string) *corev1.Node {
+	node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: key, Effect: corev1.TaintEffectNoSchedule})
+	return node
+}
+
+func withAnnotation(node *corev1.Node, key, value string) *corev1.Node {
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[key] = value
+	return node
+}
+
 func newNodeSet(len int) []*corev1.Node {
 	nodes := []*corev1.Node{}
 	for i := 0; i < len; i++ {
@@ -342,11 +489,12 @@ func newNodeSet(len int) []*corev1.Node {
 
 func TestMaxUnavailable(t *testing.T) {
 	tests := []struct {
-		poolName   string
-		maxUnavail *intstr.IntOrString
-		nodes      []*corev1.Node
-		expected   int
-		err        bool
+		poolName    string
+		annotations map[string]string
+		maxUnavail  *intstr.IntOrString
+		nodes       []*corev1.Node
+		expected    int
+		err         bool
 	}{
 		{
 			maxUnavail: nil,
@@ -403,14 +551,75 @@ func TestMaxUnavailable(t *testing.T) {
 			nodes:      newNodeSet(7),
 			expected:   3,
 			err:        false,
+		}, {
+			maxUnavail: intStrPtr(intstr.FromString("100%")),
+			nodes:      newNodeSet(4),
+			expected:   3,
+			err:        false,
+		}, {
+			annotations: map[string]string{allowAllAtOnceAnnotationKey: "true"},
+			maxUnavail:  intStrPtr(intstr.FromString("100%")),
+			nodes:       newNodeSet(4),
+			expected:    4,
+			err:         false,
+		}, {
+			maxUnavail: intStrPtr(intstr.FromString("100%")),
+			nodes:      newNodeSet(1),
+			expected:   1,
+			err:        false,
+		}, {
+			maxUnavail: intStrPtr(intstr.FromString("100%")),
+			nodes:      newNodeSet(0),
+			expected:   1,
+			err:        false,
+		}, {
+			poolName:    "master",
+			annotations: map[string]string{allowAllAtOnceAnnotationKey: "true"},
+			maxUnavail:  intStrPtr(intstr.FromString("100%")),
+			nodes:       newNodeSet(3),
+			expected:    1,
+			err:         false,
+		}, {
+			annotations: map[string]string{maxUnavailableOverrideAnnotationKey: "1"},
+			maxUnavail:  intStrPtr(intstr.FromInt(3)),
+			nodes:       newNodeSet(10),
+			expected:    1,
+			err:         false,
+		}, {
+			annotations: map[string]string{maxUnavailableOverrideAnnotationKey: "0"},
+			maxUnavail:  intStrPtr(intstr.FromInt(3)),
+			nodes:       newNodeSet(10),
+			expected:    1,
+			err:         false,
+		}, {
+			annotations: map[string]string{maxUnavailableOverrideAnnotationKey: "50%"},
+			maxUnavail:  intStrPtr(intstr.FromInt(1)),
+			nodes:       newNodeSet(10),
+			expected:    5,
+			err:         false,
+		}, {
+			poolName:    "master",
+			annotations: map[string]string{maxUnavailableOverrideAnnotationKey: "3"},
+			maxUnavail:  intStrPtr(intstr.FromInt(1)),
+			nodes:       newNodeSet(3),
+			expected:    1,
+			err:         false,
+		}, {
+			annotations: map[string]string{maxUnavailableOverrideAnnotationKey: "half"},
+			maxUnavail:  intStrPtr(intstr.FromInt(1)),
+			nodes:       newNodeSet(4),
+			expected:    0,
+			err:         true,
 		},
 	}
 
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			clampedBefore := masterMaxUnavailableClamped.Value()
 			pool := &mcfgv1.MachineConfigPool{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: test.poolName,
+					Name:        test.poolName,
+					Annotations: test.annotations,
 				},
 				Spec: mcfgv1.MachineConfigPoolSpec{
 					MaxUnavailable: test.maxUnavail,
@@ -424,12 +633,60 @@ func TestMaxUnavailable(t *testing.T) {
 			if got != test.expected {
 				t.Fatalf("mismatch maxUnavailable: got %d want: %d", got, test.expected)
 			}
+
+			m, _ := calculateMaxUnavailable(pool, test.nodes)
+			clamped := masterMaxUnavailableClamped.Value() - clampedBefore
+			if wantClamped := m.quorumClamped; wantClamped != (clamped == 1) {
+				t.Fatalf("mismatch clamped metric: got %d increments, clamp expected: %v", clamped, wantClamped)
+			}
+		})
+	}
+}
+
+func TestMaxUnavailableScaling(t *testing.T) {
+	tests := []struct {
+		poolName string
+		scaling  mcfgv1.MaxUnavailableScaling
+		nodes    int
+		expected int
+	}{
+		{scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 1, expected: 1},
+		{scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 4, expected: 2},
+		{scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 10, expected: 3},
+		{scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 100, expected: 10},
+		{scaling: mcfgv1.MaxUnavailableScalingLog2, nodes: 1, expected: 1},
+		{scaling: mcfgv1.MaxUnavailableScalingLog2, nodes: 4, expected: 2},
+		{scaling: mcfgv1.MaxUnavailableScalingLog2, nodes: 10, expected: 3},
+		{scaling: mcfgv1.MaxUnavailableScalingLog2, nodes: 100, expected: 6},
+		{scaling: mcfgv1.MaxUnavailableScalingFixed, nodes: 100, expected: 5},
+		// The etcd quorum clamp applies to the scaled value
+		{poolName: "master", scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 4, expected: 1},
+		{poolName: "master", scaling: mcfgv1.MaxUnavailableScalingSqrt, nodes: 9, expected: 3},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			poolName := test.poolName
+			if poolName == "" {
+				poolName = "worker"
+			}
+			// maxUnavailable is ignored unless scaling is Fixed.
+			pool := newMachineConfigPool(poolName, nil, intStrPtr(intstr.FromInt(5)), "")
+			pool.Spec.MaxUnavailableScaling = test.scaling
+			got, err := maxUnavailable(pool, newNodeSet(test.nodes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Fatalf("mismatch for %s with %d nodes: got %d want: %d", test.scaling, test.nodes, got, test.expected)
+			}
 		})
 	}
 }
 
 func TestGetCandidateMachines(t *testing.T) {
 	tests := []struct {
+		spec     mcfgv1.MachineConfigPoolSpec
 		nodes    []*corev1.Node
 		progress int
 
@@ -520,17 +777,171 @@ func TestGetCandidateMachines(t *testing.T) {
 			newNodeWithReady("node-3", "v1", "v1", corev1.ConditionTrue),
 		},
 		expected: nil,
+	}, {
+		// A pinned node is never a candidate and takes up capacity
+		progress: 2,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			withAnnotation(newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue), pinConfigAnnotationKey, "v0"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-2"},
+	}, {
+		// An unavailable pinned node is only counted once
+		progress: 2,
+		nodes: []*corev1.Node{
+			withAnnotation(newNodeWithReady("node-0", "v0", "v0", corev1.ConditionFalse), pinConfigAnnotationKey, "v0"),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-1"},
+	}, {
+		// Cordoned nodes are candidates unless the pool skips them
+		progress: 2,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newCordonedNode("node-1", "v0", "v0"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-1"},
+	}, {
+		progress: 3,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newCordonedNode("node-1", "v0", "v0"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-1", "node-2"},
+	}, {
+		spec:     mcfgv1.MachineConfigPoolSpec{SkipCordonedNodes: true},
+		progress: 3,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newCordonedNode("node-1", "v0", "v0"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-2", "node-3"},
+	}, {
+		// Skipped cordoned nodes still count as unavailable
+		spec:     mcfgv1.MachineConfigPoolSpec{SkipCordonedNodes: true},
+		progress: 2,
+		nodes: []*corev1.Node{
+			newCordonedNode("node-0", "v0", "v0"),
+			newCordonedNode("node-1", "v0", "v0"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: nil,
+	}, {
+		// Nodes retained on the previous config aren't candidates
+		spec:     mcfgv1.MachineConfigPoolSpec{RetainPreviousConfig: 2},
+		progress: 3,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: []string{"node-1"},
+	}, {
+		spec:     mcfgv1.MachineConfigPoolSpec{RetainPreviousConfig: 2},
+		progress: 3,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: nil,
+	}, {
+		// Retaining more nodes than the pool has holds all of them
+		spec:     mcfgv1.MachineConfigPoolSpec{RetainPreviousConfig: 10},
+		progress: 3,
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: nil,
+	}, {
+		// Not ready GPU nodes don't hold back the rest of the pool, and aren't candidates
+		spec:     mcfgv1.MachineConfigPoolSpec{IgnoreUnavailableTaints: []string{"gpu"}},
+		progress: 1,
+		nodes: []*corev1.Node{
+			withTaint(newNodeWithReady("node-0", "v0", "v0", corev1.ConditionFalse), "gpu"),
+			withTaint(newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue), "gpu"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+			withTaint(newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue), "other"),
+		},
+		expected: []string{"node-2"},
+	}, {
+		// Other taints still count
+		spec:     mcfgv1.MachineConfigPoolSpec{IgnoreUnavailableTaints: []string{"gpu"}},
+		progress: 1,
+		nodes: []*corev1.Node{
+			withTaint(newNodeWithReady("node-0", "v0", "v0", corev1.ConditionFalse), "other"),
+			withTaint(newNodeWithReady("node-1", "v0", "v0", corev1.ConditionFalse), "gpu"),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		expected: nil,
 	}}
 
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
 			pool := &mcfgv1.MachineConfigPool{
-				Spec: mcfgv1.MachineConfigPoolSpec{
-					Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
-				},
+				Spec: *test.spec.DeepCopy(),
+			}
+			pool.Spec.Configuration = mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}}
+
+			got := getCandidateMachines(pool, test.nodes, test.progress, checkNodeReady, nil, nil, nil)
+			var nodeNames []string
+			for _, node := range got {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			if !reflect.DeepEqual(nodeNames, test.expected) {
+				t.Fatalf("mismatch: got %v want: %v", nodeNames, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetCandidateMachinesMasterOrdering(t *testing.T) {
+	// Defer node-0, e.g. because it is the etcd leader.
+	order := func(nodes []*corev1.Node) []*corev1.Node {
+		var ordered []*corev1.Node
+		for _, node := range nodes {
+			if node.Name != "node-0" {
+				ordered = append(ordered, node)
+			}
+		}
+		for _, node := range nodes {
+			if node.Name == "node-0" {
+				ordered = append(ordered, node)
 			}
+		}
+		return ordered
+	}
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+	}
 
-			got := getCandidateMachines(pool, test.nodes, test.progress)
+	tests := []struct {
+		pool     string
+		expected []string
+	}{{
+		pool:     "master",
+		expected: []string{"node-1"},
+	}, {
+		pool:     "worker",
+		expected: []string{"node-0"},
+	}}
+	for _, test := range tests {
+		t.Run(test.pool, func(t *testing.T) {
+			pool := newMachineConfigPool(test.pool, nil, nil, "v1")
+			got := getCandidateMachines(pool, nodes, 1, checkNodeReady, order, nil, nil)
 			var nodeNames []string
 			for _, node := range got {
 				nodeNames = append(nodeNames, node.Name)
@@ -702,6 +1113,18 @@ func TestSetDesiredMachineConfigAnnotation(t *testing.T) {
 				t.Fatal(actions)
 			}
 
+			if !actions[0].Matches("get", "nodes") || actions[0].(core.GetAction).GetName() != "node-0" {
+				t.Fatal(actions)
+			}
+		},
+	}, {
+		node:       newNode("node-0", "v0", "v0"),
+		extraannos: map[string]string{pinConfigAnnotationKey: "v0"},
+		verify: func(actions []core.Action, t *testing.T) {
+			if len(actions) != 1 {
+				t.Fatal(actions)
+			}
+
 			if !actions[0].Matches("get", "nodes") || actions[0].(core.GetAction).GetName() != "node-0" {
 				t.Fatal(actions)
 			}
@@ -744,6 +1167,7 @@ func TestShouldMakeProgress(t *testing.T) {
 
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
 	f.nodeLister = append(f.nodeLister, nodes...)
 	for idx := range nodes {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
@@ -765,7 +1189,7 @@ func TestShouldMakeProgress(t *testing.T) {
 		t.Fatal(err)
 	}
 	f.expectPatchNodeAction(expNode, exppatch)
-	expStatus := calculateStatus(mcp, nodes)
+	expStatus := calculateStatus(mcp, nodes, checkNodeReady)
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -773,31 +1197,82 @@ func TestShouldMakeProgress(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
-func TestEmptyCurrentMachineConfig(t *testing.T) {
+func TestCandidateJitter(t *testing.T) {
 	f := newFixture(t)
-	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "")
+	mcp := newMachineConfigPool("test-cluster-worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "worker"), intStrPtr(intstr.FromInt(2)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "worker"}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role": "worker"}),
+		newNodeWithLabel("node-2", "v0", "v0", map[string]string{"node-role": "worker"}),
+	}
+
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
-	f.run(getKey(mcp, t))
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	jitter := 20 * time.Millisecond
+	c := f.newController()
+	WithCandidateJitter(jitter)(c)
+
+	start := time.Now()
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < jitter {
+		t.Fatalf("expected candidates to be staggered by at least %v, sync took %v", jitter, elapsed)
+	}
+
+	var patched []string
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if action.Matches("patch", "nodes") {
+			patched = append(patched, action.(core.PatchAction).GetName())
+		}
+	}
+	sort.Strings(patched)
+	if !reflect.DeepEqual(patched, []string{"node-1", "node-2"}) {
+		t.Fatalf("mismatch patched nodes: got %v want: %v", patched, []string{"node-1", "node-2"})
+	}
 }
 
-func TestPaused(t *testing.T) {
+func TestResetOrphanedDesiredConfig(t *testing.T) {
 	f := newFixture(t)
 	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
-	mcp.Spec.Paused = true
 	nodes := []*corev1.Node{
 		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
-		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v0", "v-deleted", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-2", "v0", "v0", map[string]string{"node-role": "master"}),
 	}
 
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
 	f.nodeLister = append(f.nodeLister, nodes...)
 	for idx := range nodes {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
+	// Only node-1 is reset; it still counts against maxUnavailable so node-2 has to wait.
+	f.expectGetNodeAction(nodes[1])
+	expNode := nodes[1].DeepCopy()
+	expNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "v1"
+	oldData, err := json.Marshal(nodes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	newData, err := json.Marshal(expNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectPatchNodeAction(expNode, exppatch)
+	expStatus := calculateStatus(mcp, nodes, checkNodeReady)
 	expMcp := mcp.DeepCopy()
 	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
@@ -805,47 +1280,176 @@ func TestPaused(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
-func TestShouldUpdateStatusOnlyUpdated(t *testing.T) {
+func TestEmptyCurrentMachineConfig(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "")
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.run(getKey(mcp, t))
+}
+
+type fakeRolloutGate struct {
+	allowed bool
+	reason  string
+}
+
+func (g fakeRolloutGate) Allowed() (bool, string, error) {
+	return g.allowed, g.reason, nil
+}
+
+func TestRolloutGated(t *testing.T) {
 	f := newFixture(t)
+	f.opts = append(f.opts, WithRolloutGate(fakeRolloutGate{reason: "alert KubeAPIDown is firing"}))
 	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
 	nodes := []*corev1.Node{
 		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
-		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role": "master"}),
 	}
 
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
 	f.nodeLister = append(f.nodeLister, nodes...)
 	for idx := range nodes {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
 	expMcp := mcp.DeepCopy()
-	expMcp.Status = expStatus
+	sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionTrue, "GateClosed", "alert KubeAPIDown is firing")
+	mcfgv1.SetMachineConfigPoolCondition(&expMcp.Status, *sgated)
+	expMcp.Status = calculateStatus(expMcp, nodes, checkNodeReady)
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
 
 	f.run(getKey(mcp, t))
 }
 
-func TestShouldUpdateStatusOnlyNoProgress(t *testing.T) {
+// TestRolloutGatedSteadyStatus makes sure conditions set during the sync are
+// written even when the machine counts of the pool did not change.
+func TestRolloutGatedSteadyStatus(t *testing.T) {
 	f := newFixture(t)
+	f.opts = append(f.opts, WithRolloutGate(fakeRolloutGate{reason: "alert KubeAPIDown is firing"}))
 	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
 	nodes := []*corev1.Node{
 		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
-		newNodeWithLabel("node-1", "v0", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role": "master"}),
 	}
+	mcp.Status = calculateStatus(mcp, nodes, checkNodeReady)
 
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
 	f.nodeLister = append(f.nodeLister, nodes...)
 	for idx := range nodes {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
 	}
 
-	expStatus := calculateStatus(mcp, nodes)
 	expMcp := mcp.DeepCopy()
-	expMcp.Status = expStatus
+	sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionTrue, "GateClosed", "alert KubeAPIDown is firing")
+	mcfgv1.SetMachineConfigPoolCondition(&expMcp.Status, *sgated)
+	expMcp.Status = calculateStatus(expMcp, nodes, checkNodeReady)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestRolloutGateReopened(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithRolloutGate(fakeRolloutGate{allowed: true}))
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
+	sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionTrue, "GateClosed", "alert KubeAPIDown is firing")
+	mcfgv1.SetMachineConfigPoolCondition(&mcp.Status, *sgated)
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expMcp := mcp.DeepCopy()
+	sopen := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionFalse, "", "")
+	mcfgv1.SetMachineConfigPoolCondition(&expMcp.Status, *sopen)
+	expMcp.Status = calculateStatus(expMcp, nodes, checkNodeReady)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestPaused(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.Paused = true
+	pausedSince := metav1.NewTime(time.Now().Add(-time.Minute))
+	mcp.Status.PausedSince = &pausedSince
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expStatus := calculateStatus(mcp, nodes, checkNodeReady)
+	expStatus.PausedSince = &pausedSince
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = expStatus
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestShouldUpdateStatusOnlyUpdated(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expStatus := calculateStatus(mcp, nodes, checkNodeReady)
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = expStatus
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestShouldUpdateStatusOnlyNoProgress(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v0", "v1", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expStatus := calculateStatus(mcp, nodes, checkNodeReady)
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = expStatus
 	f.expectUpdateMachineConfigPoolStatus(expMcp)
 
 	f.run(getKey(mcp, t))
@@ -858,11 +1462,12 @@ func TestShouldDoNothing(t *testing.T) {
 		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
 		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role": "master"}),
 	}
-	status := calculateStatus(mcp, nodes)
+	status := calculateStatus(mcp, nodes, checkNodeReady)
 	mcp.Status = status
 
 	f.mcpLister = append(f.mcpLister, mcp)
 	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
 	f.nodeLister = append(f.nodeLister, nodes...)
 	for idx := range nodes {
 		f.kubeobjects = append(f.kubeobjects, nodes[idx])
@@ -872,12 +1477,7 @@ func TestShouldDoNothing(t *testing.T) {
 }
 
 func getKey(config *mcfgv1.MachineConfigPool, t *testing.T) string {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(config)
-	if err != nil {
-		t.Errorf("Unexpected error getting key for config %v: %v", config.Name, err)
-		return ""
-	}
-	return key
+	return poolKey(config)
 }
 
 func filterLastTransitionTime(obj runtime.Object) runtime.Object {
@@ -892,3 +1492,756 @@ func filterLastTransitionTime(obj runtime.Object) runtime.Object {
 	}
 	return o
 }
+
+func TestRunDrainsInFlightSync(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithDrainTimeout(time.Minute))
+	c := f.newController()
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	c.syncHandler = func(key string) error {
+		started <- key
+		<-release
+		return nil
+	}
+	c.queue.Add("worker")
+	c.queue.Add("infra")
+
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		c.Run(1, stopCh)
+		close(stopped)
+	}()
+
+	first := <-started
+	close(stopCh)
+	select {
+	case <-stopped:
+		t.Fatalf("expected Run to wait for the in-flight sync of %s", first)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected Run to return once the in-flight sync finished")
+	}
+	select {
+	case key := <-started:
+		t.Fatalf("expected no new sync to start while draining, got %s", key)
+	default:
+	}
+}
+
+func TestRunDrainTimeout(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithDrainTimeout(10*time.Millisecond))
+	c := f.newController()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	c.syncHandler = func(key string) error {
+		close(started)
+		<-release
+		return nil
+	}
+	c.queue.Add("worker")
+
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		c.Run(1, stopCh)
+		close(stopped)
+	}()
+
+	<-started
+	close(stopCh)
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected Run to give up on a stuck sync after the drain timeout")
+	}
+}
+
+func TestUpdateNodeStartedEvent(t *testing.T) {
+	for _, nodeEvents := range []bool{true, false} {
+		f := newFixture(t)
+		mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.opts = append(f.opts, WithNodeEvents(nodeEvents))
+		c := f.newController()
+		recorder := record.NewFakeRecorder(10)
+		c.eventRecorder = recorder
+
+		labels := map[string]string{"node-role/worker": ""}
+		pending := newNodeWithLabel("node-0", "v0", "v1", labels)
+		pending.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+		working := pending.DeepCopy()
+		working.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateWorking
+
+		c.updateNode(pending, working)
+		// Further updates while working don't repeat the event.
+		c.updateNode(working, working.DeepCopy())
+
+		// The event on the pool is always recorded, the one on the node only with node events.
+		expected := 1
+		if nodeEvents {
+			expected = 2
+		}
+		want := "Normal NodeUpdateStarted Node node-0 started updating to v1"
+		for i := 0; i < expected; i++ {
+			select {
+			case got := <-recorder.Events:
+				if got != want {
+					t.Fatalf("mismatch event: got %q want: %q", got, want)
+				}
+			default:
+				t.Fatalf("node events %t: expected %d events, got %d", nodeEvents, expected, i)
+			}
+		}
+		select {
+		case got := <-recorder.Events:
+			t.Fatalf("node events %t: unexpected event: %q", nodeEvents, got)
+		default:
+		}
+	}
+}
+
+func TestDeleteUpdatingNode(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v1", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	patched := func() []string {
+		var names []string
+		for _, action := range f.kubeclient.Actions() {
+			if patch, ok := action.(core.PatchAction); ok {
+				names = append(names, patch.GetName())
+			}
+		}
+		return names
+	}
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if got := patched(); len(got) != 0 {
+		t.Fatalf("expected no capacity while node-0 is updating, got patches for %v", got)
+	}
+
+	// node-0 gets scaled down mid-update.
+	if err := f.kubeinformers.Core().V1().Nodes().Informer().GetIndexer().Delete(nodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	c.deleteNode(nodes[0])
+	if c.queue.Len() != 1 {
+		t.Fatalf("expected the pool to be queued right away, got %d queued", c.queue.Len())
+	}
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if got := patched(); !reflect.DeepEqual(got, []string{"node-1"}) {
+		t.Fatalf("expected the freed capacity to go to node-1, got patches for %v", got)
+	}
+}
+
+func TestPoolKeyIgnoresNamespace(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	c := f.newController()
+
+	stray := mcp.DeepCopy()
+	stray.Namespace = "openshift-machine-config-operator"
+	c.enqueue(mcp)
+	c.enqueue(stray)
+	if c.queue.Len() != 1 {
+		t.Fatalf("expected a pool with a stray namespace to share the key of the pool, got %d keys", c.queue.Len())
+	}
+
+	key, _ := c.queue.Get()
+	if key != "worker" {
+		t.Fatalf("mismatch key: got %v want: worker", key)
+	}
+	if err := c.syncHandler(key.(string)); err != nil {
+		t.Fatalf("expected the key to resolve to the pool: %v", err)
+	}
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if action.Matches("update", "machineconfigpools") && action.GetSubresource() == "status" {
+			return
+		}
+	}
+	t.Fatalf("expected the pool status to be synced, got %v", f.client.Actions())
+}
+
+func TestMaxUnavailableOverride(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(3)), "v1")
+	mcp.Annotations = map[string]string{maxUnavailableOverrideAnnotationKey: "1"}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-2", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-3", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	patched := 0
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if action.GetVerb() == "patch" {
+			patched++
+		}
+	}
+	if patched != 1 {
+		t.Fatalf("expected the override to limit the rollout to 1 node, patched %d", patched)
+	}
+	select {
+	case got := <-recorder.Events:
+		if want := "Normal MaxUnavailableOverridden"; !strings.HasPrefix(got, want) {
+			t.Fatalf("mismatch event: got %q want prefix: %q", got, want)
+		}
+	default:
+		t.Fatalf("expected an event about the override")
+	}
+
+	// The override is only reported again once it changes.
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	for len(recorder.Events) > 0 {
+		if got := <-recorder.Events; strings.Contains(got, "MaxUnavailableOverridden") {
+			t.Fatalf("expected the unchanged override not to be reported again, got %q", got)
+		}
+	}
+}
+
+func TestNodePinnedEventOnlyOnChange(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		withAnnotation(newNodeWithLabel("node-0", "v0", "v0", labels), pinConfigAnnotationKey, "v0"),
+		newNodeWithLabel("node-1", "v1", "v1", labels),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	pinnedEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if got := <-recorder.Events; strings.HasPrefix(got, "Warning NodePinned") {
+				count++
+			}
+		}
+		return count
+	}
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if got := pinnedEvents(); got != 1 {
+		t.Fatalf("expected 1 event about the pinned node, got %d", got)
+	}
+
+	// The pin is only reported again once it changes.
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if got := pinnedEvents(); got != 0 {
+		t.Fatalf("expected the unchanged pin not to be reported again, got %d events", got)
+	}
+}
+
+func TestNodeWithoutAnnotations(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-new", "", "", corev1.ConditionTrue),
+	}
+	if !needsInitialConfig(nodes[1]) {
+		t.Fatalf("expected a node without annotations to need its initial config")
+	}
+	if IsNodeUnavailable(nodes[1], mcfgv1.UnavailabilityPolicyStrict, nil) {
+		t.Fatalf("expected a ready node without annotations not to count as unavailable")
+	}
+	got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil)
+	if len(got) != 1 || got[0].Name != "node-new" {
+		t.Fatalf("expected the new node to be selected for its initial config, got %v", got)
+	}
+
+	// Until it is ready, a new node holds back the pool like any unready node.
+	unready := newNodeWithReady("node-new", "", "", corev1.ConditionFalse)
+	if !IsNodeUnavailable(unready, mcfgv1.UnavailabilityPolicyStrict, nil) {
+		t.Fatalf("expected an unready node without annotations to count as unavailable")
+	}
+	if got := getCandidateMachines(pool, []*corev1.Node{nodes[0], unready}, 1, checkNodeReady, nil, nil, nil); len(got) != 0 {
+		t.Fatalf("expected no candidates while the new node is unready, got %v", got)
+	}
+}
+
+func TestUpdateNodeWithoutAnnotations(t *testing.T) {
+	f := newFixture(t)
+	pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	f.mcpLister = append(f.mcpLister, pool)
+	c := f.newController()
+	var enqueued []string
+	c.enqueueMachineConfigPool = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+
+	unready := newNodeWithReady("node-new", "", "", corev1.ConditionFalse)
+	unready.Labels = map[string]string{"node-role/worker": ""}
+	relabeled := unready.DeepCopy()
+	relabeled.Labels["topology"] = "a"
+	c.updateNode(unready, relabeled)
+	if len(enqueued) != 0 {
+		t.Fatalf("expected no sync for an unrelated change of a new node, got %v", enqueued)
+	}
+
+	ready := relabeled.DeepCopy()
+	ready.Status.Conditions[0].Status = corev1.ConditionTrue
+	c.updateNode(relabeled, ready)
+	if expected := []string{"worker"}; !reflect.DeepEqual(enqueued, expected) {
+		t.Fatalf("expected the pool to sync once its new node is ready, got %v", enqueued)
+	}
+}
+
+func TestStartupGracePeriod(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithStartupGracePeriod(time.Minute))
+	pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	c := f.newController()
+
+	now := time.Now()
+	if got := c.startupDelay(now); got != time.Minute {
+		t.Fatalf("expected the whole grace period before Run, got %v", got)
+	}
+	c.runStarted = now.Add(-20 * time.Second).UnixNano()
+	if got := c.startupDelay(now); got != 40*time.Second {
+		t.Fatalf("mismatch startup delay: got %v want: %v", got, 40*time.Second)
+	}
+
+	// Every way of enqueuing the pool is delayed during startup, not just adding it.
+	c.runStarted = time.Now().UnixNano()
+	c.addMachineConfigPool(pool)
+	c.enqueue(pool)
+	c.enqueueAfter(pool, time.Millisecond)
+	if c.queue.Len() != 0 {
+		t.Fatalf("expected a pool enqueued during startup to be delayed, got %d queued", c.queue.Len())
+	}
+
+	c.runStarted = time.Now().Add(-2 * time.Minute).UnixNano()
+	c.enqueue(pool)
+	if c.queue.Len() != 1 {
+		t.Fatalf("expected a pool enqueued after startup to be queued right away, got %d queued", c.queue.Len())
+	}
+}
+
+func TestNodeUpdateBackoff(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithNodeUpdateBackoff(wait.Backoff{Steps: 2, Duration: time.Millisecond}))
+	node := newNode("node-0", "v0", "v0")
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	patches := 0
+	f.kubeclient.PrependReactor("patch", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		patches++
+		return true, nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-0", fmt.Errorf("conflict"))
+	})
+	exhausted := nodeUpdateRetriesExhausted.Value()
+
+	if err := c.setDesiredMachineConfigAnnotation("node-0", "v1"); !errors.IsConflict(err) {
+		t.Fatalf("expected the conflict once retries are exhausted, got %v", err)
+	}
+	if patches != 2 {
+		t.Fatalf("expected 2 patch attempts, got %d", patches)
+	}
+	if got := nodeUpdateRetriesExhausted.Value() - exhausted; got != 1 {
+		t.Fatalf("expected exhausted retries to be counted once, got %d", got)
+	}
+}
+
+func TestNodeUpdateBackoffWithoutSteps(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithNodeUpdateBackoff(wait.Backoff{Steps: 0, Duration: time.Millisecond}))
+	node := newNode("node-0", "v0", "v0")
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	if c.nodeUpdateBackoff.Steps != nodeUpdateBackoff.Steps {
+		t.Fatalf("expected a backoff without steps to keep the default, got %+v", c.nodeUpdateBackoff)
+	}
+
+	if err := c.setDesiredMachineConfigAnnotation("node-0", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != "v1" {
+		t.Fatalf("expected the desired config to be written, got %v", updated.Annotations)
+	}
+}
+
+func TestGetCandidateMachinesInFlightFirst(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v2")
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v2", "v2", corev1.ConditionTrue),
+		// Still updating to the previous target of the pool.
+		newNodeWithReady("node-2", "v0", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	// node-2 takes one slot of maxUnavailable. The other goes to node-2 rather than
+	// disrupting a new node.
+	got := getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil)
+	if len(got) != 1 || got[0].Name != "node-2" {
+		t.Fatalf("expected the in-flight node-2 to be selected first, got %v", got)
+	}
+
+	// With more room, new nodes follow in their usual order.
+	got = getCandidateMachines(pool, nodes, 3, checkNodeReady, nil, nil, nil)
+	var names []string
+	for _, node := range got {
+		names = append(names, node.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"node-2", "node-0"}) {
+		t.Fatalf("mismatch candidates: got %v want: [node-2 node-0]", names)
+	}
+}
+
+func TestUpdateNodeRelabeled(t *testing.T) {
+	f := newFixture(t)
+	worker := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	infra := newMachineConfigPool("infra", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", ""), nil, "v1")
+	oldNode := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": ""})
+	curNode := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/infra": ""})
+	f.nodeLister = append(f.nodeLister, curNode)
+	f.mcpLister = append(f.mcpLister, worker, infra)
+
+	c := f.newController()
+	var enqueued []string
+	c.enqueueMachineConfigPool = func(pool *mcfgv1.MachineConfigPool) {
+		enqueued = append(enqueued, pool.Name)
+	}
+
+	// The node moved from worker to infra, both pools are enqueued.
+	c.updateNode(oldNode, curNode)
+	if expected := []string{"worker", "infra"}; !reflect.DeepEqual(enqueued, expected) {
+		t.Fatalf("mismatch enqueued pools: got %v want: %v", enqueued, expected)
+	}
+
+	// A label change within the pool doesn't move the node.
+	enqueued = nil
+	relabeled := curNode.DeepCopy()
+	relabeled.Labels["example.com/rack"] = "r1"
+	c.updateNode(curNode, relabeled)
+	if len(enqueued) != 0 {
+		t.Fatalf("expected no sync for a label change within the pool, got %v", enqueued)
+	}
+}
+
+func TestSyncPool(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+
+	if err := c.SyncPool("worker"); err != nil {
+		t.Fatal(err)
+	}
+	patched := false
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		patched = patched || action.GetVerb() == "patch"
+	}
+	if !patched {
+		t.Fatalf("expected node-0 to be updated by a single reconcile")
+	}
+	if err := c.SyncPool("missing"); err != nil {
+		t.Fatalf("expected no error syncing a missing pool, got %v", err)
+	}
+}
+
+func TestGetCandidateMachinesDeterministic(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+	var nodes []*corev1.Node
+	for i := 0; i < 6; i++ {
+		nodes = append(nodes, newNodeWithReady(fmt.Sprintf("node-%d", i), "v0", "v0", corev1.ConditionTrue))
+	}
+
+	for i := 0; i < 20; i++ {
+		rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+		var names []string
+		for _, node := range getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil) {
+			names = append(names, node.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"node-0", "node-1"}) {
+			t.Fatalf("mismatch candidates: got %v want: [node-0 node-1]", names)
+		}
+	}
+}
+
+func TestGetCandidateMachinesMinHealthy(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      []*corev1.Node
+		maxUnavail int
+		policy     mcfgv1.UnavailabilityPolicy
+		minHealthy int32
+		expected   []string
+	}{{
+		name: "two nodes, one unready, without minHealthy",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionFalse),
+		},
+		maxUnavail: 2,
+		expected:   []string{"node-0"},
+	}, {
+		name: "two nodes, one unready, keeping one healthy",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionFalse),
+		},
+		maxUnavail: 2,
+		minHealthy: 1,
+		expected:   []string{"node-1"},
+	}, {
+		name: "two nodes, the other one unready but not counted as unavailable",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v1", "v1", corev1.ConditionFalse),
+		},
+		maxUnavail: 1,
+		policy:     mcfgv1.UnavailabilityPolicyUpdateOnly,
+		minHealthy: 1,
+		expected:   nil,
+	}, {
+		name: "three nodes, keeping one healthy",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		maxUnavail: 3,
+		minHealthy: 1,
+		expected:   []string{"node-0", "node-1"},
+	}, {
+		name: "three nodes, one updating, keeping two healthy",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v1", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		maxUnavail: 3,
+		minHealthy: 2,
+		expected:   nil,
+	}, {
+		name: "three nodes, one updating to an old config, keeping two healthy",
+		nodes: []*corev1.Node{
+			newNodeWithReady("node-0", "v0", "v-old", corev1.ConditionTrue),
+			newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+			newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		},
+		maxUnavail: 3,
+		minHealthy: 2,
+		expected:   []string{"node-0"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(test.maxUnavail)), "v1")
+			pool.Spec.UnavailabilityPolicy = test.policy
+			pool.Spec.MinHealthy = test.minHealthy
+			var got []string
+			for _, node := range getCandidateMachines(pool, test.nodes, test.maxUnavail, checkNodeReady, nil, nil, nil) {
+				got = append(got, node.Name)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("expected candidates %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestMaxUnavailableExceedsPoolSize(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	recorder := &objectRecorder{}
+	c.eventRecorder = recorder
+	nodes := newNodeSet(3)
+
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(10)), "v1")
+	m, err := calculateMaxUnavailable(pool, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.exceedsPoolSize {
+		t.Fatalf("expected maxUnavailable 10 to exceed a pool of 3 nodes")
+	}
+	c.checkMaxUnavailableSize(pool, m, len(nodes))
+	c.checkMaxUnavailableSize(pool, m, len(nodes))
+	if !mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize) {
+		t.Fatalf("expected the pool to report that maxUnavailable exceeds its size, got %v", pool.Status.Conditions)
+	}
+	if len(recorder.events) != 1 || !recorder.has("pool/worker", "MaxUnavailableExceedsPoolSize") {
+		t.Fatalf("expected a single MaxUnavailableExceedsPoolSize event, got %v", recorder.events)
+	}
+
+	// The message follows the requested maxUnavailable while it keeps exceeding the pool.
+	pool.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(20))
+	if m, err = calculateMaxUnavailable(pool, nodes); err != nil {
+		t.Fatal(err)
+	}
+	c.checkMaxUnavailableSize(pool, m, len(nodes))
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize)
+	if expected := "maxUnavailable 20 is larger than the 3 nodes of the pool"; cond == nil || cond.Message != expected {
+		t.Fatalf("mismatch condition: got %v want message: %q", cond, expected)
+	}
+
+	pool.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(3))
+	if m, err = calculateMaxUnavailable(pool, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if m.exceedsPoolSize {
+		t.Fatalf("expected maxUnavailable 3 not to exceed a pool of 3 nodes")
+	}
+	c.checkMaxUnavailableSize(pool, m, len(nodes))
+	if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolMaxUnavailableExceedsPoolSize) {
+		t.Fatalf("expected the condition to clear, got %v", pool.Status.Conditions)
+	}
+}
+
+func TestTargetConfigNotFound(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v9")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := &objectRecorder{}
+	c.eventRecorder = recorder
+
+	if err := c.syncHandler(getKey(mcp, t)); err == nil {
+		t.Fatalf("expected the sync of a pool targeting a missing config to fail")
+	}
+	for _, action := range f.kubeclient.Actions() {
+		if action.Matches("patch", "nodes") {
+			t.Fatalf("expected no node to be picked for a missing config, got %v", action)
+		}
+	}
+	// Later syncs of the pool fail the same way without recording the event again.
+	if err := c.syncHandler(getKey(mcp, t)); err == nil {
+		t.Fatalf("expected the sync of a pool targeting a missing config to fail")
+	}
+	notFound := 0
+	for _, event := range recorder.events {
+		if event == "pool/worker MachineConfigNotFound" {
+			notFound++
+		}
+	}
+	if notFound != 1 {
+		t.Fatalf("expected a single MachineConfigNotFound event, got %v", recorder.events)
+	}
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	if status == nil {
+		t.Fatalf("expected the pool status to be updated")
+	}
+	degraded := mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolDegraded)
+	if degraded == nil || degraded.Status != corev1.ConditionTrue || degraded.Reason != syncErrorMachineConfigNotFound {
+		t.Fatalf("expected the pool to be degraded with reason %s, got %v", syncErrorMachineConfigNotFound, degraded)
+	}
+}
+
+func TestSkipNodesUnderPressure(t *testing.T) {
+	underPressure := func(name string, pressure corev1.NodeConditionType) *corev1.Node {
+		node := newNode(name, "v0", "v0")
+		node.Status.Conditions = []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: pressure, Status: corev1.ConditionTrue},
+		}
+		return node
+	}
+	nodes := []*corev1.Node{
+		underPressure("node-0", corev1.NodeDiskPressure),
+		newNode("node-1", "v0", "v0"),
+		underPressure("node-2", corev1.NodePIDPressure),
+	}
+	candidateNames := func(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []string {
+		var names []string
+		for _, node := range getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil) {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	if got := candidateNames(pool, nodes); !reflect.DeepEqual(got, []string{"node-0"}) {
+		t.Fatalf("expected pressure to be ignored by default, got candidates %v", got)
+	}
+	pool.Spec.SkipNodesUnderPressure = true
+	if got := candidateNames(pool, nodes); !reflect.DeepEqual(got, []string{"node-1"}) {
+		t.Fatalf("expected node-0 under disk pressure to be deferred without taking the slot, got candidates %v", got)
+	}
+
+	// Once the disk pressure clears, node-0 is updated again.
+	nodes[0].Status.Conditions[1].Status = corev1.ConditionFalse
+	if got := candidateNames(pool, nodes); !reflect.DeepEqual(got, []string{"node-0"}) {
+		t.Fatalf("expected node-0 to be updated once its pressure cleared, got candidates %v", got)
+	}
+}
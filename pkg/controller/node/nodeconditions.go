@@ -0,0 +1,48 @@
+package node
+
+import (
+	"fmt"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// checkRequiredConditions returns a non-nil error if node doesn't report one of the
+// conditions required by pool as True.
+func checkRequiredConditions(pool *mcfgv1.MachineConfigPool, node *corev1.Node) error {
+	for _, required := range pool.Spec.RequiredNodeConditions {
+		found := false
+		for i := range node.Status.Conditions {
+			cond := &node.Status.Conditions[i]
+			if cond.Type != required {
+				continue
+			}
+			if cond.Status != corev1.ConditionTrue {
+				return fmt.Errorf("node %s is reporting %s %s", node.Name, required, cond.Status)
+			}
+			found = true
+		}
+		if !found {
+			return fmt.Errorf("node %s is not reporting %s", node.Name, required)
+		}
+	}
+	return nil
+}
+
+// withRequiredConditions wraps checkReady so that nodes also have to report the
+// conditions required by pool as True to be ready. A nil checkReady uses the default
+// node readiness check.
+func withRequiredConditions(pool *mcfgv1.MachineConfigPool, checkReady NodeReadyChecker) NodeReadyChecker {
+	if checkReady == nil {
+		checkReady = checkNodeReady
+	}
+	if len(pool.Spec.RequiredNodeConditions) == 0 {
+		return checkReady
+	}
+	return func(node *corev1.Node) error {
+		if err := checkReady(node); err != nil {
+			return err
+		}
+		return checkRequiredConditions(pool, node)
+	}
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRequiredNodeConditions(t *testing.T) {
+	const gpuHealthy corev1.NodeConditionType = "nvidia.com/gpu-healthy"
+	tests := []struct {
+		name       string
+		required   []corev1.NodeConditionType
+		ready      corev1.ConditionStatus
+		conditions []corev1.NodeCondition
+		available  bool
+	}{{
+		name:      "default readiness",
+		ready:     corev1.ConditionTrue,
+		available: true,
+	}, {
+		name:       "required condition true",
+		required:   []corev1.NodeConditionType{gpuHealthy},
+		ready:      corev1.ConditionTrue,
+		conditions: []corev1.NodeCondition{{Type: gpuHealthy, Status: corev1.ConditionTrue}},
+		available:  true,
+	}, {
+		name:       "required condition false",
+		required:   []corev1.NodeConditionType{gpuHealthy},
+		ready:      corev1.ConditionTrue,
+		conditions: []corev1.NodeCondition{{Type: gpuHealthy, Status: corev1.ConditionFalse}},
+	}, {
+		name:     "required condition missing",
+		required: []corev1.NodeConditionType{gpuHealthy},
+		ready:    corev1.ConditionTrue,
+	}, {
+		name:       "required condition true on an unready node",
+		required:   []corev1.NodeConditionType{gpuHealthy},
+		ready:      corev1.ConditionFalse,
+		conditions: []corev1.NodeCondition{{Type: gpuHealthy, Status: corev1.ConditionTrue}},
+	}, {
+		name:       "condition not required",
+		ready:      corev1.ConditionTrue,
+		conditions: []corev1.NodeCondition{{Type: gpuHealthy, Status: corev1.ConditionFalse}},
+		available:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("gpu", nil, intStrPtr(intstr.FromInt(1)), "v1")
+			pool.Spec.RequiredNodeConditions = test.required
+			node := newNodeWithReady("node-0", "v1", "v1", test.ready)
+			node.Status.Conditions = append(node.Status.Conditions, test.conditions...)
+
+			unavailable := getUnavailableMachines([]*corev1.Node{node}, mcfgv1.UnavailabilityPolicyStrict, withRequiredConditions(pool, nil))
+			if got := len(unavailable) == 0; got != test.available {
+				t.Fatalf("expected available %v, got %v", test.available, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package node
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WithNodeEvents additionally records the progress of node updates as events on the
+// nodes themselves, for admins following a rollout with `oc describe node`: when the
+// controller sets the desired config of a node, when the node gets stuck failing to
+// apply it, and when it completes its update.
+func WithNodeEvents(enabled bool) Option {
+	return func(ctrl *Controller) {
+		ctrl.nodeEvents = enabled
+	}
+}
+
+// nodeEventf records an event on node, if node events are enabled.
+func (ctrl *Controller) nodeEventf(node *corev1.Node, eventtype, reason, messageFmt string, args ...interface{}) {
+	if !ctrl.nodeEvents {
+		return
+	}
+	ctrl.eventRecorder.Eventf(node, eventtype, reason, messageFmt, args...)
+}
@@ -0,0 +1,120 @@
+package node
+
+import (
+	"sync"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// objectRecorder records the reason of every event along with the object it was
+// recorded on.
+type objectRecorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *objectRecorder) record(object runtime.Object, reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch o := object.(type) {
+	case *corev1.Node:
+		r.events = append(r.events, "node/"+o.Name+" "+reason)
+	case *mcfgv1.MachineConfigPool:
+		r.events = append(r.events, "pool/"+o.Name+" "+reason)
+	}
+}
+
+func (r *objectRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.record(object, reason)
+}
+
+func (r *objectRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, reason)
+}
+
+func (r *objectRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, reason)
+}
+
+func (r *objectRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, reason)
+}
+
+// has returns whether an event with reason was recorded on object.
+func (r *objectRecorder) has(object, reason string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, event := range r.events {
+		if event == object+" "+reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNodeEventsOnNodeProgress(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		f := newFixture(t)
+		f.opts = append(f.opts, WithNodeEvents(enabled))
+		mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+		f.mcpLister = append(f.mcpLister, mcp)
+		c := f.newController()
+		recorder := &objectRecorder{}
+		c.eventRecorder = recorder
+
+		labels := map[string]string{"node-role/worker": ""}
+		working := newNodeWithLabel("node-0", "v0", "v1", labels)
+		working.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateWorking
+		failing := working.DeepCopy()
+		failing.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+		done := newNodeWithLabel("node-0", "v1", "v1", labels)
+		done.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+
+		c.updateNode(working, failing)
+		c.updateNode(failing, done)
+
+		for _, reason := range []string{"NodeUpdateStuck", "NodeUpdateCompleted"} {
+			if got := recorder.has("node/node-0", reason); got != enabled {
+				t.Errorf("node events enabled %t: expected an event %s on the node %t, got %v", enabled, reason, enabled, recorder.events)
+			}
+			if recorder.has("pool/worker", reason) {
+				t.Errorf("expected no event %s on the pool, got %v", reason, recorder.events)
+			}
+		}
+	}
+}
+
+func TestNodeEventsOnSetDesiredConfig(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		f := newFixture(t)
+		f.opts = append(f.opts, WithNodeEvents(enabled))
+		mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+		node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.objects = append(f.objects, mcp)
+		f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+		f.nodeLister = append(f.nodeLister, node)
+		f.kubeobjects = append(f.kubeobjects, node)
+		c := f.newController()
+		recorder := &objectRecorder{}
+		c.eventRecorder = recorder
+
+		if err := c.syncHandler(getKey(mcp, t)); err != nil {
+			t.Fatal(err)
+		}
+		if got := recorder.has("node/node-0", "SetDesiredConfig"); got != enabled {
+			t.Errorf("node events enabled %t: expected an event SetDesiredConfig on the node %t, got %v", enabled, enabled, recorder.events)
+		}
+		if recorder.has("pool/worker", "SetDesiredConfig") {
+			t.Errorf("expected no event SetDesiredConfig on the pool, got %v", recorder.events)
+		}
+	}
+}
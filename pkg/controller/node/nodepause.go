@@ -0,0 +1,48 @@
+package node
+
+import (
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// isNodePaused checks whether a node was paused individually.
+func isNodePaused(node *corev1.Node) bool {
+	return node.Annotations[pausedAnnotationKey] == "true"
+}
+
+// withoutPaused returns the nodes that are not paused individually.
+func withoutPaused(nodes []*corev1.Node) []*corev1.Node {
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		if !isNodePaused(node) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// setPausedNodes records on newStatus the nodes of pool that are paused individually,
+// and announces changes to them with an event.
+func (ctrl *Controller) setPausedNodes(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, nodes []*corev1.Node) {
+	paused := sets.NewString()
+	for _, node := range nodes {
+		if isNodePaused(node) {
+			paused.Insert(node.Name)
+		}
+	}
+	newStatus.PausedNodes = nil
+	if paused.Len() > 0 {
+		newStatus.PausedNodes = paused.List()
+	}
+	if paused.Equal(sets.NewString(pool.Status.PausedNodes...)) {
+		return
+	}
+	if paused.Len() == 0 {
+		ctrl.eventRecorder.Event(pool, corev1.EventTypeNormal, "NodesPaused", "No nodes are paused individually")
+		return
+	}
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "NodesPaused", "Nodes paused individually and left alone: %s", strings.Join(newStatus.PausedNodes, ", "))
+}
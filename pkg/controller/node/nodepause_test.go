@@ -0,0 +1,73 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetCandidateMachinesPausedNodes(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		// Paused mid-update, it doesn't count against maxUnavailable.
+		withAnnotation(newNodeWithReady("node-0", "v0", "v1", corev1.ConditionFalse), pausedAnnotationKey, "true"),
+		withAnnotation(newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue), pausedAnnotationKey, "true"),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		withAnnotation(newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue), pausedAnnotationKey, "false"),
+	}
+
+	got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil)
+	if len(got) != 1 || got[0].Name != "node-2" {
+		t.Fatalf("expected the first unpaused node to be selected, got %v", got)
+	}
+	got = getCandidateMachines(pool, nodes, 4, checkNodeReady, nil, nil, nil)
+	var names []string
+	for _, node := range got {
+		names = append(names, node.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"node-2", "node-3"}) {
+		t.Fatalf("mismatch candidates: got %v want: [node-2 node-3]", names)
+	}
+}
+
+func TestSetPausedNodes(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	nodes := []*corev1.Node{
+		withAnnotation(newNode("node-1", "v0", "v0"), pausedAnnotationKey, "true"),
+		newNode("node-2", "v0", "v0"),
+		withAnnotation(newNode("node-0", "v0", "v0"), pausedAnnotationKey, "true"),
+	}
+
+	status := pool.Status
+	c.setPausedNodes(pool, &status, nodes)
+	if !reflect.DeepEqual(status.PausedNodes, []string{"node-0", "node-1"}) {
+		t.Fatalf("mismatch paused nodes: got %v want: [node-0 node-1]", status.PausedNodes)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal NodesPaused") || !strings.Contains(event, "node-0, node-1") {
+		t.Fatalf("expected an event listing the paused nodes, got %q", event)
+	}
+
+	// Unchanged paused nodes aren't announced again.
+	pool.Status = status
+	c.setPausedNodes(pool, &status, nodes)
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no further event, got %q", <-recorder.Events)
+	}
+
+	pool.Status = status
+	c.setPausedNodes(pool, &status, nodes[1:2])
+	if status.PausedNodes != nil {
+		t.Fatalf("expected no paused nodes, got %v", status.PausedNodes)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal NodesPaused") {
+		t.Fatalf("expected an event once no nodes are paused, got %q", event)
+	}
+}
@@ -0,0 +1,45 @@
+package node
+
+import (
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RolloutComplete is sent when every node of a pool got updated to its target config.
+type RolloutComplete struct {
+	// Pool is the name of the pool.
+	Pool string
+	// Config is the name of the MachineConfig the pool was updated to.
+	Config string
+}
+
+// WithRolloutCompleteNotifications sends a RolloutComplete to notify once every time
+// a pool finishes updating its nodes. The send doesn't block: the notification is
+// dropped if notify isn't ready to receive it, so notify should be buffered. A
+// RolloutComplete event is recorded on the pool either way.
+func WithRolloutCompleteNotifications(notify chan<- RolloutComplete) Option {
+	return func(ctrl *Controller) {
+		ctrl.rolloutComplete = notify
+	}
+}
+
+// isRolloutComplete returns whether the pool just finished updating, going from oldStatus
+// to newStatus. Pools that never reported being updating don't count.
+func isRolloutComplete(oldStatus, newStatus mcfgv1.MachineConfigPoolStatus) bool {
+	return mcfgv1.IsMachineConfigPoolConditionFalse(oldStatus.Conditions, mcfgv1.MachineConfigPoolUpdated) &&
+		mcfgv1.IsMachineConfigPoolConditionTrue(newStatus.Conditions, mcfgv1.MachineConfigPoolUpdated)
+}
+
+// notifyRolloutComplete records that pool finished updating to config.
+func (ctrl *Controller) notifyRolloutComplete(pool *mcfgv1.MachineConfigPool, config string) {
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "RolloutComplete", "All nodes are updated to %s", config)
+	if ctrl.rolloutComplete == nil {
+		return
+	}
+	select {
+	case ctrl.rolloutComplete <- RolloutComplete{Pool: pool.Name, Config: config}:
+	default:
+		glog.Warningf("Pool %s: dropped rollout complete notification for %s, receiver is not ready", pool.Name, config)
+	}
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newStatusWithUpdated(status corev1.ConditionStatus) mcfgv1.MachineConfigPoolStatus {
+	var s mcfgv1.MachineConfigPoolStatus
+	if status != "" {
+		mcfgv1.SetMachineConfigPoolCondition(&s, *mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, status, "", ""))
+	}
+	return s
+}
+
+func TestIsRolloutComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, cur corev1.ConditionStatus
+		expected bool
+	}{
+		{name: "finished updating", old: corev1.ConditionFalse, cur: corev1.ConditionTrue, expected: true},
+		{name: "still updating", old: corev1.ConditionFalse, cur: corev1.ConditionFalse},
+		{name: "already updated", old: corev1.ConditionTrue, cur: corev1.ConditionTrue},
+		{name: "new pool", old: "", cur: corev1.ConditionTrue},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isRolloutComplete(newStatusWithUpdated(test.old), newStatusWithUpdated(test.cur)); got != test.expected {
+				t.Fatalf("mismatch: got %v want: %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestRolloutCompleteNotification(t *testing.T) {
+	f := newFixture(t)
+	notify := make(chan RolloutComplete, 1)
+	f.opts = append(f.opts, WithRolloutCompleteNotifications(notify))
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Status = newStatusWithUpdated(corev1.ConditionFalse)
+	mcp.Status.Configuration.Name = "v0"
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expMcp := mcp.DeepCopy()
+	expMcp.Status = calculateStatus(mcp, nodes, checkNodeReady)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+
+	select {
+	case got := <-notify:
+		if want := (RolloutComplete{Pool: "test-cluster-master", Config: "v1"}); got != want {
+			t.Fatalf("mismatch notification: got %v want: %v", got, want)
+		}
+	default:
+		t.Fatalf("expected a rollout complete notification")
+	}
+}
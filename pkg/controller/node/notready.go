@@ -0,0 +1,81 @@
+package node
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// notReadyGraceRemaining returns how much longer node still counts as ready under grace
+// while it reports NotReady, or zero if it doesn't, e.g. because it's NotReady for a
+// reason grace doesn't cover.
+func notReadyGraceRemaining(grace *mcfgv1.MachineConfigPoolNotReadyGrace, node *corev1.Node, now time.Time) time.Duration {
+	if grace == nil {
+		return 0
+	}
+	for i := range node.Status.Conditions {
+		cond := &node.Status.Conditions[i]
+		if cond.Type != corev1.NodeReady || cond.Status == corev1.ConditionTrue {
+			continue
+		}
+		for _, reason := range grace.Reasons {
+			if cond.Reason != reason {
+				continue
+			}
+			if remaining := cond.LastTransitionTime.Add(grace.Period.Duration).Sub(now); remaining > 0 {
+				return remaining
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// withNotReadyGrace wraps checkReady so that nodes reporting NotReady within the grace
+// of pool at now are checked as if their NodeReady condition were true; every other
+// check still applies to them. A nil checkReady uses the default node readiness check.
+func withNotReadyGrace(pool *mcfgv1.MachineConfigPool, checkReady NodeReadyChecker, now time.Time) NodeReadyChecker {
+	if checkReady == nil {
+		checkReady = checkNodeReady
+	}
+	grace := pool.Spec.NotReadyGrace
+	if grace == nil {
+		return checkReady
+	}
+	return func(node *corev1.Node) error {
+		if notReadyGraceRemaining(grace, node, now) <= 0 {
+			return checkReady(node)
+		}
+		graced := node.DeepCopy()
+		for i := range graced.Status.Conditions {
+			if graced.Status.Conditions[i].Type == corev1.NodeReady {
+				graced.Status.Conditions[i].Status = corev1.ConditionTrue
+			}
+		}
+		return checkReady(graced)
+	}
+}
+
+// poolReadyChecker returns the readiness check of the nodes of pool, which honors its
+// NotReady grace and required conditions, and syncs pool again once the first grace
+// period of nodes runs out.
+func (ctrl *Controller) poolReadyChecker(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) NodeReadyChecker {
+	now := time.Now()
+	var first time.Duration
+	for _, node := range nodes {
+		remaining := notReadyGraceRemaining(pool.Spec.NotReadyGrace, node, now)
+		if remaining <= 0 {
+			continue
+		}
+		glog.V(4).Infof("Pool %s: node %s is NotReady, counting it as ready for another %v", pool.Name, node.Name, remaining)
+		if first == 0 || remaining < first {
+			first = remaining
+		}
+	}
+	if first > 0 {
+		ctrl.enqueueAfter(pool, first)
+	}
+	return withRequiredConditions(pool, withNotReadyGrace(pool, ctrl.nodeReadyChecker, now))
+}
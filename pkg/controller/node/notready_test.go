@@ -0,0 +1,76 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNotReadyGrace(t *testing.T) {
+	now := time.Now()
+	grace := &mcfgv1.MachineConfigPoolNotReadyGrace{
+		Reasons: []string{"NetworkPluginNotReady"},
+		Period:  metav1.Duration{Duration: 5 * time.Minute},
+	}
+	tests := []struct {
+		name    string
+		grace   *mcfgv1.MachineConfigPoolNotReadyGrace
+		reason  string
+		since   time.Duration
+		network corev1.ConditionStatus
+		ready   bool
+	}{{
+		name:   "no grace",
+		reason: "NetworkPluginNotReady",
+		since:  time.Minute,
+	}, {
+		name:   "graced reason within the period",
+		grace:  grace,
+		reason: "NetworkPluginNotReady",
+		since:  time.Minute,
+		ready:  true,
+	}, {
+		name:   "graced reason past the period",
+		grace:  grace,
+		reason: "NetworkPluginNotReady",
+		since:  10 * time.Minute,
+	}, {
+		name:   "other reason",
+		grace:  grace,
+		reason: "KubeletNotReady",
+		since:  time.Minute,
+	}, {
+		name:    "graced reason with the network unavailable",
+		grace:   grace,
+		reason:  "NetworkPluginNotReady",
+		since:   time.Minute,
+		network: corev1.ConditionTrue,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+			pool.Spec.NotReadyGrace = test.grace
+			node := newNodeWithReady("node-0", "v1", "v1", corev1.ConditionFalse)
+			node.Status.Conditions[0].Reason = test.reason
+			node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-test.since))
+			if test.network != "" {
+				node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: corev1.NodeNetworkUnavailable, Status: test.network})
+			}
+			err := withNotReadyGrace(pool, nil, now)(node)
+			if ready := err == nil; ready != test.ready {
+				t.Fatalf("expected ready %v, got error %v", test.ready, err)
+			}
+			if unavailable := IsNodeUnavailable(node, mcfgv1.UnavailabilityPolicyStrict, withNotReadyGrace(pool, nil, now)); unavailable == test.ready {
+				t.Fatalf("expected unavailable %v, got %v", !test.ready, unavailable)
+			}
+			if node.Status.Conditions[0].Status != corev1.ConditionFalse {
+				t.Fatalf("expected the node not to be modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// pauseTracker remembers when pools were seen being paused, until their status
+// records it, and when they were last warned about being paused for too long.
+type pauseTracker struct {
+	lock   sync.Mutex
+	paused map[string]time.Time
+	warned map[string]time.Time
+}
+
+func newPauseTracker() *pauseTracker {
+	return &pauseTracker{paused: map[string]time.Time{}, warned: map[string]time.Time{}}
+}
+
+// pause records that pool was paused at t.
+func (p *pauseTracker) pause(pool string, t time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.paused[pool] = t
+	delete(p.warned, pool)
+}
+
+// forget drops the pause time of pool, e.g. because it was unpaused.
+func (p *pauseTracker) forget(pool string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.paused, pool)
+	delete(p.warned, pool)
+}
+
+// since returns when pool was seen being paused, or now if that wasn't observed,
+// e.g. because the controller restarted.
+func (p *pauseTracker) since(pool string, now time.Time) time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if t, ok := p.paused[pool]; ok {
+		return t
+	}
+	return now
+}
+
+// warn returns whether pool is due a warning at now, i.e. it was never warned about
+// or last warned at least every ago, and if so records now as its last warning.
+// Otherwise it returns how long until the next warning is due.
+func (p *pauseTracker) warn(pool string, now time.Time, every time.Duration) (bool, time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if last, ok := p.warned[pool]; ok {
+		if remaining := last.Add(every).Sub(now); remaining > 0 {
+			return false, remaining
+		}
+	}
+	p.warned[pool] = now
+	return true, every
+}
+
+// WithPausedWarnAfter sets the PausedTooLong condition of pools paused for longer than
+// after, and repeats a warning event about them every after until they are unpaused.
+// Zero, the default, never warns.
+func WithPausedWarnAfter(after time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.pausedWarnAfter = after
+	}
+}
+
+// pausedTooLong returns whether pool has been paused for at least after at now, and
+// otherwise how long until it will have been.
+func pausedTooLong(pool *mcfgv1.MachineConfigPool, after time.Duration, now time.Time) (bool, time.Duration) {
+	if !pool.Spec.Paused || pool.Status.PausedSince == nil || after <= 0 {
+		return false, 0
+	}
+	if remaining := pool.Status.PausedSince.Add(after).Sub(now); remaining > 0 {
+		return false, remaining
+	}
+	return true, 0
+}
+
+// checkPaused records on the status of pool since when it is paused, and warns about
+// it once it has been paused for too long. The PausedTooLong condition is cleared when
+// the pool is unpaused.
+func (ctrl *Controller) checkPaused(pool *mcfgv1.MachineConfigPool, now time.Time) {
+	if !pool.Spec.Paused {
+		pool.Status.PausedSince = nil
+		ctrl.pauses.forget(pool.Name)
+		if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolPausedTooLong) {
+			spaused := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolPausedTooLong, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *spaused)
+		}
+		return
+	}
+	if pool.Status.PausedSince == nil {
+		since := metav1.NewTime(ctrl.pauses.since(pool.Name, now))
+		pool.Status.PausedSince = &since
+	}
+
+	tooLong, remaining := pausedTooLong(pool, ctrl.pausedWarnAfter, now)
+	if !tooLong {
+		if remaining > 0 {
+			ctrl.enqueueAfter(pool, remaining)
+		}
+		return
+	}
+	message := fmt.Sprintf("Paused since %s, nodes are not updated to %s", pool.Status.PausedSince.UTC().Format(time.RFC3339), pool.Spec.Configuration.Name)
+	spaused := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolPausedTooLong, corev1.ConditionTrue, "Paused", message)
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *spaused)
+	due, next := ctrl.pauses.warn(pool.Name, now, ctrl.pausedWarnAfter)
+	if due {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "PausedTooLong", "This machineconfigpool has been paused for over %v: %s", ctrl.pausedWarnAfter, message)
+	}
+	ctrl.enqueueAfter(pool, next)
+}
@@ -0,0 +1,108 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestPausedTooLong(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		paused    bool
+		since     time.Duration
+		after     time.Duration
+		tooLong   bool
+		remaining time.Duration
+	}{{
+		name:   "not paused",
+		paused: false,
+		after:  time.Hour,
+	}, {
+		name:   "no threshold",
+		paused: true,
+		since:  48 * time.Hour,
+	}, {
+		name:      "recently paused",
+		paused:    true,
+		since:     20 * time.Minute,
+		after:     time.Hour,
+		remaining: 40 * time.Minute,
+	}, {
+		name:    "at the threshold",
+		paused:  true,
+		since:   time.Hour,
+		after:   time.Hour,
+		tooLong: true,
+	}, {
+		name:    "past the threshold",
+		paused:  true,
+		since:   3 * time.Hour,
+		after:   time.Hour,
+		tooLong: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, nil, "v1")
+			pool.Spec.Paused = test.paused
+			since := metav1.NewTime(now.Add(-test.since))
+			pool.Status.PausedSince = &since
+			tooLong, remaining := pausedTooLong(pool, test.after, now)
+			if tooLong != test.tooLong || remaining != test.remaining {
+				t.Fatalf("mismatch: got %t, %v want: %t, %v", tooLong, remaining, test.tooLong, test.remaining)
+			}
+		})
+	}
+}
+
+func TestCheckPaused(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithPausedWarnAfter(time.Hour))
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	now := time.Now()
+
+	old := newMachineConfigPool("worker", nil, nil, "v1")
+	cur := old.DeepCopy()
+	cur.Spec.Paused = true
+	c.updateMachineConfigPool(old, cur)
+	c.checkPaused(cur, now.Add(2*time.Hour))
+	if cur.Status.PausedSince == nil || cur.Status.PausedSince.Time.Before(now) || cur.Status.PausedSince.Time.After(now.Add(time.Hour)) {
+		t.Fatalf("expected the time the pool was paused to be recorded, got %v", cur.Status.PausedSince)
+	}
+	if !mcfgv1.IsMachineConfigPoolConditionTrue(cur.Status.Conditions, mcfgv1.MachineConfigPoolPausedTooLong) {
+		t.Fatalf("expected the PausedTooLong condition past the threshold")
+	}
+	select {
+	case got := <-recorder.Events:
+		if want := "Warning PausedTooLong"; !strings.HasPrefix(got, want) {
+			t.Fatalf("mismatch event: got %q want prefix: %q", got, want)
+		}
+	default:
+		t.Fatalf("expected a warning event about the paused pool")
+	}
+
+	// The warning is repeated once per threshold, not on every sync.
+	c.checkPaused(cur, now.Add(2*time.Hour+30*time.Minute))
+	if got := len(recorder.Events); got != 0 {
+		t.Fatalf("expected no warning before the threshold passed again, got %d events", got)
+	}
+	c.checkPaused(cur, now.Add(3*time.Hour))
+	if got := len(recorder.Events); got != 1 {
+		t.Fatalf("expected the warning to be repeated once the threshold passed again, got %d events", got)
+	}
+	<-recorder.Events
+
+	cur.Spec.Paused = false
+	c.checkPaused(cur, now.Add(4*time.Hour))
+	if cur.Status.PausedSince != nil || mcfgv1.IsMachineConfigPoolConditionTrue(cur.Status.Conditions, mcfgv1.MachineConfigPoolPausedTooLong) {
+		t.Fatalf("expected unpausing to clear the pause time and condition")
+	}
+}
@@ -0,0 +1,49 @@
+package node
+
+import "sync"
+
+// poolEventTracker remembers the value last reported by the events of pools that a sync
+// would otherwise record every time, so that they are only recorded when it changes.
+type poolEventTracker struct {
+	lock   sync.Mutex
+	values map[string]map[string]string
+}
+
+func newPoolEventTracker() *poolEventTracker {
+	return &poolEventTracker{values: map[string]map[string]string{}}
+}
+
+// changed records value as the latest one of the reason events of pool, and returns
+// whether it differs from the one recorded before, if any.
+func (t *poolEventTracker) changed(pool, reason, value string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	values, ok := t.values[pool]
+	if !ok {
+		values = map[string]string{}
+		t.values[pool] = values
+	}
+	if previous, ok := values[reason]; ok && previous == value {
+		return false
+	}
+	values[reason] = value
+	return true
+}
+
+// clear forgets the reason events of pool, e.g. once their cause is gone, so that they
+// are recorded again if it comes back.
+func (t *poolEventTracker) clear(pool, reason string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.values[pool], reason)
+}
+
+// forget forgets the events of pool, e.g. once it is deleted.
+func (t *poolEventTracker) forget(pool string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.values, pool)
+}
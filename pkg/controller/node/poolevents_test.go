@@ -0,0 +1,32 @@
+package node
+
+import "testing"
+
+func TestPoolEventTracker(t *testing.T) {
+	tracker := newPoolEventTracker()
+	steps := []struct {
+		pool, value string
+		clear       bool
+		expected    bool
+	}{
+		{pool: "worker", value: "2", expected: true},
+		{pool: "worker", value: "2", expected: false},
+		{pool: "infra", value: "2", expected: true},
+		{pool: "worker", value: "3", expected: true},
+		{pool: "worker", clear: true},
+		{pool: "worker", value: "3", expected: true},
+	}
+	for i, step := range steps {
+		if step.clear {
+			tracker.clear(step.pool, "MaxUnavailableOverridden")
+			continue
+		}
+		if got := tracker.changed(step.pool, "MaxUnavailableOverridden", step.value); got != step.expected {
+			t.Fatalf("step %d: mismatch changed for %s=%s: got %v want: %v", i, step.pool, step.value, got, step.expected)
+		}
+	}
+	tracker.forget("infra")
+	if !tracker.changed("infra", "MaxUnavailableOverridden", "2") {
+		t.Fatalf("expected a forgotten pool to report its events again")
+	}
+}
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// poolNodeSelector selects the nodes of a pool: those matching any of its node selectors.
+type poolNodeSelector []labels.Selector
+
+// nodeSelectorsOf returns the node selector of pool followed by its additional ones.
+func nodeSelectorsOf(pool *mcfgv1.MachineConfigPool) []*metav1.LabelSelector {
+	return append([]*metav1.LabelSelector{pool.Spec.NodeSelector}, pool.Spec.NodeSelectors...)
+}
+
+// newPoolNodeSelector returns the selector of the nodes of pool. If a nil or empty
+// selector creeps in, it matches nothing, not everything.
+func newPoolNodeSelector(pool *mcfgv1.MachineConfigPool) (poolNodeSelector, error) {
+	var selector poolNodeSelector
+	for _, ls := range nodeSelectorsOf(pool) {
+		s, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+		if s.Empty() {
+			continue
+		}
+		selector = append(selector, s)
+	}
+	return selector, nil
+}
+
+// Matches returns whether a node with the given labels is selected.
+func (s poolNodeSelector) Matches(set labels.Labels) bool {
+	for _, selector := range s {
+		if selector.Matches(set) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s poolNodeSelector) String() string {
+	if len(s) == 0 {
+		return "<none>"
+	}
+	parts := make([]string, 0, len(s))
+	for _, selector := range s {
+		parts = append(parts, selector.String())
+	}
+	return strings.Join(parts, " or ")
+}
+
+// checkEmptyPool reports the NoMatchingNodes condition on pool while selector matches
+// none of the nodes, and records an event naming the selector when it starts to. Pools
+// can be empty on purpose, so this is advisory only.
+func (ctrl *Controller) checkEmptyPool(pool *mcfgv1.MachineConfigPool, selector poolNodeSelector, nodes []*corev1.Node) {
+	empty := mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolNoMatchingNodes)
+	if len(nodes) > 0 {
+		if empty {
+			sempty := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolNoMatchingNodes, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sempty)
+		}
+		return
+	}
+	message := fmt.Sprintf("node selector %s matches no node", selector)
+	if !empty {
+		glog.Infof("Pool %s: %s", pool.Name, message)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "NoMatchingNodes", "The %s, check that it is intended", message)
+	}
+	sempty := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolNoMatchingNodes, corev1.ConditionTrue, "SelectorMatchesNoNodes", message)
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sempty)
+}
+
+// getNodesForPool returns the nodes selected by any of the node selectors of pool.
+func (ctrl *Controller) getNodesForPool(pool *mcfgv1.MachineConfigPool) ([]*corev1.Node, error) {
+	selector, err := newPoolNodeSelector(pool)
+	if err != nil {
+		return nil, err
+	}
+	all, err := ctrl.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*corev1.Node
+	for _, node := range all {
+		if selector.Matches(labels.Set(node.Labels)) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
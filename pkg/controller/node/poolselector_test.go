@@ -0,0 +1,140 @@
+package node
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func newRackPool(name string, racks ...string) *mcfgv1.MachineConfigPool {
+	pool := newMachineConfigPool(name, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "rack", racks[0]), intStrPtr(intstr.FromInt(1)), "v1")
+	for _, rack := range racks[1:] {
+		pool.Spec.NodeSelectors = append(pool.Spec.NodeSelectors, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "rack", rack))
+	}
+	return pool
+}
+
+func TestNodeSelectorsMatchAny(t *testing.T) {
+	f := newFixture(t)
+	pool := newRackPool("racks", "a", "b")
+	f.mcpLister = append(f.mcpLister, pool)
+	f.nodeLister = append(f.nodeLister,
+		newNodeWithLabel("node-a", "v1", "v1", map[string]string{"rack": "a"}),
+		newNodeWithLabel("node-b", "v1", "v1", map[string]string{"rack": "b"}),
+		newNodeWithLabel("node-c", "v1", "v1", map[string]string{"rack": "c"}),
+	)
+	c := f.newController()
+
+	nodes, err := c.getNodesForPool(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"node-a", "node-b"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected the pool to select %v, got %v", want, names)
+	}
+
+	for _, node := range f.nodeLister {
+		got, err := c.getPoolForNode(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selected := got != nil; selected != (node.Name != "node-c") {
+			t.Fatalf("unexpected pool %v for node %s", got, node.Name)
+		}
+	}
+}
+
+func TestNodeSelectorsOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    *mcfgv1.MachineConfigPool
+		overlap bool
+	}{{
+		name: "distinct racks",
+		a:    newRackPool("racks-ab", "a", "b"),
+		b:    newRackPool("racks-c", "c"),
+	}, {
+		name:    "overlap through an additional selector",
+		a:       newRackPool("racks-ab", "a", "b"),
+		b:       newRackPool("racks-bc", "c", "b"),
+		overlap: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conflicts, err := ValidatePoolSelectors([]*mcfgv1.MachineConfigPool{test.a, test.b})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(conflicts[test.a.Name]) > 0; got != test.overlap {
+				t.Fatalf("expected overlap %v, got conflicts %v", test.overlap, conflicts)
+			}
+		})
+	}
+}
+
+func TestEmptyNodeSelectorsMatchNothing(t *testing.T) {
+	pool := newRackPool("racks", "a")
+	pool.Spec.NodeSelectors = []*metav1.LabelSelector{{}, nil}
+	selector, err := newPoolNodeSelector(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := newNodeWithLabel("node-c", "v1", "v1", map[string]string{"rack": "c"})
+	if selector.Matches(labels.Set(node.Labels)) {
+		t.Fatalf("expected empty node selectors not to select every node")
+	}
+}
+
+func TestNoMatchingNodes(t *testing.T) {
+	f := newFixture(t)
+	pool := newRackPool("worker", "a", "b")
+	f.mcpLister = append(f.mcpLister, pool)
+	f.objects = append(f.objects, pool)
+	f.mcLister = append(f.mcLister, newMachineConfig("v1"))
+	node := newNodeWithLabel("node-0", "v1", "v1", map[string]string{"rack": "c"})
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	recorder := &objectRecorder{}
+	c.eventRecorder = recorder
+
+	if err := c.syncHandler(getKey(pool, t)); err != nil {
+		t.Fatal(err)
+	}
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	if status == nil {
+		t.Fatalf("expected the status of the pool to be written")
+	}
+	cond := mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolNoMatchingNodes)
+	if cond == nil || cond.Status != corev1.ConditionTrue || !strings.Contains(cond.Message, "rack=a or rack=b") {
+		t.Fatalf("expected the pool to report that its selector matches no node, got %v", status.Conditions)
+	}
+	if !recorder.has("pool/worker", "NoMatchingNodes") {
+		t.Fatalf("expected a NoMatchingNodes event, got %v", recorder.events)
+	}
+
+	// The condition clears once a node matches.
+	pool.Status = *status
+	c.checkEmptyPool(pool, nil, []*corev1.Node{node})
+	if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolNoMatchingNodes) {
+		t.Fatalf("expected the condition to clear, got %v", pool.Status.Conditions)
+	}
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"math"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// WithPrePullLookahead marks up to depth nodes of a pool that are due to update after the
+// current candidates with the pre-pull annotation, so their daemons can pull the OS image
+// of the target config while other nodes update. The annotation is removed again once a
+// node is selected for its update or leaves the lookahead. Zero, the default, disables
+// pre-pulling.
+func WithPrePullLookahead(depth int) Option {
+	return func(ctrl *Controller) {
+		ctrl.prePullLookahead = depth
+	}
+}
+
+// getLookaheadMachines returns up to depth nodes of the pool that would be selected for
+// an update after candidates, in the order they would be selected.
+func getLookaheadMachines(pool *mcfgv1.MachineConfigPool, nodesInPool, candidates []*corev1.Node, depth int, checkReady NodeReadyChecker, masterOrdering MasterOrdering, held sets.String, podCounts map[string]int) []*corev1.Node {
+	if depth <= 0 {
+		return nil
+	}
+	selected := sets.NewString()
+	for _, node := range candidates {
+		selected.Insert(node.Name)
+	}
+	var lookahead []*corev1.Node
+	for _, node := range getCandidateMachines(pool, nodesInPool, math.MaxInt32, checkReady, masterOrdering, held, podCounts) {
+		if selected.Has(node.Name) {
+			continue
+		}
+		lookahead = append(lookahead, node)
+		if len(lookahead) == depth {
+			break
+		}
+	}
+	return lookahead
+}
+
+// updatePrePullAnnotations asks the lookahead nodes of pool to pull the OS image of the
+// config target moves them to ahead of their update, and clears the pre-pull annotation of
+// its other nodes, e.g. once they were selected for an update or dropped out of the
+// lookahead. Only nodes whose annotation, as seen in nodes, differs are patched.
+func (ctrl *Controller) updatePrePullAnnotations(pool, target *mcfgv1.MachineConfigPool, nodes, lookahead []*corev1.Node) {
+	prePull := map[string]string{}
+	for _, node := range lookahead {
+		prePull[node.Name] = nextConfig(target, node)
+	}
+	for _, node := range nodes {
+		config := prePull[node.Name]
+		if node.Annotations[daemonconsts.PrePullMachineConfigAnnotationKey] == config {
+			continue
+		}
+		if config == "" {
+			glog.V(2).Infof("Pool %s: clearing the pre-pull annotation of node %s", pool.Name, node.Name)
+		} else {
+			glog.V(2).Infof("Pool %s: asking node %s to pre-pull config %s", pool.Name, node.Name, config)
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, daemonconsts.PrePullMachineConfigAnnotationKey, config); err != nil {
+			glog.Warningf("Pool %s: failed to update the pre-pull annotation of node %s: %v", pool.Name, node.Name, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestPrePullLookahead(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithPrePullLookahead(2))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", labels),
+		newNodeWithLabel("node-1", "v0", "v0", labels),
+		withAnnotation(newNodeWithLabel("node-2", "v0", "v0", labels), pinConfigAnnotationKey, "v0"),
+		newNodeWithLabel("node-3", "v0", "v0", labels),
+		newNodeWithLabel("node-4", "v0", "v0", labels),
+		newNodeWithLabel("node-5", "v0", "v0", labels),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pinned node counts against maxUnavailable, leaving room to update one node. Two
+	// of the remaining ones, never the pinned one, are asked to pre-pull.
+	updated, prePulling := 0, 0
+	for _, name := range []string{"node-1", "node-2", "node-3", "node-4", "node-5"} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		prePull := node.Annotations[daemonconsts.PrePullMachineConfigAnnotationKey]
+		switch {
+		case name == "node-2" && (desired != "v0" || prePull != ""):
+			t.Fatalf("expected the pinned node to be left alone, got desired %q pre-pull %q", desired, prePull)
+		case desired == "v1" && prePull != "":
+			t.Fatalf("expected no pre-pull on updating node %s", name)
+		case desired == "v1":
+			updated++
+		case prePull == "v1":
+			prePulling++
+		}
+	}
+	if updated != 1 || prePulling != 2 {
+		t.Fatalf("expected 1 node updated and 2 pre-pulling, got %d and %d", updated, prePulling)
+	}
+}
+
+func TestPrePullLookaheadDisabled(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+	if got := getLookaheadMachines(pool, nodes, nodes[:1], 0, checkNodeReady, nil, nil, nil); len(got) != 0 {
+		t.Fatalf("expected no look-ahead nodes when disabled, got %d", len(got))
+	}
+}
+
+func TestPrePullAnnotationsUpdated(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithPrePullLookahead(1))
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	nodes := []*corev1.Node{
+		withAnnotation(newNode("node-0", "v1", "v1"), daemonconsts.PrePullMachineConfigAnnotationKey, "v1"),
+		newNode("node-1", "v0", "v0"),
+		withAnnotation(newNode("node-2", "v0", "v0"), daemonconsts.PrePullMachineConfigAnnotationKey, "v1"),
+		withAnnotation(newNode("node-3", "v0", "v0"), daemonconsts.PrePullMachineConfigAnnotationKey, "v1"),
+	}
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	// node-0 updated and node-3 dropped out of the lookahead, node-2 is already pre-pulling.
+	c.updatePrePullAnnotations(pool, pool, nodes, []*corev1.Node{nodes[1], nodes[2]})
+
+	patched := map[string]string{}
+	for _, action := range f.kubeclient.Actions() {
+		if action.GetVerb() == "get" {
+			if get, ok := action.(core.GetAction); ok && get.GetName() == "node-2" {
+				t.Fatalf("expected no lookup of node-2, already annotated")
+			}
+		}
+		if patch, ok := action.(core.PatchAction); ok {
+			patched[patch.GetName()] = string(patch.GetPatch())
+		}
+	}
+	if len(patched) != 3 {
+		t.Fatalf("expected node-0, node-1 and node-3 to be patched, got %v", patched)
+	}
+	for _, name := range []string{"node-0", "node-3"} {
+		if !strings.Contains(patched[name], `"`+daemonconsts.PrePullMachineConfigAnnotationKey+`":null`) {
+			t.Fatalf("expected the pre-pull annotation of %s to be cleared, got %s", name, patched[name])
+		}
+	}
+	if !strings.Contains(patched["node-1"], `"`+daemonconsts.PrePullMachineConfigAnnotationKey+`":"v1"`) {
+		t.Fatalf("expected node-1 to be asked to pre-pull v1, got %s", patched["node-1"])
+	}
+}
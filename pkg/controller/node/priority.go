@@ -0,0 +1,46 @@
+package node
+
+import (
+	"time"
+)
+
+// preemptedRecheckDelay is how long a pool that couldn't get global update slots for all
+// of its candidates waits before trying again. The progress of other pools doesn't sync it.
+const preemptedRecheckDelay = 30 * time.Second
+
+// poolDemand is what the last sync of a pool asked of the global budget.
+type poolDemand struct {
+	priority int32
+	// waiting is the number of candidates the sync couldn't give a slot to.
+	waiting int
+}
+
+// setDemand records the candidates of pool that its last sync selected but couldn't give
+// a slot to. Pools that didn't get to select candidates, e.g. because they're paused,
+// gated or waiting for an approval, record none.
+func (b *globalBudget) setDemand(pool string, priority int32, waiting int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if waiting <= 0 {
+		delete(b.demand, pool)
+		return
+	}
+	b.demand[pool] = poolDemand{priority: priority, waiting: waiting}
+}
+
+// higherPriorityDemand returns the number of global update slots the pools with a higher
+// spec.rolloutPriority than priority are waiting for, which a pool of that priority has to
+// leave to them.
+func (b *globalBudget) higherPriorityDemand(priority int32) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	ahead := 0
+	for _, demand := range b.demand {
+		if demand.priority > priority {
+			ahead += demand.waiting
+		}
+	}
+	return ahead
+}
@@ -0,0 +1,108 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+// syncPriorityPools shares a global maxUnavailable of one between a routine and a
+// security pool, given to routine-1 updating at first. The security pool is synced,
+// routine-1 finishes its update, then the routine pool and the security pool are synced
+// again. The security pool is passed to setup first. It returns the patched nodes.
+func syncPriorityPools(t *testing.T, setup func(*mcfgv1.MachineConfigPool)) []string {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithGlobalMaxUnavailable(1))
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	for _, role := range []string{"routine", "security"} {
+		mcp := newMachineConfigPool(role, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/"+role, ""), intStrPtr(intstr.FromInt(1)), "v1")
+		if role == "security" {
+			setup(mcp)
+		}
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.objects = append(f.objects, mcp)
+		node := newNodeWithLabel(role+"-0", "v0", "v0", map[string]string{"node-role/" + role: ""})
+		f.nodeLister = append(f.nodeLister, node)
+		f.kubeobjects = append(f.kubeobjects, node)
+	}
+	updating := newNodeWithLabel("routine-1", "v0", "v1", map[string]string{"node-role/routine": ""})
+	f.nodeLister = append(f.nodeLister, updating)
+	f.kubeobjects = append(f.kubeobjects, updating)
+	c := f.newController()
+
+	sync := func(key string) {
+		if err := c.syncHandler(key); err != nil {
+			t.Fatalf("error syncing %s: %v", key, err)
+		}
+	}
+	sync("security")
+	updated := updating.DeepCopy()
+	updated.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] = "v1"
+	updated.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+	f.kubeinformers.Core().V1().Nodes().Informer().GetIndexer().Update(updated)
+	sync("routine")
+	sync("security")
+
+	var patched []string
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok {
+			patched = append(patched, patch.GetName())
+		}
+	}
+	return patched
+}
+
+func TestRolloutPriorityPreemptsGlobalSlots(t *testing.T) {
+	// The routine pool leaves the freed slot to the waiting security pool.
+	patched := syncPriorityPools(t, func(pool *mcfgv1.MachineConfigPool) {
+		pool.Spec.RolloutPriority = 10
+	})
+	if !reflect.DeepEqual(patched, []string{"security-0"}) {
+		t.Fatalf("expected the security pool to take the global slot, got patches for %v", patched)
+	}
+	// With equal priorities, the pool synced first takes it.
+	patched = syncPriorityPools(t, func(pool *mcfgv1.MachineConfigPool) {})
+	if !reflect.DeepEqual(patched, []string{"routine-0"}) {
+		t.Fatalf("expected the routine pool to take the global slot, got patches for %v", patched)
+	}
+	// A security pool with nothing to update doesn't hold back the routine pool.
+	patched = syncPriorityPools(t, func(pool *mcfgv1.MachineConfigPool) {
+		pool.Spec.RolloutPriority = 10
+		pool.Spec.Configuration.Name = "v0"
+	})
+	if !reflect.DeepEqual(patched, []string{"routine-0"}) {
+		t.Fatalf("expected the routine pool to take the unneeded slot, got patches for %v", patched)
+	}
+	// Nor does a security pool that can't pick candidates, here waiting for approval.
+	patched = syncPriorityPools(t, func(pool *mcfgv1.MachineConfigPool) {
+		pool.Spec.RolloutPriority = 10
+		pool.Spec.RequireApproval = true
+	})
+	if !reflect.DeepEqual(patched, []string{"routine-0"}) {
+		t.Fatalf("expected the routine pool to take the slot of the waiting pool, got patches for %v", patched)
+	}
+}
+
+func TestHigherPriorityDemand(t *testing.T) {
+	b := newGlobalBudget(3)
+	b.setDemand("security", 10, 2)
+	b.setDemand("infra", 5, 1)
+	b.setDemand("routine", 0, 1)
+
+	if ahead := b.higherPriorityDemand(0); ahead != 3 {
+		t.Fatalf("expected 3 slots left to higher priorities, got %d", ahead)
+	}
+	if ahead := b.higherPriorityDemand(5); ahead != 2 {
+		t.Fatalf("expected 2 slots left to higher priorities, got %d", ahead)
+	}
+	// A sync without waiting candidates drops the demand of its pool.
+	b.setDemand("security", 10, 0)
+	if ahead := b.higherPriorityDemand(0); ahead != 1 {
+		t.Fatalf("expected 1 slot left to higher priorities, got %d", ahead)
+	}
+}
@@ -0,0 +1,62 @@
+package node
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// propagateAnnotations copies the annotations of pool named in spec.propagateAnnotations
+// to its nodes, and removes the ones it copied before that are no longer propagated.
+func (ctrl *Controller) propagateAnnotations(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	want := map[string]string{}
+	for _, key := range pool.Spec.PropagateAnnotations {
+		if value, ok := pool.Annotations[key]; ok {
+			want[key] = value
+		}
+	}
+
+	var errs []error
+	for _, node := range nodes {
+		annotations := map[string]string{}
+		for key, value := range node.Annotations {
+			annotations[key] = value
+		}
+		applyPropagatedAnnotations(annotations, want)
+		if reflect.DeepEqual(annotations, node.Annotations) || len(annotations) == 0 && len(node.Annotations) == 0 {
+			continue
+		}
+		err := ctrl.updateNodeAnnotations(node.Name, func(annotations map[string]string) {
+			applyPropagatedAnnotations(annotations, want)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// applyPropagatedAnnotations changes the annotations of a node so that the annotations
+// propagated to it from its pool are exactly want.
+func applyPropagatedAnnotations(annotations, want map[string]string) {
+	for _, key := range strings.Split(annotations[propagatedAnnotationsAnnotationKey], ",") {
+		if _, ok := want[key]; !ok {
+			delete(annotations, key)
+		}
+	}
+	keys := make([]string, 0, len(want))
+	for key, value := range want {
+		annotations[key] = value
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		delete(annotations, propagatedAnnotationsAnnotationKey)
+		return
+	}
+	sort.Strings(keys)
+	annotations[propagatedAnnotationsAnnotationKey] = strings.Join(keys, ",")
+}
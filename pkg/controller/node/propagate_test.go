@@ -0,0 +1,77 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core "k8s.io/client-go/testing"
+)
+
+func TestApplyPropagatedAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"admin.example.com/owner":          "team-a",
+		"example.com/environment":          "staging",
+		"example.com/cost-center":          "1",
+		propagatedAnnotationsAnnotationKey: "example.com/cost-center,example.com/environment",
+	}
+	applyPropagatedAnnotations(annotations, map[string]string{
+		"example.com/cost-center": "2",
+		"example.com/team":        "infra",
+	})
+	expected := map[string]string{
+		"admin.example.com/owner":          "team-a",
+		"example.com/cost-center":          "2",
+		"example.com/team":                 "infra",
+		propagatedAnnotationsAnnotationKey: "example.com/cost-center,example.com/team",
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Fatalf("mismatch annotations: got %v want: %v", annotations, expected)
+	}
+
+	applyPropagatedAnnotations(annotations, map[string]string{})
+	if expected := map[string]string{"admin.example.com/owner": "team-a"}; !reflect.DeepEqual(annotations, expected) {
+		t.Fatalf("expected only the admin annotation to be left, got %v", annotations)
+	}
+}
+
+func TestPropagateAnnotations(t *testing.T) {
+	f := newFixture(t)
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		withAnnotation(withAnnotation(newNode("node-1", "v1", "v1"), "example.com/environment", "staging"), propagatedAnnotationsAnnotationKey, "example.com/environment"),
+	}
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Annotations = map[string]string{"example.com/cost-center": "1234", "example.com/environment": "staging"}
+	pool.Spec.PropagateAnnotations = []string{"example.com/cost-center"}
+
+	if err := c.propagateAnnotations(pool, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"node-0", "node-1"} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := node.Annotations["example.com/cost-center"]; got != "1234" {
+			t.Fatalf("expected the cost center to be propagated to %s, got %q", name, got)
+		}
+	}
+	// The fake client can't remove annotations, so look at the patch of node-1.
+	removed := false
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if patch, ok := action.(core.PatchAction); ok && patch.GetName() == "node-1" {
+			removed = strings.Contains(string(patch.GetPatch()), `"example.com/environment":null`)
+		}
+	}
+	if !removed {
+		t.Fatalf("expected the annotation no longer propagated to be removed from node-1")
+	}
+}
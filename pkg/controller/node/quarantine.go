@@ -0,0 +1,127 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// failureTracker counts how many times in a row every node failed to apply its desired config.
+type failureTracker struct {
+	lock     sync.Mutex
+	failures map[string]nodeFailures
+}
+
+type nodeFailures struct {
+	config string
+	count  int
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{failures: map[string]nodeFailures{}}
+}
+
+// WithQuarantineThreshold quarantines nodes that failed to apply the same desired config
+// threshold times in a row: they are annotated, no longer selected for an update and no
+// longer count against maxUnavailable, so the rest of their pool can proceed. A node
+// leaves quarantine once it stops failing or its pool moves it to another config. Zero,
+// the default, never quarantines nodes.
+func WithQuarantineThreshold(threshold int) Option {
+	return func(ctrl *Controller) {
+		ctrl.quarantineThreshold = threshold
+	}
+}
+
+// fail records that node failed to apply config.
+func (t *failureTracker) fail(node, config string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	f := t.failures[node]
+	if f.config != config {
+		f = nodeFailures{config: config}
+	}
+	f.count++
+	t.failures[node] = f
+	return f.count
+}
+
+// count returns how many times in a row node failed to apply config.
+func (t *failureTracker) count(node, config string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if f := t.failures[node]; f.config == config {
+		return f.count
+	}
+	return 0
+}
+
+// forget drops the failures of node, e.g. because it applied its config.
+func (t *failureTracker) forget(node string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.failures, node)
+}
+
+// isNodeQuarantined checks whether the node controller quarantined a node.
+func isNodeQuarantined(node *corev1.Node) bool {
+	return node.Annotations[quarantinedAnnotationKey] != ""
+}
+
+// withoutQuarantined returns the nodes that are not quarantined.
+func withoutQuarantined(nodes []*corev1.Node) []*corev1.Node {
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		if !isNodeQuarantined(node) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// updateQuarantine quarantines the nodes of the pool that failed too often and releases
+// the ones no longer failing, as well as the ones whose quarantined config isn't the one
+// the pool moves them to anymore, so that they get its new target. It returns nodes with
+// the updated nodes swapped in.
+func (ctrl *Controller) updateQuarantine(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) ([]*corev1.Node, error) {
+	if ctrl.quarantineThreshold <= 0 {
+		return nodes, nil
+	}
+	updated := make([]*corev1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		var value string
+		switch {
+		case isNodeQuarantined(node) && !isNodeMCDFailing(node):
+			glog.Infof("Pool %s: node %s is no longer failing, releasing it from quarantine", pool.Name, node.Name)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "NodeReleasedFromQuarantine", "Node %s is no longer failing and was released from quarantine", node.Name)
+		case isNodeQuarantined(node) && node.Annotations[quarantinedAnnotationKey] != nextConfig(pool, node):
+			glog.Infof("Pool %s: node %s was quarantined for %s, releasing it for %s", pool.Name, node.Name, node.Annotations[quarantinedAnnotationKey], nextConfig(pool, node))
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "NodeReleasedFromQuarantine", "Node %s was quarantined for %s and was released for %s", node.Name, node.Annotations[quarantinedAnnotationKey], nextConfig(pool, node))
+		case !isNodeQuarantined(node) && isNodeMCDFailing(node) && ctrl.failures.count(node.Name, desired) >= ctrl.quarantineThreshold:
+			value = desired
+			glog.Warningf("Pool %s: node %s failed to apply %s %d times in a row, quarantining it", pool.Name, node.Name, desired, ctrl.quarantineThreshold)
+			ctrl.eventRecorder.Eventf(node, corev1.EventTypeWarning, "NodeQuarantined", "Failed to apply %s %d times in a row, no longer holding back the pool", desired, ctrl.quarantineThreshold)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "NodeQuarantined", "Node %s failed to apply %s %d times in a row and was quarantined", node.Name, desired, ctrl.quarantineThreshold)
+		default:
+			updated = append(updated, node)
+			continue
+		}
+		if err := ctrl.setNodeAnnotation(node.Name, quarantinedAnnotationKey, value); err != nil {
+			return nil, err
+		}
+		node = node.DeepCopy()
+		if value == "" {
+			delete(node.Annotations, quarantinedAnnotationKey)
+		} else {
+			node.Annotations[quarantinedAnnotationKey] = value
+		}
+		updated = append(updated, node)
+	}
+	return updated, nil
+}
@@ -0,0 +1,176 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailureTracker(t *testing.T) {
+	tracker := newFailureTracker()
+	tracker.fail("node-0", "v1")
+	if got := tracker.fail("node-0", "v1"); got != 2 {
+		t.Fatalf("mismatch failures: got %d want: 2", got)
+	}
+	// Failing a different config starts over.
+	if got := tracker.fail("node-0", "v2"); got != 1 {
+		t.Fatalf("mismatch failures after a config change: got %d want: 1", got)
+	}
+	if got := tracker.count("node-0", "v1"); got != 0 {
+		t.Fatalf("mismatch failures of the previous config: got %d want: 0", got)
+	}
+	tracker.forget("node-0")
+	if got := tracker.count("node-0", "v2"); got != 0 {
+		t.Fatalf("mismatch failures after forgetting: got %d want: 0", got)
+	}
+}
+
+func TestUpdateNodeCountsFailures(t *testing.T) {
+	f := newFixture(t)
+	pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	f.mcpLister = append(f.mcpLister, pool)
+	c := f.newController()
+
+	labels := map[string]string{"node-role/worker": ""}
+	working := newNodeWithLabel("node-0", "v0", "v1", labels)
+	failing := working.DeepCopy()
+	failing.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	for i := 0; i < 3; i++ {
+		c.updateNode(working, failing)
+		c.updateNode(failing, failing.DeepCopy())
+		c.updateNode(failing, working)
+	}
+	if got := c.failures.count("node-0", "v1"); got != 3 {
+		t.Fatalf("mismatch failures: got %d want: 3", got)
+	}
+
+	done := newNodeWithLabel("node-0", "v1", "v1", labels)
+	c.updateNode(working, done)
+	if got := c.failures.count("node-0", "v1"); got != 0 {
+		t.Fatalf("expected failures to be forgotten once the node applied its config, got %d", got)
+	}
+}
+
+func TestQuarantineThreshold(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithQuarantineThreshold(2))
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	nodes := []*corev1.Node{
+		newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded),
+		newNodeWithReadyAndDaemonState("node-1", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded),
+		withAnnotation(newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue), quarantinedAnnotationKey, "v1"),
+		newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-4", "v0", "v0", corev1.ConditionTrue),
+	}
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	// node-0 reached the threshold, node-1 didn't yet.
+	c.failures.fail("node-0", "v1")
+	c.failures.fail("node-0", "v1")
+	c.failures.fail("node-1", "v1")
+
+	// Both failing nodes hold back the pool until one is quarantined.
+	if got := getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil); len(got) != 0 {
+		t.Fatalf("expected failing nodes to hold back the pool, got %v", got)
+	}
+
+	updated, err := c.updateQuarantine(pool, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var quarantined []string
+	for _, node := range updated {
+		if isNodeQuarantined(node) {
+			quarantined = append(quarantined, node.Name)
+		}
+	}
+	if expected := []string{"node-0"}; !reflect.DeepEqual(quarantined, expected) {
+		t.Fatalf("mismatch quarantined nodes: got %v want: %v", quarantined, expected)
+	}
+	for name, want := range map[string]string{"node-0": "v1", "node-1": ""} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := node.Annotations[quarantinedAnnotationKey]; got != want {
+			t.Fatalf("mismatch quarantined annotation of %s: got %q want: %q", name, got, want)
+		}
+	}
+	// The fake client can't remove annotations, so look at the patch releasing node-2.
+	released := false
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if patch, ok := action.(core.PatchAction); ok && patch.GetName() == "node-2" {
+			released = strings.Contains(string(patch.GetPatch()), `"`+quarantinedAnnotationKey+`":null`)
+		}
+	}
+	if !released {
+		t.Fatalf("expected node-2 to be released from quarantine")
+	}
+	// A node event and a pool event for node-0, and a pool event releasing node-2.
+	if len(recorder.Events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(recorder.Events))
+	}
+
+	// The quarantined node no longer counts against maxUnavailable, only node-1 does.
+	got := getCandidateMachines(pool, updated, 2, checkNodeReady, nil, nil, nil)
+	if len(got) != 1 || got[0].Name != "node-3" {
+		t.Fatalf("expected node-3 to be updated despite the quarantined node, got %v", got)
+	}
+}
+
+func TestQuarantineReleasedOnNewTarget(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithQuarantineThreshold(2))
+	pool := newMachineConfigPool("worker", nil, nil, "v2")
+	nodes := []*corev1.Node{
+		withAnnotation(newNodeWithReadyAndDaemonState("node-0", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded), quarantinedAnnotationKey, "v1"),
+		withAnnotation(newNodeWithReadyAndDaemonState("node-1", "v0", "v2", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded), quarantinedAnnotationKey, "v2"),
+	}
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	updated, err := c.updateQuarantine(pool, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// node-0 was quarantined for a config the pool no longer moves it to, node-1 still fails the target.
+	var quarantined []string
+	for _, node := range updated {
+		if isNodeQuarantined(node) {
+			quarantined = append(quarantined, node.Name)
+		}
+	}
+	if expected := []string{"node-1"}; !reflect.DeepEqual(quarantined, expected) {
+		t.Fatalf("mismatch quarantined nodes: got %v want: %v", quarantined, expected)
+	}
+	released := false
+	for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+		if patch, ok := action.(core.PatchAction); ok {
+			if patch.GetName() != "node-0" {
+				t.Fatalf("unexpected patch of %s", patch.GetName())
+			}
+			released = strings.Contains(string(patch.GetPatch()), `"`+quarantinedAnnotationKey+`":null`)
+		}
+	}
+	if !released {
+		t.Fatalf("expected node-0 to be released from quarantine")
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(recorder.Events))
+	}
+}
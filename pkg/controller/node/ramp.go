@@ -0,0 +1,53 @@
+package node
+
+import (
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// updateMaxUnavailableRamp records in newStatus how far the rollout of pool ramped up. The
+// nodes updated and ready on the target config count towards the next step, and while
+// any node fails to apply it the ramp starts over from the nodes ready then.
+func updateMaxUnavailableRamp(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, nodes []*corev1.Node) {
+	ramp := pool.Spec.MaxUnavailableRamp
+	if ramp == nil {
+		newStatus.MaxUnavailableRamp = nil
+		return
+	}
+	state := mcfgv1.MachineConfigPoolMaxUnavailableRampStatus{Config: pool.Spec.Configuration.Name}
+	if prev := pool.Status.MaxUnavailableRamp; prev != nil && prev.Config == state.Config {
+		state.Baseline = prev.Baseline
+	}
+	ready := newStatus.ReadyMachineCount
+	if countFailingThisConfig(pool, nodes) > 0 {
+		state.Baseline = ready
+	}
+	successes := ready - state.Baseline
+	if successes < 0 {
+		successes = 0
+	}
+	state.Current = rampedMaxUnavailable(ramp, successes)
+	newStatus.MaxUnavailableRamp = &state
+}
+
+// rampedMaxUnavailable returns the number of nodes updated at once after successes nodes
+// completed their update.
+func rampedMaxUnavailable(ramp *mcfgv1.MachineConfigPoolMaxUnavailableRamp, successes int32) int32 {
+	current := ramp.Initial
+	if ramp.SuccessesPerStep > 0 {
+		current += ramp.Step * (successes / ramp.SuccessesPerStep)
+	}
+	if current > ramp.Ceiling {
+		current = ramp.Ceiling
+	}
+	return current
+}
+
+// currentRampMaxUnavailable returns the number of nodes of pool updated at once under its
+// maxUnavailable ramp, as of its status.
+func currentRampMaxUnavailable(pool *mcfgv1.MachineConfigPool) int {
+	if state := pool.Status.MaxUnavailableRamp; state != nil && state.Config == pool.Spec.Configuration.Name {
+		return int(state.Current)
+	}
+	return int(pool.Spec.MaxUnavailableRamp.Initial)
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMaxUnavailableRamp(t *testing.T) {
+	pool := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "v1")
+	pool.Spec.MaxUnavailableRamp = &mcfgv1.MachineConfigPoolMaxUnavailableRamp{Initial: 1, Ceiling: 4, Step: 2, SuccessesPerStep: 2}
+	var nodes []*corev1.Node
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, newNode(string(rune('a'+i)), "v0", "v1"))
+	}
+
+	for _, step := range []struct {
+		name     string
+		ready    int32
+		failing  bool
+		baseline int32
+		current  int32
+	}{
+		{name: "start", ready: 0, current: 1},
+		{name: "one success", ready: 1, current: 1},
+		{name: "first step", ready: 2, current: 3},
+		{name: "ceiling", ready: 6, current: 4},
+		{name: "failure resets", ready: 6, failing: true, baseline: 6, current: 1},
+		{name: "still failing", ready: 7, failing: true, baseline: 7, current: 1},
+		{name: "recovered", ready: 8, baseline: 7, current: 1},
+		{name: "ramping again", ready: 9, baseline: 7, current: 3},
+	} {
+		nodes[9].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDone
+		if step.failing {
+			nodes[9].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+		}
+		newStatus := mcfgv1.MachineConfigPoolStatus{ReadyMachineCount: step.ready}
+		updateMaxUnavailableRamp(pool, &newStatus, nodes)
+		want := mcfgv1.MachineConfigPoolMaxUnavailableRampStatus{Config: "v1", Baseline: step.baseline, Current: step.current}
+		if newStatus.MaxUnavailableRamp == nil || *newStatus.MaxUnavailableRamp != want {
+			t.Fatalf("%s: got ramp %+v, want %+v", step.name, newStatus.MaxUnavailableRamp, want)
+		}
+		pool.Status = newStatus
+		m, err := calculateMaxUnavailable(pool, nodes)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if m.effective != int(step.current) {
+			t.Fatalf("%s: got maxUnavailable %d, want %d", step.name, m.effective, step.current)
+		}
+	}
+
+	pool.Spec.Configuration.Name = "v2"
+	if m, _ := calculateMaxUnavailable(pool, nodes); m.effective != 1 {
+		t.Fatalf("expected a new config to start from the initial value, got %d", m.effective)
+	}
+	pool.Spec.MaxUnavailableRamp = nil
+	newStatus := pool.Status
+	updateMaxUnavailableRamp(pool, &newStatus, nodes)
+	if newStatus.MaxUnavailableRamp != nil {
+		t.Fatalf("expected the ramp status to be cleared with the ramp, got %+v", newStatus.MaxUnavailableRamp)
+	}
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"sort"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NodeStatus is the machine config state of a node as the node controller sees it.
+type NodeStatus struct {
+	// Name is the name of the node.
+	Name string
+	// Pool is the name of the pool governing the node, empty if none does.
+	Pool string
+	// CurrentConfig is the MachineConfig the node runs.
+	CurrentConfig string
+	// DesiredConfig is the MachineConfig the node was asked to update to.
+	DesiredConfig string
+	// UpToDate is set if the node finished updating to the target config of its pool,
+	// spec.rollbackTo during a rollback.
+	UpToDate bool
+	// Error explains why the pool of the node can't be determined, e.g. because
+	// several pools select it.
+	Error string
+}
+
+// NodeReport returns the state of every node, sorted by name, resolving the pool of each
+// node the way the controller does. It only reads from the listers, so it is safe to call
+// while pools are being synced.
+func (ctrl *Controller) NodeReport() ([]NodeStatus, error) {
+	nodes, err := ctrl.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		status := NodeStatus{
+			Name:          node.Name,
+			CurrentConfig: node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey],
+			DesiredConfig: node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey],
+		}
+		pool, err := ctrl.getPoolForNode(node)
+		if err != nil {
+			status.Error = err.Error()
+		} else if pool != nil {
+			status.Pool = pool.Name
+			status.UpToDate = isNodeDoneAt(node, rollbackTarget(pool).Spec.Configuration.Name)
+		}
+		report = append(report, status)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Name < report[j].Name })
+	return report, nil
+}
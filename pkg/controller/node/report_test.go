@@ -0,0 +1,49 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeReport(t *testing.T) {
+	f := newFixture(t)
+	master := newMachineConfigPool("master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/master", ""), nil, "m1")
+	worker := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), nil, "w1")
+	worker.Spec.RollbackTo = "w0"
+	infra := newMachineConfigPool("infra", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", ""), nil, "i1")
+	gpu := newMachineConfigPool("gpu", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/gpu", ""), nil, "g1")
+	f.mcpLister = append(f.mcpLister, master, worker, infra, gpu)
+	f.nodeLister = append(f.nodeLister,
+		newNodeWithLabel("node-3", "", "", nil),
+		newNodeWithLabel("node-0", "m1", "m1", map[string]string{"node-role/master": ""}),
+		newNodeWithLabel("node-1", "w1", "w0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-2", "w0", "w0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-4", "i0", "i0", map[string]string{"node-role/infra": "", "node-role/gpu": ""}),
+		newNodeWithLabel("node-5", "i0", "i1", map[string]string{"node-role/worker": "", "node-role/infra": ""}),
+	)
+	c := f.newController()
+
+	report, err := c.NodeReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NodeStatus{
+		{Name: "node-0", Pool: "master", CurrentConfig: "m1", DesiredConfig: "m1", UpToDate: true},
+		// The worker pool is rolling back to w0.
+		{Name: "node-1", Pool: "worker", CurrentConfig: "w1", DesiredConfig: "w0"},
+		{Name: "node-2", Pool: "worker", CurrentConfig: "w0", DesiredConfig: "w0", UpToDate: true},
+		{Name: "node-3"},
+		{Name: "node-4", CurrentConfig: "i0", DesiredConfig: "i0"},
+		// Custom pools take precedence over the worker pool.
+		{Name: "node-5", Pool: "infra", CurrentConfig: "i0", DesiredConfig: "i1"},
+	}
+	if report[4].Error == "" {
+		t.Fatalf("expected an error for a node in two custom pools")
+	}
+	report[4].Error = ""
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("mismatch node report:\ngot  %+v\nwant %+v", report, expected)
+	}
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// resyncMinInterval is how long the /resync endpoint turns down requests after a resync.
+const resyncMinInterval = 30 * time.Second
+
+// WithResyncEndpoint also serves /resync on the address of the health server, see
+// WithHealthServer. A POST to it resyncs all pools right away, at most once every
+// resyncMinInterval. The endpoint is unauthenticated, so anyone who can reach the
+// address can trigger resyncs; it is off by default.
+func WithResyncEndpoint(enabled bool) Option {
+	return func(ctrl *Controller) {
+		ctrl.resyncEndpoint = enabled
+	}
+}
+
+// ResyncAll queues every pool to be synced right away rather than after the usual
+// delay, e.g. to recover after an operator upgrade or a manual intervention without
+// waiting for the pools to change. It returns the number of pools queued.
+func (ctrl *Controller) ResyncAll() (int, error) {
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	glog.Infof("Resyncing all %d pools", len(pools))
+	for _, pool := range pools {
+		ctrl.enqueue(pool)
+	}
+	return len(pools), nil
+}
+
+type resyncHandler struct {
+	ctrl *Controller
+
+	lock sync.Mutex
+	// last is when the latest resync was served.
+	last time.Time
+}
+
+// ServeHTTP handles /resync requests, which resync all pools unless the latest resync
+// was less than resyncMinInterval ago.
+func (h *resyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.lock.Lock()
+	now := time.Now()
+	if wait := h.last.Add(resyncMinInterval).Sub(now); wait > 0 {
+		h.lock.Unlock()
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(wait.Seconds())+1))
+		http.Error(w, fmt.Sprintf("Pools were resynced less than %v ago", resyncMinInterval), http.StatusTooManyRequests)
+		return
+	}
+	h.last = now
+	h.lock.Unlock()
+
+	count, err := h.ctrl.ResyncAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "Resyncing %d pools\n", count)
+}
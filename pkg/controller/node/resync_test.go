@@ -0,0 +1,83 @@
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestResyncAll(t *testing.T) {
+	f := newFixture(t)
+	for _, name := range []string{"master", "worker", "infra"} {
+		f.mcpLister = append(f.mcpLister, newMachineConfigPool(name, nil, intStrPtr(intstr.FromInt(1)), "v1"))
+	}
+	c := f.newController()
+
+	handler := &resyncHandler{ctrl: c}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resync", nil))
+	if rec.Code != http.StatusMethodNotAllowed || c.queue.Len() != 0 {
+		t.Fatalf("expected a GET not to resync anything, got %d with %d pools queued", rec.Code, c.queue.Len())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/resync", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("mismatch status code: got %d want: %d", rec.Code, http.StatusOK)
+	}
+	if c.queue.Len() != 3 {
+		t.Fatalf("expected every pool to be queued once, got %d queued", c.queue.Len())
+	}
+	var keys []string
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		keys = append(keys, key.(string))
+		c.queue.Done(key)
+	}
+	sort.Strings(keys)
+	if want := []string{"infra", "master", "worker"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("mismatch queued pools: got %v want: %v", keys, want)
+	}
+}
+
+func TestResyncRateLimited(t *testing.T) {
+	f := newFixture(t)
+	f.mcpLister = append(f.mcpLister, newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1"))
+	c := f.newController()
+	handler := &resyncHandler{ctrl: c, last: time.Now()}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/resync", nil))
+	if rec.Code != http.StatusTooManyRequests || c.queue.Len() != 0 {
+		t.Fatalf("expected a resync right after another to be turned down, got %d with %d pools queued", rec.Code, c.queue.Len())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected turned down resyncs to say when to retry")
+	}
+
+	handler.last = time.Now().Add(-resyncMinInterval)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/resync", nil))
+	if rec.Code != http.StatusOK || c.queue.Len() != 1 {
+		t.Fatalf("expected a resync once the interval passed, got %d with %d pools queued", rec.Code, c.queue.Len())
+	}
+}
+
+func TestResyncEndpointOptIn(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		f := newFixture(t)
+		f.opts = append(f.opts, WithResyncEndpoint(enabled))
+		c := f.newController()
+
+		rec := httptest.NewRecorder()
+		c.healthMux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/resync", nil))
+		if served := rec.Code != http.StatusNotFound; served != enabled {
+			t.Fatalf("mismatch /resync served: got %t want: %t", served, enabled)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rollbackTarget returns a copy of pool targeting Spec.RollbackTo while a rollback is
+// requested, and pool itself otherwise. The copy is only used to pick and update nodes.
+// Nodes roll back directly, skipping the config sequence of the pool.
+func rollbackTarget(pool *mcfgv1.MachineConfigPool) *mcfgv1.MachineConfigPool {
+	if pool.Spec.RollbackTo == "" {
+		return pool
+	}
+	target := pool.DeepCopy()
+	target.Spec.Configuration.Name = pool.Spec.RollbackTo
+	target.Spec.ConfigSequence = nil
+	return target
+}
+
+// setRollbackCondition records on pool whether a rollback is in progress and which
+// of its nodes still need to revert.
+func setRollbackCondition(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) {
+	if pool.Spec.RollbackTo == "" {
+		if mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRollingBack) {
+			srollback := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRollingBack, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *srollback)
+		}
+		return
+	}
+
+	var pending []string
+	for _, node := range nodes {
+		if !isNodeDoneAt(node, pool.Spec.RollbackTo) {
+			pending = append(pending, node.Name)
+		}
+	}
+	sort.Strings(pending)
+	message := fmt.Sprintf("Rolling back to %s, %d nodes still to revert", pool.Spec.RollbackTo, len(pending))
+	if len(pending) > 0 {
+		message = fmt.Sprintf("%s: %s", message, strings.Join(pending, ", "))
+	}
+	srollback := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRollingBack, corev1.ConditionTrue, "RollbackRequested", message)
+	setConditionMessage(&pool.Status, srollback)
+}
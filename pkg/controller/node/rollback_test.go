@@ -0,0 +1,88 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestRollback(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("test-cluster-master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role", "master"), intStrPtr(intstr.FromInt(1)), "v2")
+	mcp.Spec.RollbackTo = "v1"
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role": "master"}),
+		newNodeWithLabel("node-1", "v2", "v2", map[string]string{"node-role": "master"}),
+	}
+
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v1"), newMachineConfig("v2"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	// node-0 is already on the rollback target and is left alone.
+	f.expectGetNodeAction(nodes[1])
+	expNode := nodes[1].DeepCopy()
+	expNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "v1"
+	oldData, err := json.Marshal(nodes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	newData, err := json.Marshal(expNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exppatch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, corev1.Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectPatchNodeAction(expNode, exppatch)
+	expMcp := mcp.DeepCopy()
+	srollback := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRollingBack, corev1.ConditionTrue, "RollbackRequested", "Rolling back to v1, 1 nodes still to revert: node-1")
+	mcfgv1.SetMachineConfigPoolCondition(&expMcp.Status, *srollback)
+	expMcp.Status = calculateStatus(expMcp, nodes, checkNodeReady)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+
+	f.run(getKey(mcp, t))
+}
+
+func TestSetRollbackCondition(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, nil, "v2")
+	pool.Spec.RollbackTo = "v1"
+	nodes := []*corev1.Node{
+		newNode("node-0", "v2", "v2"),
+		newNode("node-1", "v2", "v1"),
+		newNode("node-2", "v1", "v1"),
+	}
+
+	setRollbackCondition(pool, nodes)
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRollingBack)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Fatalf("expected a rollback in progress, got %v", cond)
+	}
+	if want := "Rolling back to v1, 2 nodes still to revert: node-0, node-1"; cond.Message != want {
+		t.Fatalf("mismatch message: got %q want: %q", cond.Message, want)
+	}
+
+	nodes[0] = newNode("node-0", "v1", "v1")
+	setRollbackCondition(pool, nodes)
+	cond = mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRollingBack)
+	if want := "Rolling back to v1, 1 nodes still to revert: node-1"; cond.Message != want {
+		t.Fatalf("expected the pending nodes to be refreshed: got %q want: %q", cond.Message, want)
+	}
+
+	pool.Spec.RollbackTo = ""
+	setRollbackCondition(pool, nodes)
+	if !mcfgv1.IsMachineConfigPoolConditionFalse(pool.Status.Conditions, mcfgv1.MachineConfigPoolRollingBack) {
+		t.Fatalf("expected clearing rollbackTo to end the rollback, got %v", pool.Status.Conditions)
+	}
+}
@@ -0,0 +1,136 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ValidatePoolSelectors returns, by pool name, the pools whose node selectors could
+// select the same node in a way getPoolForNode can't resolve: a custom pool together
+// with the master pool or with another custom pool. Such a node can't be reconciled.
+func ValidatePoolSelectors(pools []*mcfgv1.MachineConfigPool) (map[string][]string, error) {
+	conflicts := map[string][]string{}
+	for i, a := range pools {
+		for _, b := range pools[i+1:] {
+			if !isPoolCombinationAmbiguous(a, b) {
+				continue
+			}
+			overlap, err := poolsOverlap(a, b)
+			if err != nil {
+				return nil, err
+			}
+			if overlap {
+				conflicts[a.Name] = append(conflicts[a.Name], b.Name)
+				conflicts[b.Name] = append(conflicts[b.Name], a.Name)
+			}
+		}
+	}
+	for name := range conflicts {
+		sort.Strings(conflicts[name])
+	}
+	return conflicts, nil
+}
+
+// isPoolCombinationAmbiguous returns whether getPoolForNode refuses a node selected by
+// both pools.
+func isPoolCombinationAmbiguous(a, b *mcfgv1.MachineConfigPool) bool {
+	_, err := choosePool("", []*mcfgv1.MachineConfigPool{a, b})
+	return err != nil
+}
+
+// poolsOverlap returns whether some set of labels matches a node selector of both pools.
+func poolsOverlap(a, b *mcfgv1.MachineConfigPool) (bool, error) {
+	for _, sa := range nodeSelectorsOf(a) {
+		for _, sb := range nodeSelectorsOf(b) {
+			overlap, err := selectorsOverlap(sa, sb)
+			if err != nil || overlap {
+				return overlap, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// selectorsOverlap returns whether some set of labels matches both selectors. Like in
+// getPoolForNode, a nil or empty selector matches nothing.
+func selectorsOverlap(a, b *metav1.LabelSelector) (bool, error) {
+	var reqs labels.Requirements
+	for _, ls := range []*metav1.LabelSelector{a, b} {
+		selector, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil {
+			return false, fmt.Errorf("invalid label selector: %v", err)
+		}
+		if selector.Empty() {
+			return false, nil
+		}
+		r, _ := selector.Requirements()
+		reqs = append(reqs, r...)
+	}
+
+	type keyConstraint struct {
+		exists, notExists bool
+		allowed           sets.String
+		forbidden         sets.String
+	}
+	constraints := map[string]*keyConstraint{}
+	for _, r := range reqs {
+		c, ok := constraints[r.Key()]
+		if !ok {
+			c = &keyConstraint{forbidden: sets.NewString()}
+			constraints[r.Key()] = c
+		}
+		switch r.Operator() {
+		case selection.In, selection.Equals, selection.DoubleEquals:
+			c.exists = true
+			if c.allowed == nil {
+				c.allowed = sets.NewString(r.Values().List()...)
+			} else {
+				c.allowed = c.allowed.Intersection(r.Values())
+			}
+		case selection.NotIn, selection.NotEquals:
+			c.forbidden = c.forbidden.Union(r.Values())
+		case selection.Exists:
+			c.exists = true
+		case selection.DoesNotExist:
+			c.notExists = true
+		default:
+			// Be conservative about operators we don't reason about.
+			return true, nil
+		}
+	}
+	for _, c := range constraints {
+		if c.exists && c.notExists {
+			return false, nil
+		}
+		if c.allowed != nil && c.allowed.Difference(c.forbidden).Len() == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// setSelectorConflictCondition records on status which other pools could select the same
+// nodes as pool. The condition is only added once a conflict was found.
+func setSelectorConflictCondition(status *mcfgv1.MachineConfigPoolStatus, pool *mcfgv1.MachineConfigPool, pools []*mcfgv1.MachineConfigPool) error {
+	conflicts, err := ValidatePoolSelectors(pools)
+	if err != nil {
+		return err
+	}
+	if others := conflicts[pool.Name]; len(others) > 0 {
+		message := fmt.Sprintf("Node selector can select the same nodes as pools %s; such nodes will not be updated", strings.Join(others, ", "))
+		sconflict := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolSelectorConflict, corev1.ConditionTrue, "OverlappingSelectors", message)
+		setConditionMessage(status, sconflict)
+	} else if mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolSelectorConflict) != nil {
+		sconflict := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolSelectorConflict, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *sconflict)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func roleSelector(role string) *metav1.LabelSelector {
+	return metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role.kubernetes.io/"+role, "")
+}
+
+func TestSelectorsOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     *metav1.LabelSelector
+		expected bool
+	}{{
+		name:     "different roles",
+		a:        roleSelector("master"),
+		b:        roleSelector("infra"),
+		expected: true,
+	}, {
+		name:     "conflicting values",
+		a:        metav1.AddLabelToSelector(&metav1.LabelSelector{}, "tier", "gold"),
+		b:        metav1.AddLabelToSelector(&metav1.LabelSelector{}, "tier", "silver"),
+		expected: false,
+	}, {
+		name: "excluded role",
+		a:    roleSelector("master"),
+		b: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"node-role.kubernetes.io/infra": ""},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key: "node-role.kubernetes.io/master", Operator: metav1.LabelSelectorOpDoesNotExist,
+			}},
+		},
+		expected: false,
+	}, {
+		name: "values left after exclusions",
+		a: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"gold", "silver"},
+		}}},
+		b: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"gold"},
+		}}},
+		expected: true,
+	}, {
+		name:     "empty selector matches nothing",
+		a:        roleSelector("master"),
+		b:        &metav1.LabelSelector{},
+		expected: false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := selectorsOverlap(test.a, test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Fatalf("mismatch: got %v want: %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePoolSelectors(t *testing.T) {
+	pools := []*mcfgv1.MachineConfigPool{
+		newMachineConfigPool("master", roleSelector("master"), nil, "v1"),
+		newMachineConfigPool("worker", roleSelector("worker"), nil, "v1"),
+		newMachineConfigPool("infra", roleSelector("infra"), nil, "v1"),
+		newMachineConfigPool("gpu", &metav1.LabelSelector{
+			MatchLabels: map[string]string{"node-role.kubernetes.io/gpu": ""},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key: "node-role.kubernetes.io/master", Operator: metav1.LabelSelectorOpDoesNotExist,
+			}, {
+				Key: "node-role.kubernetes.io/infra", Operator: metav1.LabelSelectorOpDoesNotExist,
+			}},
+		}, nil, "v1"),
+	}
+
+	conflicts, err := ValidatePoolSelectors(pools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"master": {"infra"},
+		"infra":  {"master"},
+	}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Fatalf("mismatch conflicts: got %v want: %v", conflicts, expected)
+	}
+
+	var status mcfgv1.MachineConfigPoolStatus
+	if err := setSelectorConflictCondition(&status, pools[2], pools); err != nil {
+		t.Fatal(err)
+	}
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolSelectorConflict)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Message != "Node selector can select the same nodes as pools master; such nodes will not be updated" {
+		t.Fatalf("unexpected condition: %v", cond)
+	}
+
+	if err := setSelectorConflictCondition(&status, pools[2], pools[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if !mcfgv1.IsMachineConfigPoolConditionFalse(status.Conditions, mcfgv1.MachineConfigPoolSelectorConflict) {
+		t.Fatalf("expected the conflict to clear once master is gone, got %v", status.Conditions)
+	}
+}
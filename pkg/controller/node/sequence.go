@@ -0,0 +1,38 @@
+package node
+
+import (
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nextConfig returns the config node is moved to when it's selected for an update:
+// the step of the pool's config sequence following the one the node finished
+// updating to, the first step if the node is on none of them, and the target
+// config of the pool once the node went through all steps.
+func nextConfig(pool *mcfgv1.MachineConfigPool, node *corev1.Node) string {
+	steps := pool.Spec.ConfigSequence
+	next := 0
+	for i, step := range steps {
+		if isNodeDoneAt(node, step) {
+			next = i + 1
+			break
+		}
+	}
+	if next < len(steps) {
+		return steps[next]
+	}
+	return pool.Spec.Configuration.Name
+}
+
+// isNodeInSequenceStep returns whether node is still updating to a step of the
+// config sequence of pool, and must not be moved on before it finished.
+func isNodeInSequenceStep(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+	for _, step := range pool.Spec.ConfigSequence {
+		if desired == step {
+			return !isNodeDoneAt(node, step)
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNextConfig(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Spec.ConfigSequence = []string{"prep-0", "prep-1"}
+	tests := []struct {
+		node     *corev1.Node
+		expected string
+	}{{
+		node:     newNode("node-0", "v0", "v0"),
+		expected: "prep-0",
+	}, {
+		node:     newNode("node-0", "prep-0", "prep-0"),
+		expected: "prep-1",
+	}, {
+		node:     newNode("node-0", "prep-1", "prep-1"),
+		expected: "v1",
+	}, {
+		// Still updating to the first step.
+		node:     newNode("node-0", "v0", "prep-0"),
+		expected: "prep-0",
+	}}
+
+	for _, test := range tests {
+		current := test.node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+		if got := nextConfig(pool, test.node); got != test.expected {
+			t.Errorf("node on %s: got next config %s want: %s", current, got, test.expected)
+		}
+	}
+
+	pool.Spec.ConfigSequence = nil
+	if got := nextConfig(pool, newNode("node-0", "v0", "v0")); got != "v1" {
+		t.Errorf("expected nodes to move to the target config without a sequence, got %s", got)
+	}
+}
+
+func TestConfigSequence(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+	pool.Spec.ConfigSequence = []string{"prep"}
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "prep", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "prep", "prep", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue),
+	}
+
+	// node-0 is still updating to the prep step and is left alone, node-1 finished it.
+	got := getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil)
+	if len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected only node-1 to be selected, got %v", got)
+	}
+	if next := nextConfig(pool, got[0]); next != "v1" {
+		t.Fatalf("expected node-1 to move on to v1, got %s", next)
+	}
+
+	// Rolling back skips the sequence.
+	pool.Spec.RollbackTo = "v0"
+	if next := nextConfig(rollbackTarget(pool), nodes[2]); next != "v0" {
+		t.Fatalf("expected a rollback to move nodes directly to v0, got %s", next)
+	}
+}
+
+func TestConfigSequenceSync(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.ConfigSequence = []string{"prep"}
+	node := newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""})
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("prep"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desired := got.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != "prep" {
+		t.Fatalf("expected node-0 to move to the prep step first, got %s", desired)
+	}
+}
@@ -0,0 +1,171 @@
+package node
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// needsShadowValidation returns whether some of nodes aren't set to config yet, so that
+// rolling it out would update them.
+func needsShadowValidation(config string, nodes []*corev1.Node) bool {
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != config {
+			return true
+		}
+	}
+	return false
+}
+
+// shadowEligibility returns whether nodes may serve as shadow node of pool, i.e. match
+// the node selector of its shadow validation.
+func shadowEligibility(pool *mcfgv1.MachineConfigPool) (func(*corev1.Node) bool, error) {
+	selector := labels.Everything()
+	if ls := pool.Spec.ShadowValidation.NodeSelector; ls != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(ls); err != nil {
+			return nil, err
+		}
+	}
+	return func(node *corev1.Node) bool {
+		return selector.Matches(labels.Set(node.Labels))
+	}, nil
+}
+
+// getShadowNode returns the shadow node the target config of pool is validated on: the
+// one already picked, if it is still in the pool, or else an eligible node already set
+// to the config, by name. Paused and pinned nodes aren't picked. It returns nil if the
+// shadow node is yet to be picked among the candidates of the pool.
+func getShadowNode(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, eligible func(*corev1.Node) bool) *corev1.Node {
+	config := pool.Spec.Configuration.Name
+	status := pool.Status.ShadowValidation
+	var shadow *corev1.Node
+	for _, node := range nodes {
+		if node.Name == status.Node {
+			return node
+		}
+		if _, pinned := getPinnedConfig(node); pinned || isNodePaused(node) || !eligible(node) {
+			continue
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == config && (shadow == nil || node.Name < shadow.Name) {
+			shadow = node
+		}
+	}
+	return shadow
+}
+
+// checkShadowValidation validates the target config of a pool with spec.shadowValidation
+// on its shadow node before the config is rolled out to the rest of the pool, recording
+// the progress on the status of pool. It returns whether the rollout is held back, i.e.
+// until the config is validated, or for good once it is rejected, in which case the shadow
+// node is moved back to the current config of the pool. While the shadow node is yet to
+// be updated, the rollout isn't held back, but it also returns which nodes may be picked:
+// the shadow node is updated like any other candidate, within the limits of the pool.
+// Rollbacks aren't validated.
+func (ctrl *Controller) checkShadowValidation(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, checkReady NodeReadyChecker, now time.Time) (bool, func(*corev1.Node) bool, error) {
+	if pool.Spec.ShadowValidation == nil || pool.Spec.RollbackTo != "" {
+		return false, nil, nil
+	}
+	config := pool.Spec.Configuration.Name
+	status := pool.Status.ShadowValidation
+	if status == nil || status.Config != config {
+		if !needsShadowValidation(config, nodes) {
+			return false, nil, nil
+		}
+		status = &mcfgv1.MachineConfigPoolShadowValidationStatus{Config: config, State: mcfgv1.ShadowValidationValidating}
+		pool.Status.ShadowValidation = status
+	}
+	switch status.State {
+	case mcfgv1.ShadowValidationValidated:
+		return false, nil, nil
+	case mcfgv1.ShadowValidationRejected:
+		return true, nil, nil
+	}
+
+	eligible, err := shadowEligibility(pool)
+	if err != nil {
+		return true, nil, newSyncError(syncErrorInvalidShadowSelector, err)
+	}
+	node := getShadowNode(pool, nodes, eligible)
+	if node == nil {
+		status.Node = ""
+		status.ReadySince = nil
+		return false, eligible, nil
+	}
+	if status.Node != node.Name {
+		status.Node = node.Name
+		status.ReadySince = nil
+	}
+	if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != config {
+		// The shadow node is still on its way to the config, e.g. being drained.
+		return false, func(n *corev1.Node) bool { return n.Name == node.Name }, nil
+	}
+
+	if isNodeMCDFailing(node) {
+		glog.Warningf("Pool %s: shadow node %s failed to apply %s, rejecting it", pool.Name, node.Name, config)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "ShadowValidationFailed", "Shadow node %s failed to apply %s; not rolling it out", node.Name, config)
+		status.State = mcfgv1.ShadowValidationRejected
+		status.ReadySince = nil
+		if previous := pool.Status.Configuration.Name; previous != "" && previous != config {
+			glog.Infof("Pool %s: moving shadow node %s back to %s", pool.Name, node.Name, previous)
+			err := ctrl.setDesiredMachineConfigAnnotation(node.Name, previous)
+			ctrl.recordDesiredConfigResult(pool, node, err)
+			if err != nil {
+				return true, nil, newSyncError(syncErrorSetDesiredConfig, err)
+			}
+		}
+		return true, nil, nil
+	}
+	if !isNodeDoneAt(node, config) || checkReady(node) != nil {
+		status.ReadySince = nil
+		return true, nil, nil
+	}
+	if status.ReadySince == nil {
+		t := metav1.NewTime(now)
+		status.ReadySince = &t
+	}
+	if remaining := status.ReadySince.Add(pool.Spec.ShadowValidation.Soak.Duration).Sub(now); remaining > 0 {
+		glog.Infof("Pool %s: shadow node %s is soaking %s, rolling it out in %v", pool.Name, node.Name, config, remaining)
+		ctrl.enqueueAfter(pool, remaining)
+		return true, nil, nil
+	}
+	glog.Infof("Pool %s: %s validated on shadow node %s", pool.Name, config, node.Name)
+	ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "ShadowValidationSucceeded", "Shadow node %s stayed ready on %s for %v; rolling it out", node.Name, config, pool.Spec.ShadowValidation.Soak.Duration)
+	status.State = mcfgv1.ShadowValidationValidated
+	return false, nil, nil
+}
+
+// pickShadowNode takes the first of candidates, picked among the nodes eligible for the
+// shadow validation of pool, as its shadow node, and returns it as the only candidate.
+func (ctrl *Controller) pickShadowNode(pool *mcfgv1.MachineConfigPool, candidates []*corev1.Node) []*corev1.Node {
+	status := pool.Status.ShadowValidation
+	if len(candidates) == 0 {
+		if status.Node == "" {
+			glog.Infof("Pool %s: no node can serve as shadow node to validate %s yet", pool.Name, status.Config)
+		}
+		return nil
+	}
+	node := candidates[0]
+	if status.Node != node.Name {
+		glog.Infof("Pool %s: validating %s on shadow node %s", pool.Name, status.Config, node.Name)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeNormal, "ShadowValidationStarted", "Validating %s on shadow node %s before rolling it out", status.Config, node.Name)
+		status.Node = node.Name
+		status.ReadySince = nil
+	}
+	return candidates[:1]
+}
+
+// setShadowValidation carries the shadow validation recorded on the status of pool by
+// its sync over to newStatus, if pool has spec.shadowValidation.
+func setShadowValidation(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus) {
+	if pool.Spec.ShadowValidation == nil {
+		newStatus.ShadowValidation = nil
+		return
+	}
+	newStatus.ShadowValidation = pool.Status.ShadowValidation
+}
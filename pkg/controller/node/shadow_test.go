@@ -0,0 +1,182 @@
+package node
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+// syncShadowPool syncs a worker pool validating v1 on a shadow node, with the given shadow
+// validation status and nodes. It returns the patched nodes along with the written status
+// of the pool and the recorded events.
+func syncShadowPool(t *testing.T, shadow *mcfgv1.MachineConfigPoolShadowValidationStatus, nodes ...*corev1.Node) ([]string, *mcfgv1.MachineConfigPoolStatus, *objectRecorder) {
+	return syncShadowPoolWith(t, func(*fixture, *mcfgv1.MachineConfigPool) {}, shadow, nodes...)
+}
+
+// syncShadowPoolWith is syncShadowPool, with the fixture and pool adjusted by setup first.
+func syncShadowPoolWith(t *testing.T, setup func(*fixture, *mcfgv1.MachineConfigPool), shadow *mcfgv1.MachineConfigPoolShadowValidationStatus, nodes ...*corev1.Node) ([]string, *mcfgv1.MachineConfigPoolStatus, *objectRecorder) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.ShadowValidation = &mcfgv1.MachineConfigPoolShadowValidation{Soak: metav1.Duration{Duration: 10 * time.Minute}}
+	setup(f, mcp)
+	mcp.Status.ShadowValidation = shadow
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	recorder := &objectRecorder{}
+	c.eventRecorder = recorder
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var patched []string
+	for _, action := range f.kubeclient.Actions() {
+		if patch, ok := action.(core.PatchAction); ok && strings.Contains(string(patch.GetPatch()), daemonconsts.DesiredMachineConfigAnnotationKey) {
+			patched = append(patched, patch.GetName())
+		}
+	}
+	sort.Strings(patched)
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	return patched, status, recorder
+}
+
+func newWorker(name, current, desired string) *corev1.Node {
+	return newNodeWithLabel(name, current, desired, map[string]string{"node-role/worker": ""})
+}
+
+func TestShadowValidationStarts(t *testing.T) {
+	patched, status, recorder := syncShadowPool(t, nil, newWorker("node-0", "v0", "v0"), newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 1 || patched[0] != "node-0" {
+		t.Fatalf("expected only the shadow node to be updated, got patches for %v", patched)
+	}
+	if status == nil || status.ShadowValidation == nil {
+		t.Fatalf("expected the shadow validation to be reported, got %v", status)
+	}
+	if sv := status.ShadowValidation; sv.Config != "v1" || sv.Node != "node-0" || sv.State != mcfgv1.ShadowValidationValidating {
+		t.Fatalf("unexpected shadow validation %+v", sv)
+	}
+	if !recorder.has("pool/worker", "ShadowValidationStarted") {
+		t.Fatalf("expected a ShadowValidationStarted event, got %v", recorder.events)
+	}
+}
+
+func TestShadowValidationPasses(t *testing.T) {
+	soaking := metav1.NewTime(time.Now().Add(-time.Minute))
+	shadow := &mcfgv1.MachineConfigPoolShadowValidationStatus{Config: "v1", Node: "node-0", State: mcfgv1.ShadowValidationValidating, ReadySince: &soaking}
+	patched, _, _ := syncShadowPool(t, shadow, newWorker("node-0", "v1", "v1"), newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 0 {
+		t.Fatalf("expected the rollout to wait for the soak of the shadow node, got patches for %v", patched)
+	}
+
+	soaked := metav1.NewTime(time.Now().Add(-20 * time.Minute))
+	shadow = &mcfgv1.MachineConfigPoolShadowValidationStatus{Config: "v1", Node: "node-0", State: mcfgv1.ShadowValidationValidating, ReadySince: &soaked}
+	patched, status, recorder := syncShadowPool(t, shadow, newWorker("node-0", "v1", "v1"), newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 1 || patched[0] != "node-1" {
+		t.Fatalf("expected the rollout to proceed once the config is validated, got patches for %v", patched)
+	}
+	if status == nil || status.ShadowValidation == nil || status.ShadowValidation.State != mcfgv1.ShadowValidationValidated {
+		t.Fatalf("expected the config to be validated, got %v", status)
+	}
+	if !recorder.has("pool/worker", "ShadowValidationSucceeded") {
+		t.Fatalf("expected a ShadowValidationSucceeded event, got %v", recorder.events)
+	}
+}
+
+func TestShadowValidationFails(t *testing.T) {
+	failing := newWorker("node-0", "v0", "v1")
+	failing.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	shadow := &mcfgv1.MachineConfigPoolShadowValidationStatus{Config: "v1", Node: "node-0", State: mcfgv1.ShadowValidationValidating}
+	var f *fixture
+	onV0 := func(fixture *fixture, mcp *mcfgv1.MachineConfigPool) {
+		f = fixture
+		mcp.Status.Configuration.Name = "v0"
+	}
+	patched, status, recorder := syncShadowPoolWith(t, onV0, shadow, failing, newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 1 || patched[0] != "node-0" {
+		t.Fatalf("expected only the shadow node to be moved back, got patches for %v", patched)
+	}
+	node, err := f.kubeclient.CoreV1().Nodes().Get("node-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != "v0" {
+		t.Fatalf("expected the shadow node to be moved back to v0, got %s", desired)
+	}
+	if status == nil || status.ShadowValidation == nil || status.ShadowValidation.State != mcfgv1.ShadowValidationRejected {
+		t.Fatalf("expected the config to be rejected, got %v", status)
+	}
+	if !recorder.has("pool/worker", "ShadowValidationFailed") {
+		t.Fatalf("expected a ShadowValidationFailed event, got %v", recorder.events)
+	}
+
+	// A rejected config stays rejected, even once the shadow node recovers.
+	shadow = status.ShadowValidation
+	patched, _, _ = syncShadowPool(t, shadow, newWorker("node-0", "v0", "v0"), newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 0 {
+		t.Fatalf("expected a rejected config not to be rolled out, got patches for %v", patched)
+	}
+}
+
+func TestShadowNodeWithinPoolLimits(t *testing.T) {
+	// With maxUnavailable 1 and a node not ready, no shadow node may go down.
+	unready := newWorker("node-1", "v0", "v0")
+	unready.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}
+	patched, status, _ := syncShadowPool(t, nil, newWorker("node-0", "v0", "v0"), unready, newWorker("node-2", "v0", "v0"))
+	if len(patched) != 0 {
+		t.Fatalf("expected no shadow node to be updated past maxUnavailable, got patches for %v", patched)
+	}
+	if status == nil || status.ShadowValidation == nil || status.ShadowValidation.Node != "" {
+		t.Fatalf("expected the shadow node to be yet to be picked, got %v", status)
+	}
+
+	// Held nodes, e.g. under maintenance, aren't picked.
+	maintenance := func(f *fixture, pool *mcfgv1.MachineConfigPool) {
+		f.opts = append(f.opts, WithNodeMaintenanceLister(fakeMaintenanceLister{nodes: []string{"node-0"}}))
+		pool.Spec.MaxUnavailable = intStrPtr(intstr.FromInt(2))
+	}
+	patched, status, _ = syncShadowPoolWith(t, maintenance, nil, newWorker("node-0", "v0", "v0"), newWorker("node-1", "v0", "v0"), newWorker("node-2", "v0", "v0"))
+	if len(patched) != 1 || patched[0] != "node-1" {
+		t.Fatalf("expected node-1 to be updated as shadow node next to the node under maintenance, got patches for %v", patched)
+	}
+	if status == nil || status.ShadowValidation == nil || status.ShadowValidation.Node != "node-1" {
+		t.Fatalf("expected node-1 to be the shadow node, got %v", status)
+	}
+}
+
+func TestShadowNodeDrained(t *testing.T) {
+	// The drain is left running.
+	withDrainPolicy := func(f *fixture, pool *mcfgv1.MachineConfigPool) {
+		pool.Spec.DrainPolicy = &mcfgv1.MachineConfigPoolDrainPolicy{}
+		f.opts = append(f.opts, func(c *Controller) {
+			c.runDrain = func(run func()) {}
+		})
+	}
+	patched, status, recorder := syncShadowPoolWith(t, withDrainPolicy, nil, newWorker("node-0", "v0", "v0"), newWorker("node-1", "v0", "v0"))
+	if len(patched) != 0 {
+		t.Fatalf("expected the shadow node to be drained before it is updated, got patches for %v", patched)
+	}
+	if status == nil || status.ShadowValidation == nil || status.ShadowValidation.Node != "node-0" {
+		t.Fatalf("expected node-0 to be the shadow node, got %v", status)
+	}
+	if !recorder.has("pool/worker", "ShadowValidationStarted") {
+		t.Fatalf("expected a ShadowValidationStarted event, got %v", recorder.events)
+	}
+}
@@ -0,0 +1,72 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// soakRemaining returns how much longer a pool with spec.perNodeSoak has to wait before
+// starting its next node update, given how many of its nodes are now updated and ready.
+// A node that became ready since the status was last written starts a new soak.
+func soakRemaining(pool *mcfgv1.MachineConfigPool, readyMachineCount int32, now time.Time) time.Duration {
+	if pool.Spec.PerNodeSoak == nil {
+		return 0
+	}
+	if readyMachineCount > pool.Status.ReadyMachineCount {
+		return pool.Spec.PerNodeSoak.Duration
+	}
+	if pool.Status.LastNodeUpdateTime == nil {
+		return 0
+	}
+	if remaining := pool.Status.LastNodeUpdateTime.Add(pool.Spec.PerNodeSoak.Duration).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// setLastNodeUpdateTime carries the time the latest node of a pool with spec.perNodeSoak
+// finished updating over to newStatus, setting it to now if another node became ready.
+func setLastNodeUpdateTime(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, now time.Time) {
+	if pool.Spec.PerNodeSoak == nil {
+		return
+	}
+	newStatus.LastNodeUpdateTime = pool.Status.LastNodeUpdateTime
+	if newStatus.ReadyMachineCount > pool.Status.ReadyMachineCount {
+		t := metav1.NewTime(now)
+		newStatus.LastNodeUpdateTime = &t
+	}
+}
+
+// setCompletedCondition tracks in newStatus since when all nodes of a pool with
+// spec.completionSoak are updated and ready, and reports the pool Completed once they
+// stayed so for the soak. It returns how long the soak has still to run.
+func setCompletedCondition(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, now time.Time) time.Duration {
+	if pool.Spec.CompletionSoak == nil {
+		return 0
+	}
+	if !mcfgv1.IsMachineConfigPoolConditionTrue(newStatus.Conditions, mcfgv1.MachineConfigPoolUpdated) {
+		newStatus.UpdatedSince = nil
+		scompleted := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolCompleted, corev1.ConditionFalse, "Updating", "")
+		mcfgv1.SetMachineConfigPoolCondition(newStatus, *scompleted)
+		return 0
+	}
+	newStatus.UpdatedSince = pool.Status.UpdatedSince
+	if newStatus.UpdatedSince == nil {
+		t := metav1.NewTime(now)
+		newStatus.UpdatedSince = &t
+	}
+	if remaining := newStatus.UpdatedSince.Add(pool.Spec.CompletionSoak.Duration).Sub(now); remaining > 0 {
+		scompleted := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolCompleted, corev1.ConditionFalse, "Soaking",
+			fmt.Sprintf("All nodes are updated, soaking for %v before completing", pool.Spec.CompletionSoak.Duration))
+		mcfgv1.SetMachineConfigPoolCondition(newStatus, *scompleted)
+		return remaining
+	}
+	scompleted := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolCompleted, corev1.ConditionTrue, "Soaked",
+		fmt.Sprintf("All nodes have been updated with %s for %v", pool.Spec.Configuration.Name, pool.Spec.CompletionSoak.Duration))
+	mcfgv1.SetMachineConfigPoolCondition(newStatus, *scompleted)
+	return 0
+}
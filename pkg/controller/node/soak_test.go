@@ -0,0 +1,171 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSoakRemaining(t *testing.T) {
+	now := time.Now()
+	soak := &metav1.Duration{Duration: 10 * time.Minute}
+	tests := []struct {
+		name       string
+		soak       *metav1.Duration
+		lastUpdate time.Duration
+		wasReady   int32
+		ready      int32
+		expected   time.Duration
+	}{{
+		name:     "no soak",
+		wasReady: 1,
+		ready:    2,
+	}, {
+		name:     "no node updated yet",
+		soak:     soak,
+		ready:    0,
+		expected: 0,
+	}, {
+		name:     "node just became ready",
+		soak:     soak,
+		wasReady: 1,
+		ready:    2,
+		expected: 10 * time.Minute,
+	}, {
+		name:       "soaking",
+		soak:       soak,
+		lastUpdate: 4 * time.Minute,
+		wasReady:   2,
+		ready:      2,
+		expected:   6 * time.Minute,
+	}, {
+		name:       "soaked",
+		soak:       soak,
+		lastUpdate: 11 * time.Minute,
+		wasReady:   2,
+		ready:      2,
+		expected:   0,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, nil, "v1")
+			pool.Spec.PerNodeSoak = test.soak
+			pool.Status.ReadyMachineCount = test.wasReady
+			if test.lastUpdate != 0 {
+				last := metav1.NewTime(now.Add(-test.lastUpdate))
+				pool.Status.LastNodeUpdateTime = &last
+			}
+			if got := soakRemaining(pool, test.ready, now); got != test.expected {
+				t.Fatalf("mismatch soak remaining: got %v want: %v", got, test.expected)
+			}
+
+			newStatus := mcfgv1.MachineConfigPoolStatus{ReadyMachineCount: test.ready}
+			setLastNodeUpdateTime(pool, &newStatus, now)
+			switch {
+			case test.soak == nil:
+				if newStatus.LastNodeUpdateTime != nil {
+					t.Fatalf("expected no last node update time without a soak")
+				}
+			case test.ready > test.wasReady:
+				if newStatus.LastNodeUpdateTime == nil || !newStatus.LastNodeUpdateTime.Time.Equal(now) {
+					t.Fatalf("expected the last node update time to be now, got %v", newStatus.LastNodeUpdateTime)
+				}
+			case newStatus.LastNodeUpdateTime != pool.Status.LastNodeUpdateTime:
+				t.Fatalf("expected the last node update time to be kept, got %v", newStatus.LastNodeUpdateTime)
+			}
+		})
+	}
+}
+
+func TestPerNodeSoak(t *testing.T) {
+	for _, soaked := range []bool{false, true} {
+		f := newFixture(t)
+		mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(3)), "v1")
+		mcp.Spec.PerNodeSoak = &metav1.Duration{Duration: time.Hour}
+		last := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		if soaked {
+			last = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		}
+		mcp.Status.LastNodeUpdateTime = &last
+		nodes := []*corev1.Node{
+			newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": ""}),
+			newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+			newNodeWithLabel("node-2", "v0", "v0", map[string]string{"node-role/worker": ""}),
+			newNodeWithLabel("node-3", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		}
+		mcp.Status.ReadyMachineCount = 1
+		f.mcpLister = append(f.mcpLister, mcp)
+		f.objects = append(f.objects, mcp)
+		f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+		f.nodeLister = append(f.nodeLister, nodes...)
+		for idx := range nodes {
+			f.kubeobjects = append(f.kubeobjects, nodes[idx])
+		}
+		c := f.newController()
+
+		if err := c.syncHandler(getKey(mcp, t)); err != nil {
+			t.Fatal(err)
+		}
+
+		patched := 0
+		for _, action := range filterInformerActions(f.kubeclient.Actions()) {
+			if action.GetVerb() == "patch" {
+				patched++
+			}
+		}
+		if !soaked && patched != 0 {
+			t.Fatalf("expected no node update while soaking, patched %d", patched)
+		}
+		if !soaked && c.queue.Len() != 0 {
+			t.Fatalf("expected the pool to be requeued after the soak, not right away")
+		}
+		if soaked && patched != 1 {
+			t.Fatalf("expected a single node update after the soak despite maxUnavailable, patched %d", patched)
+		}
+	}
+}
+
+func TestCompletionSoak(t *testing.T) {
+	now := time.Now()
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	pool.Spec.CompletionSoak = &metav1.Duration{Duration: time.Hour}
+	ready := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue),
+	}
+	completed := func(status mcfgv1.MachineConfigPoolStatus) bool {
+		return mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolCompleted)
+	}
+
+	// All nodes just became updated and ready, the soak starts.
+	status := calculateStatus(pool, ready, checkNodeReady)
+	if remaining := setCompletedCondition(pool, &status, now); remaining != time.Hour || completed(status) {
+		t.Fatalf("expected the soak to start, got %v remaining, completed %t", remaining, completed(status))
+	}
+	pool.Status = status
+
+	// A node regresses during the soak and restarts it.
+	regressed := []*corev1.Node{ready[0], newNodeWithReady("node-1", "v1", "v1", corev1.ConditionFalse)}
+	status = calculateStatus(pool, regressed, checkNodeReady)
+	setCompletedCondition(pool, &status, now.Add(30*time.Minute))
+	if status.UpdatedSince != nil || completed(status) {
+		t.Fatalf("expected a regressed node to reset the soak, got %v", status.UpdatedSince)
+	}
+	pool.Status = status
+
+	status = calculateStatus(pool, ready, checkNodeReady)
+	if remaining := setCompletedCondition(pool, &status, now.Add(40*time.Minute)); remaining != time.Hour {
+		t.Fatalf("expected the soak to start over, got %v remaining", remaining)
+	}
+	pool.Status = status
+
+	status = calculateStatus(pool, ready, checkNodeReady)
+	if remaining := setCompletedCondition(pool, &status, now.Add(100*time.Minute)); remaining != 0 || !completed(status) {
+		t.Fatalf("expected the pool to be completed after the soak, got %v remaining, completed %t", remaining, completed(status))
+	}
+}
@@ -0,0 +1,52 @@
+package node
+
+import (
+	"sort"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// getStaggerGroup returns the value of the pool's StaggerByLabel shared by the nodes
+// that are updated now: the group that started updating to the target config but didn't
+// finish yet, or else the next group that still needs updating, in the order of their
+// values. Nodes without the label form the group with the empty value.
+// It returns the empty value if every group is done.
+func getStaggerGroup(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node) string {
+	targetConfig := pool.Spec.Configuration.Name
+	started := map[string]bool{}
+	done := map[string]bool{}
+	for _, node := range nodesInPool {
+		group := node.Labels[pool.Spec.StaggerByLabel]
+		if _, ok := done[group]; !ok {
+			done[group] = true
+		}
+		if isNodeSkipped(pool, node) {
+			continue
+		}
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == targetConfig {
+			started[group] = true
+		}
+		if !isNodeDoneAt(node, targetConfig) {
+			done[group] = false
+		}
+	}
+
+	var groups []string
+	for group := range done {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if started[group] && !done[group] {
+			return group
+		}
+	}
+	for _, group := range groups {
+		if !done[group] {
+			return group
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newGenerationNode(name, generation, currentConfig, desiredConfig string) *corev1.Node {
+	node := newNodeWithReady(name, currentConfig, desiredConfig, corev1.ConditionTrue)
+	node.Labels = map[string]string{"hardware/generation": generation}
+	return node
+}
+
+func TestGetCandidateMachinesStaggerByLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    []*corev1.Node
+		expected []string
+	}{{
+		name: "first group in order starts",
+		nodes: []*corev1.Node{
+			newGenerationNode("node-0", "gen2", "v0", "v0"),
+			newGenerationNode("node-1", "gen1", "v0", "v0"),
+			newGenerationNode("node-2", "gen1", "v0", "v0"),
+			newGenerationNode("node-3", "gen2", "v0", "v0"),
+		},
+		expected: []string{"node-1", "node-2"},
+	}, {
+		name: "started group is finished first",
+		nodes: []*corev1.Node{
+			newGenerationNode("node-0", "gen1", "v0", "v0"),
+			newGenerationNode("node-1", "gen2", "v1", "v1"),
+			newGenerationNode("node-2", "gen1", "v0", "v0"),
+			newGenerationNode("node-3", "gen2", "v0", "v0"),
+		},
+		expected: []string{"node-3"},
+	}, {
+		name: "next group waits for the last update of the current one",
+		nodes: []*corev1.Node{
+			newGenerationNode("node-0", "gen1", "v1", "v1"),
+			newGenerationNode("node-1", "gen1", "v0", "v1"),
+			newGenerationNode("node-2", "gen2", "v0", "v0"),
+			newGenerationNode("node-3", "gen2", "v0", "v0"),
+		},
+		expected: nil,
+	}, {
+		name: "next group starts once the current one is done",
+		nodes: []*corev1.Node{
+			newGenerationNode("node-0", "gen1", "v1", "v1"),
+			newGenerationNode("node-1", "gen1", "v1", "v1"),
+			newGenerationNode("node-2", "gen2", "v0", "v0"),
+			newGenerationNode("node-3", "gen2", "v0", "v0"),
+		},
+		expected: []string{"node-2", "node-3"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, nil, "v1")
+			pool.Spec.StaggerByLabel = "hardware/generation"
+
+			got := getCandidateMachines(pool, test.nodes, 3, checkNodeReady, nil, nil, nil)
+			var nodeNames []string
+			for _, node := range got {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			if !reflect.DeepEqual(nodeNames, test.expected) {
+				t.Fatalf("mismatch: got %v want: %v", nodeNames, test.expected)
+			}
+		})
+	}
+}
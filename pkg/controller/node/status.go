@@ -3,46 +3,91 @@ package node
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func (ctrl *Controller) syncStatusOnly(pool *mcfgv1.MachineConfigPool) error {
-	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if remaining := ctrl.debounceStatus(pool, time.Now()); remaining > 0 {
+		ctrl.enqueueAfter(pool, remaining)
+		return nil
+	}
+	nodes, err := ctrl.getNodesForPool(pool)
 	if err != nil {
 		return err
 	}
-	nodes, err := ctrl.nodeLister.List(selector)
+
+	newStatus := calculateStatus(pool, nodes, ctrl.poolReadyChecker(pool, nodes))
+	unresolved, err := ctrl.getUnresolvedNodes(nodes)
 	if err != nil {
 		return err
 	}
-
-	newStatus := calculateStatus(pool, nodes)
-	if equality.Semantic.DeepEqual(pool.Status, newStatus) {
+	newStatus.UnresolvedMachineCount = int32(unresolved.Len())
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	if err := setSelectorConflictCondition(&newStatus, pool, pools); err != nil {
+		return err
+	}
+	newStatus.EstimatedTimeRemaining = ctrl.updateDurations.estimateTimeRemaining(pool, nodes, newStatus)
+	setLastNodeUpdateTime(pool, &newStatus, time.Now())
+	newStatus.PausedSince = pool.Status.PausedSince
+	ctrl.setLastConfigChangeTime(pool, &newStatus)
+	updateRolloutHistory(pool, &newStatus, time.Now())
+	ctrl.setConfigChange(pool, &newStatus)
+	ctrl.setPausedNodes(pool, &newStatus, nodes)
+	updateMaxUnavailableRamp(pool, &newStatus, nodes)
+	setLastActedConfig(pool, &newStatus)
+	newStatus.ResolvedConfig = pool.Status.ResolvedConfig
+	setZoneProgress(pool, &newStatus, nodes)
+	setShadowValidation(pool, &newStatus)
+	if remaining := setCompletedCondition(pool, &newStatus, time.Now()); remaining > 0 {
+		ctrl.enqueueAfter(pool, remaining)
+	}
+	// The sync may have recorded state on the status of pool itself, so compare with the
+	// status as it was written last.
+	oldStatus := pool.Status
+	if cached, err := ctrl.mcpLister.Get(pool.Name); err == nil {
+		oldStatus = cached.Status
+	}
+	if equality.Semantic.DeepEqual(oldStatus, newStatus) {
 		return nil
 	}
 
+	complete := isRolloutComplete(pool.Status, newStatus)
 	newPool := pool
 	newPool.Status = newStatus
-	_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(newPool)
-	return err
+	if _, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(newPool); err != nil {
+		return err
+	}
+	if complete {
+		ctrl.notifyRolloutComplete(newPool, newStatus.Configuration.Name)
+	}
+	return nil
 }
 
-func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv1.MachineConfigPoolStatus {
+func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, checkReady NodeReadyChecker) mcfgv1.MachineConfigPoolStatus {
 	machineCount := int32(len(nodes))
 
-	updatedMachines := getUpdatedMachines(pool.Spec.Configuration.Name, nodes)
+	updatedMachines := withoutPendingVerifications(pool, withoutPendingPostUpdateHooks(pool, getUpdatedMachines(pool.Spec.Configuration.Name, nodes)))
 	updatedMachineCount := int32(len(updatedMachines))
 
-	readyMachines := getReadyMachines(pool.Spec.Configuration.Name, nodes)
+	readyMachines := withoutPendingVerifications(pool, withoutPendingPostUpdateHooks(pool, getReadyMachines(pool.Spec.Configuration.Name, nodes, checkReady)))
 	readyMachineCount := int32(len(readyMachines))
 
-	unavailableMachines := getUnavailableMachines(nodes)
+	unavailableMachines := getUnavailableMachines(nodes, pool.Spec.UnavailabilityPolicy, checkReady)
+	for _, node := range nodes {
+		if isNodeCordonedUnavailable(pool, node, checkReady) || isPostUpdateHookUnavailable(pool, node, checkReady) || isVerificationUnavailable(pool, node, checkReady) {
+			unavailableMachines = append(unavailableMachines, node)
+		}
+	}
 	unavailableMachineCount := int32(len(unavailableMachines))
 
 	degradedMachines := getDegradedMachines(nodes)
@@ -51,6 +96,8 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		reason, ok := n.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey]
 		if ok && reason != "" {
 			degradedReasons = append(degradedReasons, fmt.Sprintf("Node %s is reporting: %q", n.Name, reason))
+		} else if isVerificationFailed(n) {
+			degradedReasons = append(degradedReasons, fmt.Sprintf("Node %s timed out verifying %s", n.Name, n.Annotations[verificationFailedAnnotationKey]))
 		}
 	}
 	degradedMachineCount := int32(len(degradedMachines))
@@ -74,6 +121,10 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 	allUpdated := updatedMachineCount == machineCount &&
 		readyMachineCount == machineCount &&
 		unavailableMachineCount == 0
+	if allUpdated && pool.Spec.RequireReadyForUpdated {
+		// checkReady may be more lenient than the default readiness check.
+		allUpdated = int32(len(getReadyMachines(pool.Spec.Configuration.Name, nodes, checkNodeReady))) == machineCount
+	}
 
 	if allUpdated {
 		//TODO: update api to only have one condition regarding status of update.
@@ -104,11 +155,48 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
 	}
 
+	if pool.Name == "master" {
+		if m, err := calculateMaxUnavailable(pool, nodes); err == nil {
+			if m.quorumClamped {
+				sclamped := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaxUnavailableClamped, corev1.ConditionTrue, "EtcdQuorum",
+					fmt.Sprintf("Requested maxUnavailable %d lowered to %d to preserve etcd quorum across %d nodes", m.requested, m.effective, len(nodes)))
+				setConditionMessage(&status, sclamped)
+			} else {
+				sclamped := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaxUnavailableClamped, corev1.ConditionFalse, "", "")
+				mcfgv1.SetMachineConfigPoolCondition(&status, *sclamped)
+			}
+		}
+	}
+
+	if pool.Spec.RetainPreviousConfig > 0 || mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolRetainingPreviousConfig) != nil {
+		if previous := getPreviousConfigMachines(pool, nodes); pool.Spec.RetainPreviousConfig > 0 && previous > 0 && previous <= int(pool.Spec.RetainPreviousConfig) {
+			sretaining := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRetainingPreviousConfig, corev1.ConditionTrue, "AwaitingApproval",
+				fmt.Sprintf("Holding the last %d nodes on their previous config, clear spec.retainPreviousConfig to update them to %s", previous, pool.Spec.Configuration.Name))
+			mcfgv1.SetMachineConfigPoolCondition(&status, *sretaining)
+		} else {
+			sretaining := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRetainingPreviousConfig, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(&status, *sretaining)
+		}
+	}
+
 	// here we now set the MCP Degraded field, the node_controller is the one making the call right now
 	// but we might have a dedicated controller or control loop somewhere else that understands how to
 	// set Degraded. For now, the node_controller understand NodeDegraded & RenderDegraded = Degraded.
 	renderDegraded := mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRenderDegraded)
-	if nodeDegraded || renderDegraded {
+	syncDegraded := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolSyncDegraded)
+	if syncDegraded != nil && syncDegraded.Status == corev1.ConditionTrue {
+		// Surface the class of the sync failure on the overall condition.
+		sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, syncDegraded.Reason, syncDegraded.Message)
+		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
+	} else if degraded, err := checkMaxDegraded(pool, nodes); err == nil && degraded.reached {
+		sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "MaxDegradedReached",
+			fmt.Sprintf("The nodes failing to apply %s reached maxDegraded %d, the rollout is halted", pool.Spec.Configuration.Name, degraded.limit))
+		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
+	} else if allNodesFailing(pool, nodes) {
+		sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "AllNodesFailing",
+			fmt.Sprintf("All %d nodes are failing to apply %s, syncing is backed off until one of them recovers", len(nodes), pool.Spec.Configuration.Name))
+		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
+	} else if nodeDegraded || renderDegraded {
 		sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "", "")
 		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
 	} else {
@@ -116,9 +204,40 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		mcfgv1.SetMachineConfigPoolCondition(&status, *sdegraded)
 	}
 
+	status.Phase = calculatePhase(pool, nodes, status)
 	return status
 }
 
+// calculatePhase sums up status, the status calculated for pool and its nodes, in a phase.
+func calculatePhase(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, status mcfgv1.MachineConfigPoolStatus) mcfgv1.MachineConfigPoolPhase {
+	switch {
+	case pool.Spec.Paused:
+		return mcfgv1.MachineConfigPoolPhasePaused
+	case isPhaseDegraded(pool, nodes, status):
+		return mcfgv1.MachineConfigPoolPhaseDegraded
+	case mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolUpdated):
+		return mcfgv1.MachineConfigPoolPhaseUpdated
+	default:
+		return mcfgv1.MachineConfigPoolPhaseUpdating
+	}
+}
+
+// isPhaseDegraded returns whether the Degraded condition of status puts the pool in the
+// Degraded phase. Below spec.maxDegraded, failing nodes only slow down the rollout.
+func isPhaseDegraded(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, status mcfgv1.MachineConfigPoolStatus) bool {
+	if !mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolDegraded) {
+		return false
+	}
+	if pool.Spec.MaxDegraded == nil ||
+		mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolSyncDegraded) ||
+		mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRenderDegraded) ||
+		allNodesFailing(pool, nodes) {
+		return true
+	}
+	degraded, err := checkMaxDegraded(pool, nodes)
+	return err != nil || degraded.reached
+}
+
 // isNodeManaged checks whether the MCD has ever run on a node
 func isNodeManaged(node *corev1.Node) bool {
 	if node.Annotations == nil {
@@ -131,6 +250,12 @@ func isNodeManaged(node *corev1.Node) bool {
 	return true
 }
 
+// needsInitialConfig checks whether a node was never managed by the MCD nor given a
+// desired config, e.g. because it just joined without the initial node annotations.
+func needsInitialConfig(node *corev1.Node) bool {
+	return !isNodeManaged(node) && node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == ""
+}
+
 /// isNodeDone returns true if the current == desired and the MCD has marked done.
 func isNodeDone(node *corev1.Node) bool {
 	if node.Annotations == nil {
@@ -187,11 +312,11 @@ func getUpdatedMachines(poolTargetConfig string, nodes []*corev1.Node) []*corev1
 
 // getReadyMachines filters the provided nodes to ones which are updated
 // and marked ready.
-func getReadyMachines(currentConfig string, nodes []*corev1.Node) []*corev1.Node {
+func getReadyMachines(currentConfig string, nodes []*corev1.Node, checkReady NodeReadyChecker) []*corev1.Node {
 	updated := getUpdatedMachines(currentConfig, nodes)
 	var ready []*corev1.Node
 	for _, node := range updated {
-		if isNodeReady(node) {
+		if checkReady(node) == nil {
 			ready = append(ready, node)
 		}
 	}
@@ -222,21 +347,50 @@ func checkNodeReady(node *corev1.Node) error {
 	return nil
 }
 
+// checkNodePressure returns a non-nil error if the node reports resource pressure, which
+// an update, rebooting it, could make worse.
+func checkNodePressure(node *corev1.Node) error {
+	for i := range node.Status.Conditions {
+		cond := &node.Status.Conditions[i]
+		switch cond.Type {
+		case corev1.NodeDiskPressure, corev1.NodeMemoryPressure, corev1.NodePIDPressure:
+			if cond.Status == corev1.ConditionTrue {
+				return fmt.Errorf("node %s is reporting %s", node.Name, cond.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// isNodeUnderPressure checks whether a node of a pool skipping nodes under pressure is
+// under pressure.
+func isNodeUnderPressure(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	return pool.Spec.SkipNodesUnderPressure && checkNodePressure(node) != nil
+}
+
 func isNodeReady(node *corev1.Node) bool {
 	return checkNodeReady(node) == nil
 }
 
-// isNodeUnavailable is the backend for getUnavailableMachines;
-// see the docs for that for more information.
-func isNodeUnavailable(node *corev1.Node) bool {
-	// Unready nodes are unavailable
-	if !isNodeReady(node) {
+// IsNodeUnavailable returns whether node counts against the maxUnavailable of a pool
+// with the given policy; it is the backend for getUnavailableMachines, see the docs for
+// that for more information. A nil checkReady uses the default node readiness check.
+func IsNodeUnavailable(node *corev1.Node, policy mcfgv1.UnavailabilityPolicy, checkReady NodeReadyChecker) bool {
+	if checkReady == nil {
+		checkReady = checkNodeReady
+	}
+	// Unready nodes are unavailable, unless only updates count
+	if policy != mcfgv1.UnavailabilityPolicyUpdateOnly && checkReady(node) != nil {
 		return true
 	}
 	// Ready nodes are not unavailable
 	if isNodeDone(node) {
 		return false
 	}
+	// Neither are nodes waiting for their initial config, no update is in progress on them
+	if needsInitialConfig(node) {
+		return false
+	}
 	// Now we know the node isn't ready - the current config must not
 	// equal target.  We want to further filter down on the MCD state.
 	// If a MCD is in a terminal (failing) state then we can safely retarget it.
@@ -251,17 +405,44 @@ func isNodeUnavailable(node *corev1.Node) bool {
 // working (or hasn't started) then the node *may* go unschedulable in the future, so we
 // don't want to potentially start another node update exceeding our
 // maxUnavailable.
+// With the UpdateOnly policy only nodes with a MCD actively working are returned.
 // Somewhat the opposite of getReadyNodes().
-func getUnavailableMachines(nodes []*corev1.Node) []*corev1.Node {
+func getUnavailableMachines(nodes []*corev1.Node, policy mcfgv1.UnavailabilityPolicy, checkReady NodeReadyChecker) []*corev1.Node {
 	var unavail []*corev1.Node
 	for _, node := range nodes {
-		if isNodeUnavailable(node) {
+		if IsNodeUnavailable(node, policy, checkReady) {
 			unavail = append(unavail, node)
 		}
 	}
 	return unavail
 }
 
+// isNodeCordonedForUpdate checks whether a node is cordoned while its MCD is working or
+// reports done, most likely because the MCD cordoned it for an update and didn't uncordon
+// it yet. A node cordoned by an admin can't be told apart once its MCD reports done.
+func isNodeCordonedForUpdate(node *corev1.Node) bool {
+	return node.Spec.Unschedulable && (isNodeMCDState(node, daemonconsts.MachineConfigDaemonStateWorking) ||
+		isNodeMCDState(node, daemonconsts.MachineConfigDaemonStateDone))
+}
+
+// isNodeCordonedUnavailable checks whether a node counts as unavailable only because the
+// pool counts nodes cordoned for an update as unavailable.
+func isNodeCordonedUnavailable(pool *mcfgv1.MachineConfigPool, node *corev1.Node, checkReady NodeReadyChecker) bool {
+	return pool.Spec.CountCordonedUnavailable && isNodeCordonedForUpdate(node) &&
+		!IsNodeUnavailable(node, pool.Spec.UnavailabilityPolicy, checkReady)
+}
+
+// getPreviousConfigMachines returns the number of nodes not yet targeting the pool's config.
+func getPreviousConfigMachines(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) int {
+	previous := 0
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != pool.Spec.Configuration.Name {
+			previous++
+		}
+	}
+	return previous
+}
+
 func getDegradedMachines(nodes []*corev1.Node) []*corev1.Node {
 	var degraded []*corev1.Node
 	for _, node := range nodes {
@@ -272,6 +453,10 @@ func getDegradedMachines(nodes []*corev1.Node) []*corev1.Node {
 		if !ok || dconfig == "" {
 			continue
 		}
+		if isVerificationFailed(node) {
+			degraded = append(degraded, node)
+			continue
+		}
 		dstate, ok := node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]
 		if !ok || dstate == "" {
 			continue
@@ -283,3 +468,14 @@ func getDegradedMachines(nodes []*corev1.Node) []*corev1.Node {
 	}
 	return degraded
 }
+
+// setConditionMessage sets condition on status like SetMachineConfigPoolCondition, but also
+// refreshes its message when the status and reason of the condition didn't change.
+func setConditionMessage(status *mcfgv1.MachineConfigPoolStatus, condition *mcfgv1.MachineConfigPoolCondition) {
+	mcfgv1.SetMachineConfigPoolCondition(status, *condition)
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condition.Type {
+			status.Conditions[i].Message = condition.Message
+		}
+	}
+}
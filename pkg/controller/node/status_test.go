@@ -9,6 +9,7 @@ import (
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestIsNodeReady(t *testing.T) {
@@ -205,7 +206,7 @@ func TestGetReadyMachines(t *testing.T) {
 
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
-			ready := getReadyMachines(test.currentConfig, test.nodes)
+			ready := getReadyMachines(test.currentConfig, test.nodes, checkNodeReady)
 			if !reflect.DeepEqual(ready, test.ready) {
 				t.Fatalf("mismatch expected: %v got %v", test.ready, ready)
 			}
@@ -282,7 +283,7 @@ func TestGetUnavailableMachines(t *testing.T) {
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
 			fmt.Printf("Starting case %d\n", idx)
-			unavail := getUnavailableMachines(test.nodes)
+			unavail := getUnavailableMachines(test.nodes, "", checkNodeReady)
 			var unavailNames []string
 			for _, node := range unavail {
 				unavailNames = append(unavailNames, node.Name)
@@ -294,6 +295,81 @@ func TestGetUnavailableMachines(t *testing.T) {
 	}
 }
 
+func TestGetUnavailableMachinesPolicy(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionFalse),
+		newNodeWithReady("node-1", "v0", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue),
+	}
+	tests := []struct {
+		policy  mcfgv1.UnavailabilityPolicy
+		unavail []string
+	}{{
+		policy:  "",
+		unavail: []string{"node-0", "node-1"},
+	}, {
+		policy:  mcfgv1.UnavailabilityPolicyStrict,
+		unavail: []string{"node-0", "node-1"},
+	}, {
+		// A node done updating but not ready doesn't hold back others
+		policy:  mcfgv1.UnavailabilityPolicyUpdateOnly,
+		unavail: []string{"node-1"},
+	}}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			var unavailNames []string
+			for _, node := range getUnavailableMachines(nodes, test.policy, checkNodeReady) {
+				unavailNames = append(unavailNames, node.Name)
+			}
+			if !reflect.DeepEqual(unavailNames, test.unavail) {
+				t.Fatalf("mismatch expected: %v got %v", test.unavail, unavailNames)
+			}
+		})
+	}
+}
+
+func TestCalculateStatusRetainingPreviousConfig(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("node-0", "v1", "v1"),
+		newNode("node-1", "v0", "v0"),
+		newNode("node-2", "v0", "v0"),
+	}
+	tests := []struct {
+		retain   int32
+		status   corev1.ConditionStatus
+		expected string
+	}{{
+		retain:   2,
+		status:   corev1.ConditionTrue,
+		expected: "Holding the last 2 nodes on their previous config, clear spec.retainPreviousConfig to update them to v1",
+	}, {
+		retain: 1,
+		status: corev1.ConditionFalse,
+	}, {
+		retain:   5,
+		status:   corev1.ConditionTrue,
+		expected: "Holding the last 2 nodes on their previous config, clear spec.retainPreviousConfig to update them to v1",
+	}}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("retain %d", test.retain), func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, nil, "v1")
+			pool.Spec.RetainPreviousConfig = test.retain
+			status := calculateStatus(pool, nodes, checkNodeReady)
+			cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolRetainingPreviousConfig)
+			if cond == nil || cond.Status != test.status || cond.Message != test.expected {
+				t.Fatalf("mismatch condition: got %v want status %s and message %q", cond, test.status, test.expected)
+			}
+		})
+	}
+
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	if status := calculateStatus(pool, nodes, checkNodeReady); mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolRetainingPreviousConfig) != nil {
+		t.Fatalf("expected no condition for pools that never retained nodes")
+	}
+}
+
 func TestCalculateStatus(t *testing.T) {
 	tests := []struct {
 		nodes         []*corev1.Node
@@ -602,8 +678,196 @@ func TestCalculateStatus(t *testing.T) {
 					Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: test.currentConfig}},
 				},
 			}
-			status := calculateStatus(pool, test.nodes)
+			status := calculateStatus(pool, test.nodes, checkNodeReady)
 			test.verify(status, t)
 		})
 	}
 }
+
+func TestCalculateStatusMaxUnavailableClamped(t *testing.T) {
+	tests := []struct {
+		poolName   string
+		maxUnavail *intstr.IntOrString
+		nodes      []*corev1.Node
+		conditions []mcfgv1.MachineConfigPoolCondition
+
+		expected corev1.ConditionStatus
+		message  string
+	}{{
+		poolName:   "master",
+		maxUnavail: intStrPtr(intstr.FromInt(2)),
+		nodes:      newNodeSet(3),
+		expected:   corev1.ConditionTrue,
+		message:    "Requested maxUnavailable 2 lowered to 1 to preserve etcd quorum across 3 nodes",
+	}, {
+		// The message of a pool that stays clamped follows its size.
+		poolName:   "master",
+		maxUnavail: intStrPtr(intstr.FromInt(4)),
+		nodes:      newNodeSet(5),
+		conditions: []mcfgv1.MachineConfigPoolCondition{*mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolMaxUnavailableClamped, corev1.ConditionTrue, "EtcdQuorum", "Requested maxUnavailable 4 lowered to 1 to preserve etcd quorum across 3 nodes")},
+		expected:   corev1.ConditionTrue,
+		message:    "Requested maxUnavailable 4 lowered to 2 to preserve etcd quorum across 5 nodes",
+	}, {
+		poolName:   "master",
+		maxUnavail: intStrPtr(intstr.FromInt(1)),
+		nodes:      newNodeSet(3),
+		expected:   corev1.ConditionFalse,
+	}, {
+		poolName:   "worker",
+		maxUnavail: intStrPtr(intstr.FromInt(2)),
+		nodes:      newNodeSet(3),
+	}}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := &mcfgv1.MachineConfigPool{
+				ObjectMeta: metav1.ObjectMeta{Name: test.poolName},
+				Spec: mcfgv1.MachineConfigPoolSpec{
+					MaxUnavailable: test.maxUnavail,
+					Configuration:  mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+				},
+				Status: mcfgv1.MachineConfigPoolStatus{Conditions: test.conditions},
+			}
+			status := calculateStatus(pool, test.nodes, checkNodeReady)
+			cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolMaxUnavailableClamped)
+			if test.expected == "" {
+				if cond != nil {
+					t.Fatalf("unexpected clamped condition: %v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatal("clamped condition not found")
+			}
+			if cond.Status != test.expected {
+				t.Fatalf("mismatch clamped status: got %s want: %s", cond.Status, test.expected)
+			}
+			if cond.Message != test.message {
+				t.Fatalf("mismatch clamped message: got %q want: %q", cond.Message, test.message)
+			}
+		})
+	}
+}
+
+func TestCalculateStatusRequireReadyForUpdated(t *testing.T) {
+	lenient := func(*corev1.Node) error { return nil }
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v1", "v1", corev1.ConditionFalse),
+	}
+	for _, require := range []bool{false, true} {
+		pool := newMachineConfigPool("worker", nil, nil, "v1")
+		pool.Spec.RequireReadyForUpdated = require
+		status := calculateStatus(pool, nodes, lenient)
+		if updated := mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolUpdated); updated == require {
+			t.Fatalf("requireReadyForUpdated %t: expected Updated %t with a done but unready node, got %t", require, !require, updated)
+		}
+	}
+}
+
+func TestCountCordonedUnavailable(t *testing.T) {
+	nodes := []*corev1.Node{
+		// Done, but not yet uncordoned by its MCD.
+		newCordonedNode("node-0", "v1", "v1"),
+		newNodeWithReady("node-1", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+	}
+	ignoreCordon := func(*corev1.Node) error { return nil }
+	for _, policy := range []mcfgv1.UnavailabilityPolicy{mcfgv1.UnavailabilityPolicyStrict, mcfgv1.UnavailabilityPolicyUpdateOnly} {
+		t.Run(string(policy), func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+			pool.Spec.UnavailabilityPolicy = policy
+			if status := calculateStatus(pool, nodes, ignoreCordon); status.UnavailableMachineCount != 0 {
+				t.Fatalf("expected the cordoned but ready node to count as available by default, got %d unavailable", status.UnavailableMachineCount)
+			}
+			if got := getCandidateMachines(pool, nodes, 2, ignoreCordon, nil, nil, nil); len(got) != 2 {
+				t.Fatalf("expected 2 candidates by default, got %d", len(got))
+			}
+
+			pool.Spec.CountCordonedUnavailable = true
+			if status := calculateStatus(pool, nodes, ignoreCordon); status.UnavailableMachineCount != 1 {
+				t.Fatalf("expected the cordoned node to count as unavailable, got %d unavailable", status.UnavailableMachineCount)
+			}
+			if got := getCandidateMachines(pool, nodes, 2, ignoreCordon, nil, nil, nil); len(got) != 1 {
+				t.Fatalf("expected the cordoned node to take a slot of maxUnavailable, got %d candidates", len(got))
+			}
+		})
+	}
+}
+
+func TestCalculateStatusPhase(t *testing.T) {
+	ready := func(name, current, desired string) *corev1.Node {
+		return newNodeWithReady(name, current, desired, corev1.ConditionTrue)
+	}
+	failing := func(name string) *corev1.Node {
+		return newNodeWithReadyAndDaemonState(name, "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded)
+	}
+	tests := []struct {
+		name         string
+		nodes        []*corev1.Node
+		paused       bool
+		maxDegraded  *intstr.IntOrString
+		syncDegraded bool
+
+		expected mcfgv1.MachineConfigPoolPhase
+	}{{
+		name:     "all updated and ready",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v1", "v1")},
+		expected: mcfgv1.MachineConfigPoolPhaseUpdated,
+	}, {
+		name:     "one node updating",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v0", "v1")},
+		expected: mcfgv1.MachineConfigPoolPhaseUpdating,
+	}, {
+		name:     "one node not updated yet",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v0", "v0")},
+		expected: mcfgv1.MachineConfigPoolPhaseUpdating,
+	}, {
+		name:     "all updated but one unready",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), newNodeWithReady("node-1", "v1", "v1", corev1.ConditionFalse)},
+		expected: mcfgv1.MachineConfigPoolPhaseUpdating,
+	}, {
+		name:     "one node failing without maxDegraded",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v0", "v0"), failing("node-2")},
+		expected: mcfgv1.MachineConfigPoolPhaseDegraded,
+	}, {
+		name:        "failing nodes below maxDegraded",
+		nodes:       []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v0", "v0"), failing("node-2")},
+		maxDegraded: intStrPtr(intstr.FromInt(2)),
+		expected:    mcfgv1.MachineConfigPoolPhaseUpdating,
+	}, {
+		name:        "failing nodes reaching maxDegraded",
+		nodes:       []*corev1.Node{ready("node-0", "v1", "v1"), failing("node-1"), failing("node-2")},
+		maxDegraded: intStrPtr(intstr.FromInt(2)),
+		expected:    mcfgv1.MachineConfigPoolPhaseDegraded,
+	}, {
+		name:         "sync failing below maxDegraded",
+		nodes:        []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v0", "v0")},
+		maxDegraded:  intStrPtr(intstr.FromInt(2)),
+		syncDegraded: true,
+		expected:     mcfgv1.MachineConfigPoolPhaseDegraded,
+	}, {
+		name:     "paused while updated",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), ready("node-1", "v1", "v1")},
+		paused:   true,
+		expected: mcfgv1.MachineConfigPoolPhasePaused,
+	}, {
+		name:     "paused while failing",
+		nodes:    []*corev1.Node{ready("node-0", "v1", "v1"), failing("node-1")},
+		paused:   true,
+		expected: mcfgv1.MachineConfigPoolPhasePaused,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+			pool.Spec.Paused = test.paused
+			pool.Spec.MaxDegraded = test.maxDegraded
+			if test.syncDegraded {
+				setSyncDegradedCondition(&pool.Status, newSyncError(syncErrorListNodes, fmt.Errorf("connection refused")))
+			}
+			if status := calculateStatus(pool, test.nodes, checkNodeReady); status.Phase != test.expected {
+				t.Fatalf("expected phase %s, got %s", test.expected, status.Phase)
+			}
+		})
+	}
+}
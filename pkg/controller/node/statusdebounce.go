@@ -0,0 +1,100 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// statusDebouncer remembers when the status of pools was last recomputed, and which
+// pools were queued by node events since their last sync.
+type statusDebouncer struct {
+	lock       sync.Mutex
+	last       map[string]time.Time
+	nodeEvents map[string]bool
+}
+
+func newStatusDebouncer() *statusDebouncer {
+	return &statusDebouncer{last: map[string]time.Time{}, nodeEvents: map[string]bool{}}
+}
+
+// nodeEvent records that pool was queued by an event of one of its nodes.
+func (s *statusDebouncer) nodeEvent(pool string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nodeEvents[pool] = true
+}
+
+// takeNodeEvent returns whether pool was queued by a node event since the last call.
+func (s *statusDebouncer) takeNodeEvent(pool string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	queued := s.nodeEvents[pool]
+	delete(s.nodeEvents, pool)
+	return queued
+}
+
+// recompute returns how long the status of pool must wait to be recomputed so that it
+// is recomputed at most once per window. If it needn't wait, the recomputation at now
+// is recorded.
+func (s *statusDebouncer) recompute(pool string, window time.Duration, now time.Time) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if last, ok := s.last[pool]; ok {
+		if remaining := last.Add(window).Sub(now); remaining > 0 {
+			return remaining
+		}
+	}
+	s.last[pool] = now
+	return 0
+}
+
+// forget drops the last recomputation of pool, e.g. because it was deleted.
+func (s *statusDebouncer) forget(pool string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.last, pool)
+	delete(s.nodeEvents, pool)
+}
+
+// WithStatusDebounce recomputes the status of a pool at most once per window. On large
+// pools frequent node events otherwise walk all nodes every time. Status recomputations
+// of syncs queued by node events falling into the window are coalesced into one at its
+// end, so the status still catches up with the latest state. Changes to the spec of a
+// pool, and status fields set by a sync, are always reflected right away. Zero, the default,
+// recomputes the status on every sync.
+func WithStatusDebounce(window time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.statusDebounce = window
+	}
+}
+
+// debounceStatus returns how long the status recomputation of pool must be delayed.
+func (ctrl *Controller) debounceStatus(pool *mcfgv1.MachineConfigPool, now time.Time) time.Duration {
+	if ctrl.statusDebounce <= 0 {
+		return 0
+	}
+	nodeEvent := ctrl.statusRecomputes.takeNodeEvent(pool.Name)
+	if !nodeEvent || pool.Status.ObservedGeneration != pool.Generation || ctrl.statusChanged(pool) {
+		ctrl.statusRecomputes.recompute(pool.Name, 0, now)
+		return 0
+	}
+	return ctrl.statusRecomputes.recompute(pool.Name, ctrl.statusDebounce, now)
+}
+
+// statusChanged returns whether the sync of pool changed its status from the status as
+// it was written last, e.g. its conditions or the state of its shadow validation, which
+// would be lost if the recomputation was delayed.
+func (ctrl *Controller) statusChanged(pool *mcfgv1.MachineConfigPool) bool {
+	cached, err := ctrl.mcpLister.Get(pool.Name)
+	if err != nil {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(cached.Status, pool.Status)
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatusDebounce(t *testing.T) {
+	now := time.Now()
+	f := newFixture(t)
+	f.opts = append(f.opts, WithStatusDebounce(time.Minute))
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	f.mcpLister = append(f.mcpLister, pool)
+	c := f.newController()
+	// The lister serves written, the pool as written last.
+	written := pool
+	pool = pool.DeepCopy()
+	debounceNodeEvent := func(at time.Duration) time.Duration {
+		c.statusRecomputes.nodeEvent(pool.Name)
+		return c.debounceStatus(pool, now.Add(at))
+	}
+
+	if remaining := debounceNodeEvent(0); remaining != 0 {
+		t.Fatalf("expected the first recomputation to go ahead, got %v", remaining)
+	}
+	if remaining := debounceNodeEvent(20 * time.Second); remaining != 40*time.Second {
+		t.Fatalf("expected the recomputation to wait for the end of the window, got %v", remaining)
+	}
+
+	// A spec change is reflected right away, and starts a new window.
+	pool.Generation = 2
+	if remaining := debounceNodeEvent(30 * time.Second); remaining != 0 {
+		t.Fatalf("expected a spec change to be recomputed right away, got %v", remaining)
+	}
+	pool.Status.ObservedGeneration = 2
+	written.Status.ObservedGeneration = 2
+	if remaining := debounceNodeEvent(40 * time.Second); remaining != 50*time.Second {
+		t.Fatalf("expected the recomputation to wait for the end of the new window, got %v", remaining)
+	}
+
+	if remaining := debounceNodeEvent(90 * time.Second); remaining != 0 {
+		t.Fatalf("expected the recomputation to go ahead after the window, got %v", remaining)
+	}
+}
+
+func TestStatusDebounceOnlyNodeEvents(t *testing.T) {
+	now := time.Now()
+	f := newFixture(t)
+	f.opts = append(f.opts, WithStatusDebounce(time.Minute))
+	pool := newMachineConfigPool("worker", nil, nil, "v1")
+	f.mcpLister = append(f.mcpLister, pool)
+	c := f.newController()
+	pool = pool.DeepCopy()
+
+	c.statusRecomputes.nodeEvent(pool.Name)
+	if remaining := c.debounceStatus(pool, now); remaining != 0 {
+		t.Fatalf("expected the first recomputation to go ahead, got %v", remaining)
+	}
+	// Syncs that weren't queued by a node event aren't debounced.
+	if remaining := c.debounceStatus(pool, now.Add(10*time.Second)); remaining != 0 {
+		t.Fatalf("expected a sync without node events to be recomputed right away, got %v", remaining)
+	}
+	// Nor are syncs that changed the conditions of the pool.
+	sgated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutGated, corev1.ConditionTrue, "GateClosed", "")
+	mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *sgated)
+	c.statusRecomputes.nodeEvent(pool.Name)
+	if remaining := c.debounceStatus(pool, now.Add(20*time.Second)); remaining != 0 {
+		t.Fatalf("expected a condition change to be recomputed right away, got %v", remaining)
+	}
+	// Nor are syncs that changed other status fields.
+	cached, err := c.mcpLister.Get(pool.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool = cached.DeepCopy()
+	pool.Status.ShadowValidation = &mcfgv1.MachineConfigPoolShadowValidationStatus{Config: "v1", State: mcfgv1.ShadowValidationValidating, Node: "node-0"}
+	c.statusRecomputes.nodeEvent(pool.Name)
+	if remaining := c.debounceStatus(pool, now.Add(30*time.Second)); remaining != 0 {
+		t.Fatalf("expected a shadow validation change to be recomputed right away, got %v", remaining)
+	}
+	pool.Status.ShadowValidation = nil
+	pool.Status.LastActedConfig = "v1"
+	c.statusRecomputes.nodeEvent(pool.Name)
+	if remaining := c.debounceStatus(pool, now.Add(40*time.Second)); remaining != 0 {
+		t.Fatalf("expected a lastActedConfig change to be recomputed right away, got %v", remaining)
+	}
+	// A sync leaving the status alone is debounced.
+	pool.Status.LastActedConfig = ""
+	c.statusRecomputes.nodeEvent(pool.Name)
+	if remaining := c.debounceStatus(pool, now.Add(50*time.Second)); remaining == 0 {
+		t.Fatalf("expected an unchanged status to be debounced")
+	}
+}
+
+func BenchmarkSyncStatusOnly(b *testing.B) {
+	for _, window := range []time.Duration{0, time.Second} {
+		b.Run(fmt.Sprintf("debounce=%v", window), func(b *testing.B) {
+			f := newFixture(nil)
+			f.opts = append(f.opts, WithStatusDebounce(window))
+			selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", "")
+			pool := newMachineConfigPool("worker", selector, nil, "v1")
+			f.mcpLister = append(f.mcpLister, pool)
+			f.objects = append(f.objects, pool)
+			for i := 0; i < 500; i++ {
+				f.nodeLister = append(f.nodeLister, newNodeWithLabel(fmt.Sprintf("node-%d", i), "v1", "v1", map[string]string{"node-role/worker": ""}))
+			}
+			c := f.newController()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.statusRecomputes.nodeEvent(pool.Name)
+				if err := c.syncStatusOnly(pool.DeepCopy()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package node
+
+import (
+	"encoding/json"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// candidateSummary is the outcome of picking the nodes of a pool to update in a sync,
+// written as JSON to the candidate-summary annotation of the pool.
+type candidateSummary struct {
+	Nodes          int `json:"nodes"`
+	Ready          int `json:"ready"`
+	Unavailable    int `json:"unavailable"`
+	FailingConfig  int `json:"failingConfig"`
+	MaxUnavailable int `json:"maxUnavailable"`
+	Candidates     int `json:"candidates"`
+}
+
+// WithCandidateSummary has every sync that picks nodes to update summarize how it did
+// in the candidate-summary annotation of the pool, for tools that can't parse events.
+// The annotation is only written when the summary changes.
+func WithCandidateSummary(enabled bool) Option {
+	return func(ctrl *Controller) {
+		ctrl.candidateSummary = enabled
+	}
+}
+
+// summarizeCandidates returns the summary of picking candidates among the nodes of pool
+// with maxUnavailable.
+func summarizeCandidates(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, maxUnavailable int, candidates []*corev1.Node, checkReady NodeReadyChecker) candidateSummary {
+	return candidateSummary{
+		Nodes:          len(nodes),
+		Ready:          len(getReadyMachines(pool.Spec.Configuration.Name, nodes, checkReady)),
+		Unavailable:    len(getUnavailableMachines(nodes, pool.Spec.UnavailabilityPolicy, checkReady)),
+		FailingConfig:  countFailingThisConfig(pool, nodes),
+		MaxUnavailable: maxUnavailable,
+		Candidates:     len(candidates),
+	}
+}
+
+// writeCandidateSummary sets the candidate-summary annotation of pool to summary, unless
+// it is up to date. pool must be a copy; it is updated to the written pool, so that the
+// status of the pool can still be updated afterwards.
+func (ctrl *Controller) writeCandidateSummary(pool *mcfgv1.MachineConfigPool, summary candidateSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if pool.Annotations[candidateSummaryAnnotationKey] == string(data) {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{candidateSummaryAnnotationKey: string(data)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	glog.V(4).Infof("Pool %s: candidate summary %s", pool.Name, data)
+	patched, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Patch(pool.Name, types.MergePatchType, patch)
+	if err != nil {
+		return err
+	}
+	pool.Annotations = patched.Annotations
+	pool.ResourceVersion = patched.ResourceVersion
+	return nil
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCandidateSummary(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithCandidateSummary(true))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(3)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", labels),
+		newNodeWithLabel("node-1", "v0", "v0", labels),
+		newNodeWithLabel("node-2", "v0", "v1", labels),
+		newNodeWithLabel("node-3", "v0", "v0", labels),
+	}
+	nodes[2].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get("worker", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got candidateSummary
+	if err := json.Unmarshal([]byte(pool.Annotations[candidateSummaryAnnotationKey]), &got); err != nil {
+		t.Fatalf("expected a JSON candidate summary, got %q: %v", pool.Annotations[candidateSummaryAnnotationKey], err)
+	}
+	// Two nodes are left to update, and node-2 failing v1 takes one of their slots.
+	want := candidateSummary{Nodes: 4, Ready: 1, Unavailable: 0, FailingConfig: 1, MaxUnavailable: 3, Candidates: 1}
+	if got != want {
+		t.Fatalf("mismatch candidate summary: got %+v want: %+v", got, want)
+	}
+
+	// An up to date summary isn't written again.
+	f.client.ClearActions()
+	if err := c.writeCandidateSummary(pool, want); err != nil {
+		t.Fatal(err)
+	}
+	if actions := filterInformerActions(f.client.Actions()); len(actions) != 0 {
+		t.Fatalf("expected no write of an unchanged summary, got %v", actions)
+	}
+}
@@ -0,0 +1,67 @@
+package node
+
+import (
+	"fmt"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the SyncDegraded condition, one per class of sync failure.
+const (
+	syncErrorInvalidNodeSelector   = "InvalidNodeSelector"
+	syncErrorListNodes             = "ListNodesFailed"
+	syncErrorMachineConfigLookup   = "MachineConfigLookupFailed"
+	syncErrorMachineConfigNotFound = "MachineConfigNotFound"
+	syncErrorInvalidMaxUnavailable = "InvalidMaxUnavailable"
+	syncErrorInvalidMaxDegraded    = "InvalidMaxDegraded"
+	syncErrorInvalidShadowSelector = "InvalidShadowNodeSelector"
+	syncErrorRolloutGate           = "RolloutGateFailed"
+	syncErrorBlockingJob           = "BlockingJobLookupFailed"
+	syncErrorNodeMaintenance       = "NodeMaintenanceLookupFailed"
+	syncErrorPoolLookup            = "PoolLookupFailed"
+	syncErrorQuarantine            = "QuarantineUpdateFailed"
+	syncErrorSetDesiredConfig      = "SetDesiredConfigFailed"
+	syncErrorUnknown               = "SyncFailed"
+)
+
+// syncError is an error of a pool sync along with the class of the failure.
+type syncError struct {
+	reason string
+	err    error
+}
+
+func (e *syncError) Error() string {
+	return e.err.Error()
+}
+
+// newSyncError wraps err, if any, in a syncError of the given class.
+func newSyncError(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &syncError{reason: reason, err: err}
+}
+
+// syncErrorReason returns the class of a pool sync error.
+func syncErrorReason(err error) string {
+	if serr, ok := err.(*syncError); ok {
+		return serr.reason
+	}
+	return syncErrorUnknown
+}
+
+// setSyncDegradedCondition reports the outcome of a pool sync in its SyncDegraded condition.
+// Pools that never failed to sync get no condition.
+func setSyncDegradedCondition(status *mcfgv1.MachineConfigPoolStatus, err error) {
+	if err == nil {
+		if mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolSyncDegraded) != nil {
+			sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolSyncDegraded, corev1.ConditionFalse, "", "")
+			mcfgv1.SetMachineConfigPoolCondition(status, *sdegraded)
+		}
+		return
+	}
+	sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolSyncDegraded, corev1.ConditionTrue, syncErrorReason(err), fmt.Sprintf("Failed to sync the pool: %v", err))
+	// Keep the message current while the failure class stays the same.
+	setConditionMessage(status, sdegraded)
+}
@@ -0,0 +1,78 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSetSyncDegradedCondition(t *testing.T) {
+	var status mcfgv1.MachineConfigPoolStatus
+	setSyncDegradedCondition(&status, nil)
+	if len(status.Conditions) != 0 {
+		t.Fatalf("expected no condition for a pool that never failed to sync, got %v", status.Conditions)
+	}
+
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{err: newSyncError(syncErrorInvalidMaxUnavailable, fmt.Errorf("invalid value")), reason: syncErrorInvalidMaxUnavailable},
+		{err: newSyncError(syncErrorSetDesiredConfig, fmt.Errorf("conflict")), reason: syncErrorSetDesiredConfig},
+		{err: fmt.Errorf("something else"), reason: syncErrorUnknown},
+	}
+	for _, test := range tests {
+		setSyncDegradedCondition(&status, test.err)
+		cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolSyncDegraded)
+		if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != test.reason {
+			t.Fatalf("mismatch condition for %v: got %v want reason: %s", test.err, cond, test.reason)
+		}
+		if want := "Failed to sync the pool: " + test.err.Error(); cond.Message != want {
+			t.Fatalf("mismatch message: got %q want: %q", cond.Message, want)
+		}
+	}
+
+	setSyncDegradedCondition(&status, nil)
+	if mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolSyncDegraded) {
+		t.Fatalf("expected a successful sync to clear the condition")
+	}
+}
+
+type failingRolloutGate struct {
+	err error
+}
+
+func (g failingRolloutGate) Allowed() (bool, string, error) {
+	return false, "", g.err
+}
+
+func TestSyncDegraded(t *testing.T) {
+	f := newFixture(t)
+	f.opts = append(f.opts, WithRolloutGate(failingRolloutGate{err: fmt.Errorf("metrics unavailable")}))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+
+	expMcp := mcp.DeepCopy()
+	setSyncDegradedCondition(&expMcp.Status, newSyncError(syncErrorRolloutGate, fmt.Errorf("error consulting rollout gate: metrics unavailable")))
+	expMcp.Status = calculateStatus(expMcp, nodes, checkNodeReady)
+	f.expectUpdateMachineConfigPoolStatus(expMcp)
+	f.runExpectError(getKey(mcp, t))
+
+	degraded := mcfgv1.GetMachineConfigPoolCondition(expMcp.Status, mcfgv1.MachineConfigPoolDegraded)
+	if degraded == nil || degraded.Status != corev1.ConditionTrue || degraded.Reason != syncErrorRolloutGate {
+		t.Fatalf("expected the pool to be degraded with reason %s, got %v", syncErrorRolloutGate, degraded)
+	}
+}
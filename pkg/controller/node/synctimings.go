@@ -0,0 +1,51 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// syncPhase is a phase of a pool sync whose duration is broken down in the sync log.
+type syncPhase string
+
+const (
+	// syncPhaseListNodes lists the nodes of the pool.
+	syncPhaseListNodes syncPhase = "listNodes"
+	// syncPhaseSelectCandidates picks the nodes to update next.
+	syncPhaseSelectCandidates syncPhase = "selectCandidates"
+	// syncPhasePatchNodes sets the desired config of the picked nodes, along with the
+	// labels, taints and annotations set with it.
+	syncPhasePatchNodes syncPhase = "patchNodes"
+	// syncPhaseSyncStatus recomputes and writes the status of the pool.
+	syncPhaseSyncStatus syncPhase = "syncStatus"
+)
+
+// syncPhases are the phases of a pool sync, in the order they run.
+var syncPhases = []syncPhase{syncPhaseListNodes, syncPhaseSelectCandidates, syncPhasePatchNodes, syncPhaseSyncStatus}
+
+// syncTimings is the time a pool sync spent in each of its phases. Phases a sync
+// returned before are zero.
+type syncTimings map[syncPhase]time.Duration
+
+// since adds the time since start to phase. It is meant to be deferred, or called
+// right after the phase.
+func (t syncTimings) since(phase syncPhase, start time.Time) {
+	t[phase] += time.Since(start)
+}
+
+func (t syncTimings) String() string {
+	parts := make([]string, 0, len(syncPhases))
+	for _, phase := range syncPhases {
+		parts = append(parts, fmt.Sprintf("%s=%v", phase, t[phase]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// logSyncTimings logs how long the sync of the pool with the given key took overall,
+// and in each of its phases.
+func logSyncTimings(key string, total time.Duration, timings syncTimings) {
+	glog.V(4).Infof("Finished syncing machineconfigpool %q (%v: %v)", key, total, timings)
+}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSyncTimings(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/worker": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/worker": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+	var observed []syncTimings
+	var totals []time.Duration
+	c.observeSyncTimings = func(key string, total time.Duration, timings syncTimings) {
+		if key != "worker" {
+			t.Fatalf("expected the timings of the worker pool, got %s", key)
+		}
+		observed = append(observed, timings)
+		totals = append(totals, total)
+	}
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+	if len(observed) != 1 {
+		t.Fatalf("expected the timings of every sync, got %d", len(observed))
+	}
+	var sum time.Duration
+	for _, phase := range syncPhases {
+		if observed[0][phase] <= 0 {
+			t.Fatalf("expected phase %s to be timed, got %v", phase, observed[0])
+		}
+		sum += observed[0][phase]
+	}
+	if sum > totals[0] {
+		t.Fatalf("expected the phases to take at most the %v of the sync, got %v", totals[0], observed[0])
+	}
+}
+
+func TestSyncTimingsString(t *testing.T) {
+	timings := syncTimings{syncPhaseSyncStatus: time.Second, syncPhaseListNodes: time.Millisecond}
+	expected := "listNodes=1ms selectCandidates=0s patchNodes=0s syncStatus=1s"
+	if got := timings.String(); got != expected {
+		t.Fatalf("mismatch timings: got %q want: %q", got, expected)
+	}
+}
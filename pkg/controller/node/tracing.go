@@ -0,0 +1,44 @@
+package node
+
+// TracerProvider starts the spans traced for every pool sync. It covers the small part
+// of a tracing API the node controller needs, so e.g. an OpenTelemetry tracer can be
+// plugged in with a thin adapter.
+type TracerProvider interface {
+	// StartSpan starts a root span with the given name.
+	StartSpan(name string) Span
+}
+
+// Span is a traced operation.
+type Span interface {
+	// StartChild starts a span with the given name as a child of this one.
+	StartChild(name string) Span
+	SetStringAttribute(key, value string)
+	SetIntAttribute(key string, value int64)
+	// SetError records the outcome of the operation, a nil err meaning success.
+	SetError(err error)
+	End()
+}
+
+// WithTracerProvider traces every pool sync as a span, with a child span for every
+// node whose desired config is set. Without it tracing is a no-op.
+func WithTracerProvider(tp TracerProvider) Option {
+	return func(ctrl *Controller) {
+		if tp != nil {
+			ctrl.tracer = tp
+		}
+	}
+}
+
+// noopTracer is the default TracerProvider. Its spans are empty structs, so tracing
+// doesn't allocate while disabled.
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(string) Span { return noopSpan{} }
+
+type noopSpan struct{}
+
+func (noopSpan) StartChild(string) Span            { return noopSpan{} }
+func (noopSpan) SetStringAttribute(string, string) {}
+func (noopSpan) SetIntAttribute(string, int64)     {}
+func (noopSpan) SetError(error)                    {}
+func (noopSpan) End()                              {}
@@ -0,0 +1,99 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type fakeSpan struct {
+	name     string
+	attrs    map[string]interface{}
+	err      error
+	ended    bool
+	children []*fakeSpan
+}
+
+func (s *fakeSpan) StartChild(name string) Span {
+	child := &fakeSpan{name: name, attrs: map[string]interface{}{}}
+	s.children = append(s.children, child)
+	return child
+}
+func (s *fakeSpan) SetStringAttribute(key, value string)    { s.attrs[key] = value }
+func (s *fakeSpan) SetIntAttribute(key string, value int64) { s.attrs[key] = value }
+func (s *fakeSpan) SetError(err error)                      { s.err = err }
+func (s *fakeSpan) End()                                    { s.ended = true }
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) StartSpan(name string) Span {
+	span := &fakeSpan{name: name, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestSyncTracing(t *testing.T) {
+	f := newFixture(t)
+	tracer := &fakeTracer{}
+	f.opts = append(f.opts, WithTracerProvider(tracer))
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(2)), "v1")
+	labels := map[string]string{"node-role/worker": ""}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v0", "v0", labels),
+		newNodeWithLabel("node-1", "v0", "v0", labels),
+		newNodeWithLabel("node-2", "v0", "v0", labels),
+	}
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("expected a span per sync, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if !span.ended || span.err != nil || span.name != "syncMachineConfigPool" {
+		t.Fatalf("expected a successful, ended sync span, got %+v", span)
+	}
+	expected := map[string]interface{}{"pool": "worker", "maxUnavailable": int64(2), "candidates": int64(2)}
+	if !reflect.DeepEqual(span.attrs, expected) {
+		t.Fatalf("mismatch span attributes: got %v want: %v", span.attrs, expected)
+	}
+	if len(span.children) != 2 {
+		t.Fatalf("expected a child span per node patch, got %d", len(span.children))
+	}
+	for _, child := range span.children {
+		if child.name != "setDesiredMachineConfigAnnotation" || !child.ended || child.attrs["node"] == "" {
+			t.Fatalf("unexpected child span: %+v", child)
+		}
+	}
+}
+
+func TestNoopTracingDoesNotAllocate(t *testing.T) {
+	var tracer TracerProvider = noopTracer{}
+	allocs := testing.AllocsPerRun(100, func() {
+		span := tracer.StartSpan("syncMachineConfigPool")
+		span.SetStringAttribute("pool", "worker")
+		span.SetIntAttribute("candidates", 1000)
+		child := span.StartChild("setDesiredMachineConfigAnnotation")
+		child.SetError(nil)
+		child.End()
+		span.End()
+	})
+	if allocs != 0 {
+		t.Fatalf("expected disabled tracing not to allocate, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// getUnresolvedNodes returns the names of the nodes whose pool can't be determined
+// because they are selected by pools that can't share them, e.g. both the master and a
+// custom pool. Rather than dropping out of the accounting of the pools selecting them,
+// they count as unavailable and aren't updated until the ambiguity is resolved.
+func (ctrl *Controller) getUnresolvedNodes(nodes []*corev1.Node) (sets.String, error) {
+	pl, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	type selectingPool struct {
+		pool     *mcfgv1.MachineConfigPool
+		selector poolNodeSelector
+	}
+	var pools []selectingPool
+	for _, pool := range pl {
+		// A pool with an invalid selector selects no node, its own sync reports it.
+		if selector, err := newPoolNodeSelector(pool); err == nil {
+			pools = append(pools, selectingPool{pool: pool, selector: selector})
+		}
+	}
+
+	unresolved := sets.NewString()
+	for _, node := range nodes {
+		var selecting []*mcfgv1.MachineConfigPool
+		for _, p := range pools {
+			if p.selector.Matches(labels.Set(node.Labels)) {
+				selecting = append(selecting, p.pool)
+			}
+		}
+		if len(selecting) < 2 {
+			continue
+		}
+		if _, err := choosePool(node.Name, selecting); err != nil {
+			glog.V(2).Infof("Can't determine the pool of node %s, holding it back: %v", node.Name, err)
+			unresolved.Insert(node.Name)
+		}
+	}
+	return unresolved, nil
+}
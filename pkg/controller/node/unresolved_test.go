@@ -0,0 +1,71 @@
+package node
+
+import (
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestUnresolvedNodes(t *testing.T) {
+	f := newFixture(t)
+	infra := newMachineConfigPool("infra", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	master := newMachineConfigPool("master", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/master", ""), intStrPtr(intstr.FromInt(1)), "v0")
+	nodes := []*corev1.Node{
+		// Selected by both the master and the custom infra pool.
+		newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/infra": "", "node-role/master": ""}),
+		newNodeWithLabel("node-1", "v0", "v0", map[string]string{"node-role/infra": ""}),
+	}
+	f.mcpLister = append(f.mcpLister, infra, master)
+	f.objects = append(f.objects, infra, master)
+	f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"))
+	f.nodeLister = append(f.nodeLister, nodes...)
+	for idx := range nodes {
+		f.kubeobjects = append(f.kubeobjects, nodes[idx])
+	}
+	c := f.newController()
+
+	if err := c.syncHandler(getKey(infra, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// node-0 takes the only slot of maxUnavailable, so node-1 isn't updated either.
+	for _, name := range []string{"node-0", "node-1"} {
+		node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]; desired != "v0" {
+			t.Fatalf("expected %s to be held back, got desired config %s", name, desired)
+		}
+	}
+	pool, err := f.client.MachineconfigurationV1().MachineConfigPools().Get("infra", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.Status.MachineCount != 2 || pool.Status.UnresolvedMachineCount != 1 {
+		t.Fatalf("expected 2 machines with 1 unresolved, got %d and %d", pool.Status.MachineCount, pool.Status.UnresolvedMachineCount)
+	}
+}
+
+func TestUnresolvedNodesInvalidSelector(t *testing.T) {
+	f := newFixture(t)
+	infra := newMachineConfigPool("infra", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/infra", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	broken := newMachineConfigPool("broken", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "node-role/infra", Operator: "Bogus"}},
+	}, intStrPtr(intstr.FromInt(1)), "v1")
+	f.mcpLister = append(f.mcpLister, infra, broken)
+	c := f.newController()
+
+	// The invalid selector of another pool doesn't make the nodes of infra ambiguous.
+	nodes := []*corev1.Node{newNodeWithLabel("node-0", "v0", "v0", map[string]string{"node-role/infra": ""})}
+	unresolved, err := c.getUnresolvedNodes(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unresolved.Len() != 0 {
+		t.Fatalf("expected no unresolved nodes, got %v", unresolved.List())
+	}
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// applyUrgentConfig sets the nodes of pool listed in its urgent-nodes annotation to the
+// config named in its urgent-config annotation, once acknowledged. This deliberately
+// bypasses the target of the pool and maxUnavailable. It returns the names of the listed
+// nodes, which the regular rollout leaves out while the annotations are set.
+func (ctrl *Controller) applyUrgentConfig(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (sets.String, error) {
+	urgent := sets.NewString()
+	config := pool.Annotations[urgentConfigAnnotationKey]
+	if config == "" {
+		ctrl.poolEvents.clear(pool.Name, "UrgentConfigNotAcknowledged")
+		ctrl.poolEvents.clear(pool.Name, "UrgentConfigNotFound")
+		return urgent, nil
+	}
+	if pool.Annotations[urgentConfigAckAnnotationKey] != config {
+		if ctrl.poolEvents.changed(pool.Name, "UrgentConfigNotAcknowledged", config) {
+			glog.Warningf("Pool %s: urgent config %s is not acknowledged, ignoring it", pool.Name, config)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "UrgentConfigNotAcknowledged", "Urgent config %s is ignored until the %s annotation is set to its name", config, urgentConfigAckAnnotationKey)
+		}
+		ctrl.poolEvents.clear(pool.Name, "UrgentConfigNotFound")
+		return urgent, nil
+	}
+	ctrl.poolEvents.clear(pool.Name, "UrgentConfigNotAcknowledged")
+	if _, err := ctrl.mcLister.Get(config); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, newSyncError(syncErrorMachineConfigLookup, err)
+		}
+		if ctrl.poolEvents.changed(pool.Name, "UrgentConfigNotFound", config) {
+			glog.Warningf("Pool %s: urgent config %s doesn't exist, ignoring it", pool.Name, config)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "UrgentConfigNotFound", "Urgent config %s doesn't exist", config)
+		}
+		return urgent, nil
+	}
+	ctrl.poolEvents.clear(pool.Name, "UrgentConfigNotFound")
+
+	listed := sets.NewString()
+	for _, name := range strings.Split(pool.Annotations[urgentNodesAnnotationKey], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			listed.Insert(name)
+		}
+	}
+	for _, node := range nodes {
+		if !listed.Has(node.Name) {
+			continue
+		}
+		urgent.Insert(node.Name)
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == config {
+			continue
+		}
+		glog.Warningf("Pool %s: setting node %s to urgent config %s, bypassing maxUnavailable", pool.Name, node.Name, config)
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "UrgentConfig", "Setting node %s to urgent config %s out of band, bypassing the target of the pool and maxUnavailable", node.Name, config)
+		err := ctrl.setDesiredMachineConfigAnnotation(node.Name, config)
+		ctrl.recordDesiredConfigResult(pool, node, err)
+		if err != nil {
+			return nil, newSyncError(syncErrorSetDesiredConfig, err)
+		}
+	}
+	if missing := listed.Difference(urgent); missing.Len() > 0 {
+		glog.Warningf("Pool %s: nodes %v listed for urgent config %s are not in the pool", pool.Name, missing.List(), config)
+	}
+	return urgent, nil
+}
+
+// withoutUrgent returns nodes without those named in urgent. The regular rollout neither
+// updates urgent nodes nor counts them against maxUnavailable, so that pushing an urgent
+// config doesn't stall it.
+func withoutUrgent(nodes []*corev1.Node, urgent sets.String) []*corev1.Node {
+	if urgent.Len() == 0 {
+		return nodes
+	}
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		if !urgent.Has(node.Name) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
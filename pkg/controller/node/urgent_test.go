@@ -0,0 +1,136 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestUrgentConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		ack  string
+		// inFlight sets the listed nodes to the urgent config already.
+		inFlight bool
+		// urgent is the number of nodes expected at the urgent config, and regular the
+		// number expected at the target of the pool.
+		urgent, regular int
+	}{{
+		// The listed nodes are updated at once, outside of maxUnavailable.
+		name:    "acknowledged",
+		ack:     "v2",
+		urgent:  2,
+		regular: 1,
+	}, {
+		// Listed nodes still updating don't hold back the regular rollout either.
+		name:     "acknowledged in flight",
+		ack:      "v2",
+		inFlight: true,
+		urgent:   2,
+		regular:  1,
+	}, {
+		name:    "not acknowledged",
+		ack:     "v1",
+		regular: 1,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+			mcp.Annotations = map[string]string{
+				urgentConfigAnnotationKey:    "v2",
+				urgentNodesAnnotationKey:     "node-0, node-1,node-9",
+				urgentConfigAckAnnotationKey: test.ack,
+			}
+			labels := map[string]string{"node-role/worker": ""}
+			listedDesired := "v0"
+			if test.inFlight {
+				listedDesired = "v2"
+			}
+			nodes := []*corev1.Node{
+				newNodeWithLabel("node-0", "v0", listedDesired, labels),
+				newNodeWithLabel("node-1", "v0", listedDesired, labels),
+				newNodeWithLabel("node-2", "v0", "v0", labels),
+			}
+			f.mcpLister = append(f.mcpLister, mcp)
+			f.objects = append(f.objects, mcp)
+			f.mcLister = append(f.mcLister, newMachineConfig("v0"), newMachineConfig("v1"), newMachineConfig("v2"))
+			f.nodeLister = append(f.nodeLister, nodes...)
+			for idx := range nodes {
+				f.kubeobjects = append(f.kubeobjects, nodes[idx])
+			}
+			c := f.newController()
+
+			if err := c.syncHandler(getKey(mcp, t)); err != nil {
+				t.Fatal(err)
+			}
+
+			var urgent, regular int
+			for _, name := range []string{"node-0", "node-1", "node-2"} {
+				node, err := f.kubeclient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] {
+				case "v2":
+					if name == "node-2" {
+						t.Fatalf("expected only the listed nodes to get the urgent config")
+					}
+					urgent++
+				case "v1":
+					regular++
+				}
+			}
+			if urgent != test.urgent || regular != test.regular {
+				t.Fatalf("mismatch updated nodes: got %d urgent and %d regular, want: %d and %d", urgent, regular, test.urgent, test.regular)
+			}
+		})
+	}
+}
+
+func TestUrgentConfigEventsOnlyOnChange(t *testing.T) {
+	f := newFixture(t)
+	f.mcLister = append(f.mcLister, newMachineConfig("v1"))
+	c := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+	mcp := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+
+	for _, step := range []struct {
+		config, ack string
+		expected    []string
+	}{
+		{config: "v2", ack: "v1", expected: []string{"Warning UrgentConfigNotAcknowledged"}},
+		{config: "v2", ack: "v1"},
+		{config: "v3", ack: "v1", expected: []string{"Warning UrgentConfigNotAcknowledged"}},
+		{config: "v3", ack: "v3", expected: []string{"Warning UrgentConfigNotFound"}},
+		{config: "v3", ack: "v3"},
+		{config: "", ack: ""},
+		{config: "v3", ack: "v3", expected: []string{"Warning UrgentConfigNotFound"}},
+	} {
+		mcp.Annotations = map[string]string{
+			urgentConfigAnnotationKey:    step.config,
+			urgentConfigAckAnnotationKey: step.ack,
+		}
+		if _, err := c.applyUrgentConfig(mcp, nil); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for len(recorder.Events) > 0 {
+			got = append(got, <-recorder.Events)
+		}
+		if len(got) != len(step.expected) {
+			t.Fatalf("urgent config %q acknowledged as %q: mismatch events: got %q want: %q", step.config, step.ack, got, step.expected)
+		}
+		for idx := range got {
+			if !strings.HasPrefix(got[idx], step.expected[idx]) {
+				t.Fatalf("urgent config %q acknowledged as %q: mismatch event: got %q want prefix: %q", step.config, step.ack, got[idx], step.expected[idx])
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package node
+
+import (
+	"reflect"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidatePool checks the parts of a pool's spec the node controller relies on. The
+// node controller doesn't update the nodes of a pool failing validation.
+func ValidatePool(pool *mcfgv1.MachineConfigPool) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// Node selectors must parse, and must not select every node of the cluster.
+	selectorPath := specPath.Child("nodeSelector")
+	if reflect.DeepEqual(pool.Spec.NodeSelector, &metav1.LabelSelector{}) {
+		errs = append(errs, field.Invalid(selectorPath, pool.Spec.NodeSelector, "selects all nodes, a non-empty selector is required"))
+	} else if _, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector); err != nil {
+		errs = append(errs, field.Invalid(selectorPath, pool.Spec.NodeSelector, err.Error()))
+	}
+	for i, selector := range pool.Spec.NodeSelectors {
+		path := specPath.Child("nodeSelectors").Index(i)
+		if selector == nil {
+			errs = append(errs, field.Required(path, "a non-empty selector is required"))
+		} else if reflect.DeepEqual(selector, &metav1.LabelSelector{}) {
+			errs = append(errs, field.Invalid(path, selector, "selects all nodes, a non-empty selector is required"))
+		} else if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errs = append(errs, field.Invalid(path, selector, err.Error()))
+		}
+	}
+
+	// A configuration selector must parse, and must not select every config.
+	if selector := pool.Spec.ConfigurationSelector; selector != nil {
+		configSelectorPath := specPath.Child("configurationSelector")
+		if reflect.DeepEqual(selector, &metav1.LabelSelector{}) {
+			errs = append(errs, field.Invalid(configSelectorPath, selector, "selects all configs, a non-empty selector is required"))
+		} else if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errs = append(errs, field.Invalid(configSelectorPath, selector, err.Error()))
+		}
+	}
+
+	// maxUnavailable and its override annotation are counts or percentages, neither negative.
+	if pool.Spec.MaxUnavailable != nil {
+		maxUnavailablePath := specPath.Child("maxUnavailable")
+		// Resolve percentages against 100 nodes; only the sign and syntax matter here.
+		maxunavail, err := intstrutil.GetValueFromIntOrPercent(pool.Spec.MaxUnavailable, 100, false)
+		if err != nil {
+			errs = append(errs, field.Invalid(maxUnavailablePath, pool.Spec.MaxUnavailable.String(), err.Error()))
+		} else if maxunavail < 0 {
+			errs = append(errs, field.Invalid(maxUnavailablePath, pool.Spec.MaxUnavailable.String(), "must not be negative"))
+		}
+	}
+
+	if override, ok := pool.Annotations[maxUnavailableOverrideAnnotationKey]; ok {
+		overridePath := field.NewPath("metadata", "annotations").Key(maxUnavailableOverrideAnnotationKey)
+		intOrPercent := intstrutil.Parse(override)
+		maxunavail, err := intstrutil.GetValueFromIntOrPercent(&intOrPercent, 100, false)
+		if err != nil {
+			errs = append(errs, field.Invalid(overridePath, override, err.Error()))
+		} else if maxunavail < 0 {
+			errs = append(errs, field.Invalid(overridePath, override, "must not be negative"))
+		}
+	}
+
+	// Counts, percentages and durations must not be negative.
+	if pool.Spec.MaxDegraded != nil {
+		maxDegradedPath := specPath.Child("maxDegraded")
+		maxDegraded, err := intstrutil.GetValueFromIntOrPercent(pool.Spec.MaxDegraded, 100, true)
+		if err != nil {
+			errs = append(errs, field.Invalid(maxDegradedPath, pool.Spec.MaxDegraded.String(), err.Error()))
+		} else if maxDegraded < 0 {
+			errs = append(errs, field.Invalid(maxDegradedPath, pool.Spec.MaxDegraded.String(), "must not be negative"))
+		}
+	}
+
+	if pool.Spec.MinHealthy < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("minHealthy"), pool.Spec.MinHealthy, "must not be negative"))
+	}
+
+	if pool.Spec.RetainPreviousConfig < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("retainPreviousConfig"), pool.Spec.RetainPreviousConfig, "must not be negative"))
+	}
+
+	if pool.Spec.PerNodeSoak != nil && pool.Spec.PerNodeSoak.Duration < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("perNodeSoak"), pool.Spec.PerNodeSoak.Duration.String(), "must not be negative"))
+	}
+
+	if pool.Spec.CompletionSoak != nil && pool.Spec.CompletionSoak.Duration < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("completionSoak"), pool.Spec.CompletionSoak.Duration.String(), "must not be negative"))
+	}
+
+	// A drain policy can only force the drain once its timeout expires.
+	if policy := pool.Spec.DrainPolicy; policy != nil {
+		policyPath := specPath.Child("drainPolicy")
+		if policy.GracePeriodSeconds != nil && *policy.GracePeriodSeconds < 0 {
+			errs = append(errs, field.Invalid(policyPath.Child("gracePeriodSeconds"), *policy.GracePeriodSeconds, "must not be negative"))
+		}
+		if policy.Timeout != nil && policy.Timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(policyPath.Child("timeout"), policy.Timeout.Duration.String(), "must not be negative"))
+		}
+		if policy.ForceOnTimeout && (policy.Timeout == nil || policy.Timeout.Duration == 0) {
+			errs = append(errs, field.Required(policyPath.Child("timeout"), "is required to force on timeout"))
+		}
+	}
+
+	// A ramp takes positive steps, and doesn't start above its ceiling.
+	if ramp := pool.Spec.MaxUnavailableRamp; ramp != nil {
+		rampPath := specPath.Child("maxUnavailableRamp")
+		if ramp.Initial < 1 {
+			errs = append(errs, field.Invalid(rampPath.Child("initial"), ramp.Initial, "must be positive"))
+		}
+		if ramp.Ceiling < ramp.Initial {
+			errs = append(errs, field.Invalid(rampPath.Child("ceiling"), ramp.Ceiling, "must not be lower than initial"))
+		}
+		if ramp.Step < 1 {
+			errs = append(errs, field.Invalid(rampPath.Child("step"), ramp.Step, "must be positive"))
+		}
+		if ramp.SuccessesPerStep < 1 {
+			errs = append(errs, field.Invalid(rampPath.Child("successesPerStep"), ramp.SuccessesPerStep, "must be positive"))
+		}
+	}
+
+	// Timeouts and debounces must not be negative either.
+	if pool.Spec.VerificationTimeout != nil && pool.Spec.VerificationTimeout.Duration < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("verificationTimeout"), pool.Spec.VerificationTimeout.Duration.String(), "must not be negative"))
+	}
+
+	if pool.Spec.TargetConfigDebounce != nil && pool.Spec.TargetConfigDebounce.Duration < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("targetConfigDebounce"), pool.Spec.TargetConfigDebounce.Duration.String(), "must not be negative"))
+	}
+
+	// A blocking Job is looked up by namespace and name.
+	if ref := pool.Spec.BlockingJob; ref != nil {
+		jobPath := specPath.Child("blockingJob")
+		if ref.Namespace == "" {
+			errs = append(errs, field.Required(jobPath.Child("namespace"), "must name the namespace of the Job"))
+		}
+		if ref.Name == "" {
+			errs = append(errs, field.Required(jobPath.Child("name"), "must name the Job"))
+		}
+	}
+
+	// A not ready grace period applies to the listed reasons, for a positive period.
+	if grace := pool.Spec.NotReadyGrace; grace != nil {
+		gracePath := specPath.Child("notReadyGrace")
+		if len(grace.Reasons) == 0 {
+			errs = append(errs, field.Required(gracePath.Child("reasons"), "must list the NodeReady reasons the grace period applies to"))
+		}
+		if grace.Period.Duration <= 0 {
+			errs = append(errs, field.Invalid(gracePath.Child("period"), grace.Period.Duration.String(), "must be positive"))
+		}
+	}
+
+	// The shadow node selector may be empty, picking among all nodes of the pool, but
+	// must parse.
+	if shadow := pool.Spec.ShadowValidation; shadow != nil {
+		shadowPath := specPath.Child("shadowValidation")
+		if _, err := metav1.LabelSelectorAsSelector(shadow.NodeSelector); err != nil {
+			errs = append(errs, field.Invalid(shadowPath.Child("nodeSelector"), shadow.NodeSelector, err.Error()))
+		}
+		if shadow.Soak.Duration < 0 {
+			errs = append(errs, field.Invalid(shadowPath.Child("soak"), shadow.Soak.Duration.String(), "must not be negative"))
+		}
+	}
+
+	// Required node conditions and config sequence steps are distinct, non-empty names.
+	conditions := map[corev1.NodeConditionType]bool{}
+	for i, condition := range pool.Spec.RequiredNodeConditions {
+		conditionPath := specPath.Child("requiredNodeConditions").Index(i)
+		if condition == "" {
+			errs = append(errs, field.Required(conditionPath, "must name a node condition type"))
+		} else if conditions[condition] {
+			errs = append(errs, field.Duplicate(conditionPath, condition))
+		}
+		conditions[condition] = true
+	}
+
+	seen := map[string]bool{}
+	for i, step := range pool.Spec.ConfigSequence {
+		stepPath := specPath.Child("configSequence").Index(i)
+		if step == "" {
+			errs = append(errs, field.Required(stepPath, "must name a MachineConfig"))
+		} else if seen[step] {
+			errs = append(errs, field.Duplicate(stepPath, step))
+		}
+		seen[step] = true
+	}
+
+	// The annotations of the machineconfiguration.openshift.io domain belong to the MCO
+	// and are never propagated.
+	for i, key := range pool.Spec.PropagateAnnotations {
+		keyPath := specPath.Child("propagateAnnotations").Index(i)
+		if key == "" {
+			errs = append(errs, field.Required(keyPath, "must name an annotation"))
+		} else if strings.HasPrefix(key, mcfgv1.GroupName+"/") {
+			errs = append(errs, field.Invalid(keyPath, key, "annotations of the "+mcfgv1.GroupName+" domain can't be propagated"))
+		}
+	}
+
+	// Policies must be known ones; empty picks the default.
+	switch pool.Spec.MaxUnavailableScaling {
+	case "", mcfgv1.MaxUnavailableScalingFixed, mcfgv1.MaxUnavailableScalingSqrt, mcfgv1.MaxUnavailableScalingLog2:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("maxUnavailableScaling"), pool.Spec.MaxUnavailableScaling,
+			[]string{string(mcfgv1.MaxUnavailableScalingFixed), string(mcfgv1.MaxUnavailableScalingSqrt), string(mcfgv1.MaxUnavailableScalingLog2)}))
+	}
+
+	switch pool.Spec.UnavailabilityPolicy {
+	case "", mcfgv1.UnavailabilityPolicyStrict, mcfgv1.UnavailabilityPolicyUpdateOnly:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("unavailabilityPolicy"), pool.Spec.UnavailabilityPolicy,
+			[]string{string(mcfgv1.UnavailabilityPolicyStrict), string(mcfgv1.UnavailabilityPolicyUpdateOnly)}))
+	}
+
+	return errs
+}
@@ -0,0 +1,228 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidatePool(t *testing.T) {
+	workerSelector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", "")
+	tests := []struct {
+		name           string
+		selector       *metav1.LabelSelector
+		maxUnavail     *intstr.IntOrString
+		policy         mcfgv1.UnavailabilityPolicy
+		override       string
+		sequence       []string
+		maxDegraded    *intstr.IntOrString
+		propagate      []string
+		completionSoak *metav1.Duration
+		drainPolicy    *mcfgv1.MachineConfigPoolDrainPolicy
+		ramp           *mcfgv1.MachineConfigPoolMaxUnavailableRamp
+		blockingJob    *mcfgv1.MachineConfigPoolJobReference
+		verifyTimeout  *metav1.Duration
+		minHealthy     int32
+		notReadyGrace  *mcfgv1.MachineConfigPoolNotReadyGrace
+		configDebounce *metav1.Duration
+		conditions     []corev1.NodeConditionType
+		selectors      []*metav1.LabelSelector
+		shadow         *mcfgv1.MachineConfigPoolShadowValidation
+		configSelector *metav1.LabelSelector
+		fields         []string
+	}{{
+		name:     "valid",
+		selector: workerSelector,
+	}, {
+		name:       "valid percentage",
+		selector:   workerSelector,
+		maxUnavail: intStrPtr(intstr.FromString("50%")),
+	}, {
+		name:     "selects everything",
+		selector: &metav1.LabelSelector{},
+		fields:   []string{"spec.nodeSelector"},
+	}, {
+		name: "unparseable selector",
+		selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key: "node-role/worker", Operator: "Bogus",
+		}}},
+		fields: []string{"spec.nodeSelector"},
+	}, {
+		name:       "unparseable maxUnavailable",
+		selector:   workerSelector,
+		maxUnavail: intStrPtr(intstr.FromString("half")),
+		fields:     []string{"spec.maxUnavailable"},
+	}, {
+		name:       "negative maxUnavailable",
+		selector:   &metav1.LabelSelector{},
+		maxUnavail: intStrPtr(intstr.FromInt(-1)),
+		fields:     []string{"spec.nodeSelector", "spec.maxUnavailable"},
+	}, {
+		name:     "valid policy",
+		selector: workerSelector,
+		policy:   mcfgv1.UnavailabilityPolicyUpdateOnly,
+	}, {
+		name:     "unknown policy",
+		selector: workerSelector,
+		policy:   "Lenient",
+		fields:   []string{"spec.unavailabilityPolicy"},
+	}, {
+		name:     "valid override",
+		selector: workerSelector,
+		override: "1",
+	}, {
+		name:     "unparseable override",
+		selector: workerSelector,
+		override: "one",
+		fields:   []string{"metadata.annotations[" + maxUnavailableOverrideAnnotationKey + "]"},
+	}, {
+		name:     "negative override",
+		selector: workerSelector,
+		override: "-1",
+		fields:   []string{"metadata.annotations[" + maxUnavailableOverrideAnnotationKey + "]"},
+	}, {
+		name:        "negative maxDegraded",
+		selector:    workerSelector,
+		maxDegraded: intStrPtr(intstr.FromInt(-1)),
+		fields:      []string{"spec.maxDegraded"},
+	}, {
+		name:      "invalid propagated annotations",
+		selector:  workerSelector,
+		propagate: []string{"example.com/cost-center", "", "machineconfiguration.openshift.io/desiredConfig"},
+		fields:    []string{"spec.propagateAnnotations[1]", "spec.propagateAnnotations[2]"},
+	}, {
+		name:           "negative completionSoak",
+		selector:       workerSelector,
+		completionSoak: &metav1.Duration{Duration: -time.Minute},
+		fields:         []string{"spec.completionSoak"},
+	}, {
+		name:        "valid drain policy",
+		selector:    workerSelector,
+		drainPolicy: &mcfgv1.MachineConfigPoolDrainPolicy{Timeout: &metav1.Duration{Duration: time.Minute}, ForceOnTimeout: true},
+	}, {
+		name:        "drain policy forcing without a timeout",
+		selector:    workerSelector,
+		drainPolicy: &mcfgv1.MachineConfigPoolDrainPolicy{ForceOnTimeout: true},
+		fields:      []string{"spec.drainPolicy.timeout"},
+	}, {
+		name:     "negative drain policy durations",
+		selector: workerSelector,
+		drainPolicy: &mcfgv1.MachineConfigPoolDrainPolicy{
+			GracePeriodSeconds: func(i int32) *int32 { return &i }(-1),
+			Timeout:            &metav1.Duration{Duration: -time.Minute},
+		},
+		fields: []string{"spec.drainPolicy.gracePeriodSeconds", "spec.drainPolicy.timeout"},
+	}, {
+		name:     "valid maxUnavailable ramp",
+		selector: workerSelector,
+		ramp:     &mcfgv1.MachineConfigPoolMaxUnavailableRamp{Initial: 1, Ceiling: 5, Step: 2, SuccessesPerStep: 3},
+	}, {
+		name:     "invalid maxUnavailable ramp",
+		selector: workerSelector,
+		ramp:     &mcfgv1.MachineConfigPoolMaxUnavailableRamp{Initial: 2, Ceiling: 1, SuccessesPerStep: 1},
+		fields:   []string{"spec.maxUnavailableRamp.ceiling", "spec.maxUnavailableRamp.step"},
+	}, {
+		name:        "blocking Job without a namespace",
+		selector:    workerSelector,
+		blockingJob: &mcfgv1.MachineConfigPoolJobReference{Name: "backup"},
+		fields:      []string{"spec.blockingJob.namespace"},
+	}, {
+		name:          "negative verificationTimeout",
+		selector:      workerSelector,
+		verifyTimeout: &metav1.Duration{Duration: -time.Minute},
+		fields:        []string{"spec.verificationTimeout"},
+	}, {
+		name:       "negative minHealthy",
+		selector:   workerSelector,
+		minHealthy: -1,
+		fields:     []string{"spec.minHealthy"},
+	}, {
+		name:          "notReadyGrace without reasons or period",
+		selector:      workerSelector,
+		notReadyGrace: &mcfgv1.MachineConfigPoolNotReadyGrace{},
+		fields:        []string{"spec.notReadyGrace.reasons", "spec.notReadyGrace.period"},
+	}, {
+		name:           "negative targetConfigDebounce",
+		selector:       workerSelector,
+		configDebounce: &metav1.Duration{Duration: -time.Minute},
+		fields:         []string{"spec.targetConfigDebounce"},
+	}, {
+		name:       "invalid required node conditions",
+		selector:   workerSelector,
+		conditions: []corev1.NodeConditionType{"nvidia.com/gpu-healthy", "", "nvidia.com/gpu-healthy"},
+		fields:     []string{"spec.requiredNodeConditions[1]", "spec.requiredNodeConditions[2]"},
+	}, {
+		name:      "additional node selectors",
+		selector:  workerSelector,
+		selectors: []*metav1.LabelSelector{metav1.AddLabelToSelector(&metav1.LabelSelector{}, "rack", "a")},
+	}, {
+		name:      "additional node selector selecting everything",
+		selector:  workerSelector,
+		selectors: []*metav1.LabelSelector{metav1.AddLabelToSelector(&metav1.LabelSelector{}, "rack", "a"), {}, nil},
+		fields:    []string{"spec.nodeSelectors[1]", "spec.nodeSelectors[2]"},
+	}, {
+		name:     "invalid shadow validation",
+		selector: workerSelector,
+		shadow: &mcfgv1.MachineConfigPoolShadowValidation{
+			NodeSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "shadow", Operator: "Bogus"}}},
+			Soak:         metav1.Duration{Duration: -time.Minute},
+		},
+		fields: []string{"spec.shadowValidation.nodeSelector", "spec.shadowValidation.soak"},
+	}, {
+		name:           "configuration selector",
+		selector:       workerSelector,
+		configSelector: metav1.AddLabelToSelector(&metav1.LabelSelector{}, "generation", "42"),
+	}, {
+		name:           "configuration selector selecting everything",
+		selector:       workerSelector,
+		configSelector: &metav1.LabelSelector{},
+		fields:         []string{"spec.configurationSelector"},
+	}, {
+		name:     "valid config sequence",
+		selector: workerSelector,
+		sequence: []string{"prep-0", "prep-1"},
+	}, {
+		name:     "invalid config sequence",
+		selector: workerSelector,
+		sequence: []string{"prep-0", "", "prep-0"},
+		fields:   []string{"spec.configSequence[1]", "spec.configSequence[2]"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newMachineConfigPool("worker", test.selector, test.maxUnavail, "v1")
+			pool.Spec.UnavailabilityPolicy = test.policy
+			pool.Spec.ConfigSequence = test.sequence
+			pool.Spec.MaxDegraded = test.maxDegraded
+			pool.Spec.PropagateAnnotations = test.propagate
+			pool.Spec.CompletionSoak = test.completionSoak
+			pool.Spec.DrainPolicy = test.drainPolicy
+			pool.Spec.MaxUnavailableRamp = test.ramp
+			pool.Spec.BlockingJob = test.blockingJob
+			pool.Spec.VerificationTimeout = test.verifyTimeout
+			pool.Spec.MinHealthy = test.minHealthy
+			pool.Spec.NotReadyGrace = test.notReadyGrace
+			pool.Spec.TargetConfigDebounce = test.configDebounce
+			pool.Spec.RequiredNodeConditions = test.conditions
+			pool.Spec.NodeSelectors = test.selectors
+			pool.Spec.ShadowValidation = test.shadow
+			pool.Spec.ConfigurationSelector = test.configSelector
+			if test.override != "" {
+				pool.Annotations = map[string]string{maxUnavailableOverrideAnnotationKey: test.override}
+			}
+			errs := ValidatePool(pool)
+			if len(errs) != len(test.fields) {
+				t.Fatalf("mismatch errors: got %v want errors for: %v", errs, test.fields)
+			}
+			for i, err := range errs {
+				if err.Field != test.fields[i] {
+					t.Fatalf("mismatch error field %d: got %s want: %s", i, err.Field, test.fields[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// verificationTracker remembers since when nodes are waiting for the verification of
+// their update.
+type verificationTracker struct {
+	lock      sync.Mutex
+	verifying map[string]nodeVerification
+	now       func() time.Time
+}
+
+type nodeVerification struct {
+	config string
+	since  time.Time
+}
+
+func newVerificationTracker() *verificationTracker {
+	return &verificationTracker{
+		verifying: map[string]nodeVerification{},
+		now:       time.Now,
+	}
+}
+
+// waiting returns how long node has been waiting for the verification of its update to
+// config, starting the wait if it wasn't waiting yet.
+func (v *verificationTracker) waiting(node, config string) time.Duration {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	entry, ok := v.verifying[node]
+	if !ok || entry.config != config {
+		entry = nodeVerification{config: config, since: v.now()}
+		v.verifying[node] = entry
+	}
+	return v.now().Sub(entry.since)
+}
+
+// forget drops the wait of node, e.g. because it was verified.
+func (v *verificationTracker) forget(node string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	delete(v.verifying, node)
+}
+
+// isVerificationPending returns whether node completed its update to the target config of
+// pool, which verifies updates, but wasn't verified yet. Such a node isn't updated yet and
+// counts as unavailable.
+func isVerificationPending(pool *mcfgv1.MachineConfigPool, node *corev1.Node) bool {
+	return pool.Spec.VerifyUpdates && isNodeDoneAt(node, pool.Spec.Configuration.Name) && node.Annotations[verifiedAnnotationKey] != "true"
+}
+
+// isVerificationFailed returns whether the verification of the update of node to its
+// current config timed out, and it wasn't verified since. Such a node counts as degraded.
+func isVerificationFailed(node *corev1.Node) bool {
+	current := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	return current != "" && node.Annotations[verificationFailedAnnotationKey] == current && node.Annotations[verifiedAnnotationKey] != "true"
+}
+
+// isVerificationUnavailable returns whether node counts as unavailable only because its
+// verification is pending.
+func isVerificationUnavailable(pool *mcfgv1.MachineConfigPool, node *corev1.Node, checkReady NodeReadyChecker) bool {
+	return isVerificationPending(pool, node) && !IsNodeUnavailable(node, pool.Spec.UnavailabilityPolicy, checkReady)
+}
+
+// withoutPendingVerifications returns the nodes whose verification isn't pending.
+func withoutPendingVerifications(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) []*corev1.Node {
+	if !pool.Spec.VerifyUpdates {
+		return nodes
+	}
+	var kept []*corev1.Node
+	for _, node := range nodes {
+		if !isVerificationPending(pool, node) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// handleVerifications goes through the verifications of the nodes of pool, if it verifies
+// updates. The verification annotations of nodes starting an update are removed, so that
+// the new config gets verified as well. Nodes waiting for their verification for longer
+// than the timeout of the pool are marked as failing verification, which counts them as
+// degraded while they keep holding their slot; the daemon state of nodes is left to the
+// daemon. The mark is removed once such nodes got verified after all.
+func (ctrl *Controller) handleVerifications(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	if !pool.Spec.VerifyUpdates {
+		return nil
+	}
+	var errs []error
+	for _, node := range nodes {
+		current := node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+		desired := node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]
+		_, hasVerified := node.Annotations[verifiedAnnotationKey]
+		_, hasFailed := node.Annotations[verificationFailedAnnotationKey]
+		verified := node.Annotations[verifiedAnnotationKey] == "true"
+		switch {
+		case desired != current:
+			ctrl.verifications.forget(node.Name)
+			if !hasVerified && !hasFailed {
+				continue
+			}
+			errs = append(errs, ctrl.updateNodeAnnotations(node.Name, func(annotations map[string]string) {
+				delete(annotations, verifiedAnnotationKey)
+				delete(annotations, verificationFailedAnnotationKey)
+			}))
+		case verified && node.Annotations[verificationFailedAnnotationKey] == current:
+			glog.Infof("Pool %s: node %s was verified after its verification timed out, restoring it", pool.Name, node.Name)
+			errs = append(errs, ctrl.setNodeAnnotation(node.Name, verificationFailedAnnotationKey, ""))
+		case isVerificationPending(pool, node):
+			if pool.Spec.VerificationTimeout == nil || node.Annotations[verificationFailedAnnotationKey] == current {
+				continue
+			}
+			timeout := pool.Spec.VerificationTimeout.Duration
+			if remaining := timeout - ctrl.verifications.waiting(node.Name, current); remaining > 0 {
+				ctrl.enqueueAfter(pool, remaining)
+				continue
+			}
+			glog.Warningf("Pool %s: verification of node %s timed out after %v, marking it degraded", pool.Name, node.Name, timeout)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "VerificationTimedOut", "Node %s wasn't verified within %v of its update to %s, marking it degraded", node.Name, timeout, current)
+			errs = append(errs, ctrl.setNodeAnnotation(node.Name, verificationFailedAnnotationKey, current))
+			ctrl.verifications.forget(node.Name)
+		default:
+			ctrl.verifications.forget(node.Name)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
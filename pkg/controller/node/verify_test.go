@@ -0,0 +1,144 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestVerificationPending(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	pool.Spec.VerifyUpdates = true
+	updated := newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue)
+	nodes := []*corev1.Node{
+		updated,
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	// Until it's verified, the node isn't updated and holds back the next one.
+	status := calculateStatus(pool, nodes, checkNodeReady)
+	if status.UpdatedMachineCount != 0 || status.UnavailableMachineCount != 1 {
+		t.Fatalf("expected the node to wait for its verification, got %d updated and %d unavailable", status.UpdatedMachineCount, status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil); len(got) != 0 {
+		t.Fatalf("expected no candidates while the verification is pending, got %v", got)
+	}
+
+	updated.Annotations[verifiedAnnotationKey] = "true"
+	status = calculateStatus(pool, nodes, checkNodeReady)
+	if status.UpdatedMachineCount != 1 || status.UnavailableMachineCount != 0 {
+		t.Fatalf("expected the node to be updated once verified, got %d updated and %d unavailable", status.UpdatedMachineCount, status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 1, checkNodeReady, nil, nil, nil); len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected node-1 to be the next candidate, got %v", got)
+	}
+}
+
+func TestVerificationPendingNotReady(t *testing.T) {
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(2)), "v1")
+	pool.Spec.VerifyUpdates = true
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionFalse),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	// A node that isn't ready while its verification is pending only counts once.
+	status := calculateStatus(pool, nodes, checkNodeReady)
+	if status.UnavailableMachineCount != 1 {
+		t.Fatalf("expected 1 unavailable node, got %d", status.UnavailableMachineCount)
+	}
+	if got := getCandidateMachines(pool, nodes, 2, checkNodeReady, nil, nil, nil); len(got) != 1 || got[0].Name != "node-1" {
+		t.Fatalf("expected node-1 to be the next candidate, got %v", got)
+	}
+}
+
+func TestVerificationTimeout(t *testing.T) {
+	f := newFixture(t)
+	pool := newMachineConfigPool("worker", nil, intStrPtr(intstr.FromInt(1)), "v1")
+	pool.Spec.VerifyUpdates = true
+	pool.Spec.VerificationTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+	node := newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue)
+	f.kubeobjects = append(f.kubeobjects, node)
+	c := f.newController()
+	now := time.Now()
+	c.verifications.now = func() time.Time { return now }
+
+	lastPatch := func() string {
+		actions := f.kubeclient.Actions()
+		for i := len(actions) - 1; i >= 0; i-- {
+			if patch, ok := actions[i].(core.PatchAction); ok {
+				return string(patch.GetPatch())
+			}
+		}
+		return ""
+	}
+	getNode := func() *corev1.Node {
+		got, err := f.kubeclient.CoreV1().Nodes().Get(node.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if err := c.handleVerifications(pool, []*corev1.Node{node}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(5 * time.Minute)
+	if err := c.handleVerifications(pool, []*corev1.Node{node}); err != nil {
+		t.Fatal(err)
+	}
+	if len(getDegradedMachines([]*corev1.Node{getNode()})) != 0 {
+		t.Fatalf("expected the node not to be degraded before the verification timed out")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if err := c.handleVerifications(pool, []*corev1.Node{node}); err != nil {
+		t.Fatal(err)
+	}
+	failed := getNode()
+	if len(getDegradedMachines([]*corev1.Node{failed})) != 1 || failed.Annotations[verificationFailedAnnotationKey] != "v1" {
+		t.Fatalf("expected the node to be marked degraded once the verification timed out, got %v", failed.Annotations)
+	}
+	if state := failed.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]; state != daemonconsts.MachineConfigDaemonStateDone {
+		t.Fatalf("expected the daemon state to be left to the daemon, got %s", state)
+	}
+	status := calculateStatus(pool, []*corev1.Node{failed}, checkNodeReady)
+	if status.DegradedMachineCount != 1 || !mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolNodeDegraded) {
+		t.Fatalf("expected the pool to report the node degraded, got %v", status)
+	}
+	if !isVerificationUnavailable(pool, failed, checkNodeReady) {
+		t.Fatalf("expected the node to keep holding its slot once its verification timed out")
+	}
+
+	// A late verification restores the node.
+	failed.Annotations[verifiedAnnotationKey] = "true"
+	if _, err := f.kubeclient.CoreV1().Nodes().Update(failed); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.handleVerifications(pool, []*corev1.Node{failed}); err != nil {
+		t.Fatal(err)
+	}
+	restored := getNode()
+	if !isNodeDone(restored) || !strings.Contains(lastPatch(), `"`+verificationFailedAnnotationKey+`":null`) {
+		t.Fatalf("expected the verified node to be restored, got %v", restored.Annotations)
+	}
+
+	// Starting the next update drops the verification of the previous one.
+	restored.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = "v2"
+	if _, err := f.kubeclient.CoreV1().Nodes().Update(restored); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.handleVerifications(pool, []*corev1.Node{restored}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lastPatch(), `"`+verifiedAnnotationKey+`":null`) {
+		t.Fatalf("expected the verification to be dropped when the node starts updating, got patch %s", lastPatch())
+	}
+}
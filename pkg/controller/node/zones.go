@@ -0,0 +1,53 @@
+package node
+
+import (
+	"sort"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// zoneLabelKey is the label holding the zone of a node. Older nodes only carry the
+// deprecated corev1.LabelZoneFailureDomain.
+const zoneLabelKey = "topology.kubernetes.io/zone"
+
+// nodeZone returns the zone of node, if it has one.
+func nodeZone(node *corev1.Node) (string, bool) {
+	if zone, ok := node.Labels[zoneLabelKey]; ok && zone != "" {
+		return zone, true
+	}
+	zone, ok := node.Labels[corev1.LabelZoneFailureDomain]
+	return zone, ok && zone != ""
+}
+
+// setZoneProgress records on newStatus how many of the nodes of pool are updated in
+// each zone. It has an entry per zone, and none if no node has a zone.
+func setZoneProgress(pool *mcfgv1.MachineConfigPool, newStatus *mcfgv1.MachineConfigPoolStatus, nodes []*corev1.Node) {
+	updated := map[string]bool{}
+	for _, node := range withoutPendingVerifications(pool, withoutPendingPostUpdateHooks(pool, getUpdatedMachines(pool.Spec.Configuration.Name, nodes))) {
+		updated[node.Name] = true
+	}
+	byZone := map[string]*mcfgv1.MachineConfigPoolZoneStatus{}
+	for _, node := range nodes {
+		zone, ok := nodeZone(node)
+		if !ok {
+			continue
+		}
+		progress, ok := byZone[zone]
+		if !ok {
+			progress = &mcfgv1.MachineConfigPoolZoneStatus{Zone: zone}
+			byZone[zone] = progress
+		}
+		progress.MachineCount++
+		if updated[node.Name] {
+			progress.UpdatedMachineCount++
+		}
+	}
+	newStatus.Zones = nil
+	for _, progress := range byZone {
+		newStatus.Zones = append(newStatus.Zones, *progress)
+	}
+	sort.Slice(newStatus.Zones, func(i, j int) bool {
+		return newStatus.Zones[i].Zone < newStatus.Zones[j].Zone
+	})
+}
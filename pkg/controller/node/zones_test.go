@@ -0,0 +1,60 @@
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	core "k8s.io/client-go/testing"
+)
+
+func TestZoneProgress(t *testing.T) {
+	f := newFixture(t)
+	mcp := newMachineConfigPool("worker", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""), intStrPtr(intstr.FromInt(1)), "v1")
+	mcp.Spec.Paused = true
+	zoned := func(name, zone, config string) *corev1.Node {
+		labels := map[string]string{"node-role/worker": ""}
+		if zone != "" {
+			labels[zoneLabelKey] = zone
+		}
+		return newNodeWithLabel(name, config, config, labels)
+	}
+	nodes := []*corev1.Node{
+		zoned("node-0", "us-east-1a", "v1"),
+		zoned("node-1", "us-east-1a", "v1"),
+		zoned("node-2", "us-east-1b", "v1"),
+		zoned("node-3", "us-east-1b", "v0"),
+		zoned("node-4", "us-east-1c", "v0"),
+		zoned("node-5", "", "v1"),
+	}
+	// Nodes only carrying the deprecated zone label are counted as well.
+	nodes = append(nodes, newNodeWithLabel("node-6", "v0", "v0", map[string]string{"node-role/worker": "", corev1.LabelZoneFailureDomain: "us-east-1c"}))
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp)
+	f.nodeLister = append(f.nodeLister, nodes...)
+	c := f.newController()
+	if err := c.syncHandler(getKey(mcp, t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var status *mcfgv1.MachineConfigPoolStatus
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if update, ok := action.(core.UpdateAction); ok && action.GetSubresource() == "status" {
+			status = &update.GetObject().(*mcfgv1.MachineConfigPool).Status
+		}
+	}
+	if status == nil {
+		t.Fatalf("expected the status of the pool to be updated")
+	}
+	want := []mcfgv1.MachineConfigPoolZoneStatus{
+		{Zone: "us-east-1a", MachineCount: 2, UpdatedMachineCount: 2},
+		{Zone: "us-east-1b", MachineCount: 2, UpdatedMachineCount: 1},
+		{Zone: "us-east-1c", MachineCount: 2, UpdatedMachineCount: 0},
+	}
+	if !reflect.DeepEqual(status.Zones, want) {
+		t.Fatalf("mismatch zones: got %+v want: %+v", status.Zones, want)
+	}
+}
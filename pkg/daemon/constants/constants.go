@@ -12,6 +12,9 @@ const (
 	CurrentMachineConfigAnnotationKey = "machineconfiguration.openshift.io/currentConfig"
 	// DesiredMachineConfigAnnotationKey is used to specify the desired MachineConfig for a machine
 	DesiredMachineConfigAnnotationKey = "machineconfiguration.openshift.io/desiredConfig"
+	// PrePullMachineConfigAnnotationKey names the MachineConfig a machine is expected to update to next,
+	// so the daemon can pull its OS image ahead of the update.
+	PrePullMachineConfigAnnotationKey = "machineconfiguration.openshift.io/pre-pull"
 	// MachineConfigDaemonStateAnnotationKey is used to fetch the state of the daemon on the machine.
 	MachineConfigDaemonStateAnnotationKey = "machineconfiguration.openshift.io/state"
 	// MachineConfigDaemonStateWorking is set by daemon when it is applying an update.
@@ -332,6 +332,18 @@ rules:
 - apiGroups: [""]
   resources: ["configmaps", "secrets"]
   verbs: ["*"]
+- apiGroups: ["batch"]
+  resources: ["jobs"]
+  verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list", "watch", "delete"]
+- apiGroups: [""]
+  resources: ["pods/eviction"]
+  verbs: ["create"]
+- apiGroups: ["nodemaintenance.kubevirt.io"]
+  resources: ["nodemaintenances"]
+  verbs: ["get", "list", "watch"]
 - apiGroups: ["config.openshift.io"]
   resources: ["images", "clusterversions", "featuregates"]
   verbs: ["*"]